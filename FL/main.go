@@ -0,0 +1,410 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// 参与方
+type party struct {
+	i    int //表示第i个参与方
+	sk   *rlwe.SecretKey
+	data examples.Dataset // 参与方的本地训练数据
+
+	Thresholdizer     mhe.Thresholdizer
+	share             mhe.ShamirSecretShare
+	ShamirPoly        mhe.ShamirPolynomial
+	ShamirPublicPoint mhe.ShamirPublicPoint
+	mhe.Combiner
+}
+
+// 参数字面量，每轮的平均消耗一个层级，模型更新每轮重新加密。
+// 解密时各参与方加入标准差为MultipartySmudgingSigma的淹没噪声，2^55的尺度使其误差远小于1e-3，
+// 平均后剩余的两个素数为权重留出余量
+var paramsLiteral = hefloat.ParametersLiteral{
+	LogN:            14,
+	LogQ:            []int{60, 55, 55},
+	LogP:            []int{61},
+	LogDefaultScale: 55,
+}
+
+var flagN = flag.Int("n", 5, "the number of parties")
+var flagT = flag.Int("t", 0, "the threshold of the decryption, 0 for all the parties")
+var flagRounds = flag.Int("rounds", 10, "the number of federated averaging rounds")
+var flagEpochs = flag.Int("epochs", 5, "the number of local gradient descent steps of every party in a round")
+var flagRate = flag.Float64("rate", 1, "the learning rate of the local gradient descent")
+var flagSamples = flag.Int("samples", 2000, "the number of training samples of the synthetic dataset, split between the parties")
+var flagFeatures = flag.Int("features", 8, "the number of features of the synthetic dataset, the bias included")
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagParams = flag.String("params", "", "load the hefloat parameter literal from a JSON or YAML configuration file")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
+func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEFloatLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	cfg := config{
+		N:        *flagN,
+		t:        *flagT,
+		rounds:   *flagRounds,
+		epochs:   *flagEpochs,
+		rate:     *flagRate,
+		samples:  *flagSamples,
+		features: *flagFeatures,
+		literal:  literal,
+	}
+	if _, err := run(cfg); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N        int                       // 参与方数量
+	t        int                       // 门限，0表示全部参与方
+	rounds   int                       // 联邦平均的轮数
+	epochs   int                       // 每轮本地梯度下降的步数
+	rate     float64                   // 学习率
+	samples  int                       // 训练样本数，平均分给各参与方
+	features int                       // 特征数，包括偏置
+	literal  hefloat.ParametersLiteral // 参数字面量
+}
+
+// roundResult 一轮联邦平均后的模型
+type roundResult struct {
+	encrypted []float64 // 门限解密出的更新后的模型
+	plaintext []float64 // 明文联邦平均得到的模型
+	accuracy  float64   // 解密出的模型在测试集上的准确率
+	baseline  float64   // 明文联邦平均的模型在测试集上的准确率
+}
+
+// result 一次运行的数据集与各轮的模型
+type result struct {
+	params hefloat.Parameters
+	test   examples.Dataset // 测试集
+	rounds []roundResult
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、秘密共享、公钥生成和若干轮联邦平均。每轮各参与方从当前的全局模型出发
+// 在本地数据上训练，在集体公钥下加密模型更新；服务器同态地求更新的平均并加到全局模型上，只有更新后的模型经过门限解密，
+// 单个参与方的更新不被解密。同样的流程在明文下重复一次，作为比较的基准。
+func run(cfg config) (*result, error) {
+	N, t := cfg.N, cfg.t
+	if N < 2 {
+		return nil, fmt.Errorf("%d parties, expected at least 2", N)
+	}
+	if t <= 0 {
+		t = N
+	}
+	if t > N {
+		return nil, fmt.Errorf("threshold %d is larger than the %d parties", t, N)
+	}
+	if cfg.samples < N {
+		return nil, fmt.Errorf("%d samples for %d parties, expected at least one per party", cfg.samples, N)
+	}
+
+	var start, end time.Time
+	var duration time.Duration
+	var durationall time.Duration
+
+	//*****初始化参数*****
+	fmt.Println("> Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
+	start = time.Now()
+	params, err := hefloat.NewParametersFromLiteral(cfg.literal)
+	if err != nil {
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEFloatFingerprint(params))
+	// 模型的每个权重占一个槽位，平均需要一个层级用于重缩放
+	if cfg.features > params.MaxSlots() {
+		return nil, fmt.Errorf("%d features do not fit in %d slots", cfg.features, params.MaxSlots())
+	}
+	if params.MaxLevel() < 1 {
+		return nil, fmt.Errorf("the averaging needs 1 level, the parameters have %d", params.MaxLevel())
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Parameter initialization time: %s\n", duration)
+
+	//*****私钥生成*****
+	fmt.Println("> Private key generation Phase")
+	audit.Phase("Private key generation Phase")
+	start = time.Now()
+	parties := make([]*party, N)
+	for i := 0; i < N; i++ {
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
+		parties[i] = &party{i: i, sk: sk}
+		audit.PartyJoined(i)
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Private key generation time: %s\n", duration)
+
+	//*****秘密共享*****
+	fmt.Println("> Shamir Secret Share Phase")
+	audit.Phase("Shamir Secret Share Phase")
+	start = time.Now()
+	if t != N {
+		shamirPublicPoints := make([]mhe.ShamirPublicPoint, N)
+		for i, p := range parties {
+			seeded.With(i, "thresholdizer", func() { p.Thresholdizer = mhe.NewThresholdizer(params) })
+			p.share = p.Thresholdizer.AllocateThresholdSecretShare()
+			if p.ShamirPoly, err = p.Thresholdizer.GenShamirPolynomial(t, p.sk); err != nil {
+				return nil, err
+			}
+			p.ShamirPublicPoint = mhe.ShamirPublicPoint(i + 1)
+			shamirPublicPoints[i] = p.ShamirPublicPoint
+		}
+		for _, p := range parties {
+			p.Combiner = mhe.NewCombiner(*params.GetRLWEParameters(), p.ShamirPublicPoint, shamirPublicPoints, t)
+		}
+		// pi发给pj的份额写入同一个缓冲区后立即被pj聚合
+		share := parties[0].Thresholdizer.AllocateThresholdSecretShare()
+		for _, pi := range parties {
+			for _, pj := range parties {
+				pi.Thresholdizer.GenShamirSecretShare(pj.ShamirPublicPoint, pi.ShamirPoly, &share)
+				audit.ShareSent("shamir", examples.AuditShamirShare, pi.i, pj.i)
+				audit.ShareReceived("shamir", examples.AuditShamirShare, pj.i, pi.i)
+				if err := pj.Thresholdizer.AggregateShares(pj.share, share, &pj.share); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("share time: %s\n", duration)
+
+	//*****公钥生成*****
+	// 前t个参与方在线，用Shamir份额重构加性份额，其和为全部参与方私钥之和
+	fmt.Println("> Public key generation Phase")
+	audit.Phase("Public key generation Phase")
+	start = time.Now()
+	online := parties[:t]
+	if t != N {
+		points := make([]mhe.ShamirPublicPoint, t)
+		for i, p := range online {
+			points[i] = p.ShamirPublicPoint
+		}
+		for _, p := range online {
+			sk := rlwe.NewSecretKey(params)
+			if err := p.Combiner.GenAdditiveShare(points, p.ShamirPublicPoint, p.share, sk); err != nil {
+				return nil, err
+			}
+			p.sk = sk
+		}
+	}
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
+	crsSeed := seeded.Seed(-1, "crs")
+	audit.CRPDerived("pk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("creating CRS: %w", err)
+	}
+	crp := ckg.SampleCRP(crs)
+	pkShare := ckg.AllocateShare()
+	roundShare := ckg.AllocateShare()
+	for _, p := range online {
+		ckg.GenShare(p.sk, crp, &pkShare)
+		ckg.AggregateShares(pkShare, roundShare, &roundShare)
+		audit.ShareSent("pk", examples.TranscriptPublicKeyShare, p.i, -1)
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(roundShare, crp, pk)
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Public key generation time: %s\n", duration)
+
+	//*****数据集*****
+	// 内置的合成数据集，前samples个样本平均分给各参与方，其余四分之一作为测试集
+	fmt.Println("> Dataset Phase")
+	audit.Phase("Dataset Phase")
+	start = time.Now()
+	dataset, err := examples.SyntheticDataset(cfg.samples+cfg.samples/4, cfg.features)
+	if err != nil {
+		return nil, err
+	}
+	train := examples.Dataset{X: dataset.X[:cfg.samples], Y: dataset.Y[:cfg.samples]}
+	out := &result{params: params, test: examples.Dataset{X: dataset.X[cfg.samples:], Y: dataset.Y[cfg.samples:]}}
+	for i, data := range train.Split(N) {
+		parties[i].data = data
+	}
+	fmt.Printf("%d training samples, %d test samples, %d features\n", len(train.X), len(out.test.X), cfg.features)
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Dataset time: %s\n", duration)
+
+	//*****联邦平均*****
+	fmt.Println("> Federated averaging Phase")
+	audit.Phase("Federated averaging Phase")
+	start = time.Now()
+	encoder := hefloat.NewEncoder(params)
+	evaluator := examples.NewFederatedEvaluator(params)
+	model := make([]float64, cfg.features)    // 门限解密出的全局模型
+	baseline := make([]float64, cfg.features) // 明文联邦平均的全局模型
+	fmt.Printf("round 0\taccuracy %.4f\n", examples.LogisticAccuracy(model, out.test))
+	for r := 1; r <= cfg.rounds; r++ {
+		// 各参与方本地训练，加密模型更新
+		updates := make([]*rlwe.Ciphertext, N)
+		plainUpdates := make([][]float64, N)
+		for _, p := range parties {
+			update := examples.TrainLogistic(model, p.data, cfg.epochs, cfg.rate)
+			for j := range update {
+				update[j] -= model[j]
+			}
+			pt := hefloat.NewPlaintext(params, params.MaxLevel())
+			if err := encoder.Encode(update, pt); err != nil {
+				return nil, err
+			}
+			var encryptor *rlwe.Encryptor
+			seeded.With(p.i, fmt.Sprintf("encrypt/%d", r), func() { encryptor = rlwe.NewEncryptor(params, pk) })
+			if updates[p.i], err = encryptor.EncryptNew(pt); err != nil {
+				return nil, err
+			}
+			audit.ShareSent(fmt.Sprintf("round/%d", r), "model-update", p.i, -1)
+
+			plainUpdates[p.i] = examples.TrainLogistic(baseline, p.data, cfg.epochs, cfg.rate)
+			for j := range plainUpdates[p.i] {
+				plainUpdates[p.i][j] -= baseline[j]
+			}
+		}
+
+		// 服务器同态地求平均并更新全局模型，在线参与方门限解密更新后的模型
+		ct, err := evaluator.Average(updates, model)
+		if err != nil {
+			return nil, err
+		}
+		round := fmt.Sprintf("decrypt/round/%d", r)
+		values, err := decrypt(params, online, ct, round)
+		if err != nil {
+			return nil, err
+		}
+		audit.DecryptionReleased(round, -1, indices(online))
+		model = values[:cfg.features]
+
+		// 明文联邦平均
+		for _, update := range plainUpdates {
+			for j := range baseline {
+				baseline[j] += update[j] / float64(N)
+			}
+		}
+
+		res := newRoundResult(model, baseline, out.test)
+		out.rounds = append(out.rounds, res)
+		fmt.Printf("round %d\taccuracy %.4f\tplaintext %.4f\tmax weight error %.2e\n", r, res.accuracy, res.baseline, maxError(model, baseline))
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("联邦平均time: %s\n", duration)
+
+	fmt.Printf("model: %.4f\n", model)
+	fmt.Printf("All time: %s\n", durationall)
+	return out, nil
+}
+
+// newRoundResult 一轮的两个模型及其在测试集上的准确率
+func newRoundResult(model, baseline []float64, test examples.Dataset) roundResult {
+	return roundResult{
+		encrypted: append([]float64(nil), model...),
+		plaintext: append([]float64(nil), baseline...),
+		accuracy:  examples.LogisticAccuracy(model, test),
+		baseline:  examples.LogisticAccuracy(baseline, test),
+	}
+}
+
+// maxError 两个模型权重之差的最大绝对值
+func maxError(a, b []float64) (e float64) {
+	for j := range a {
+		e = math.Max(e, math.Abs(a[j]-b[j]))
+	}
+	return
+}
+
+// decrypt 每个在线参与方带淹没噪声地部分解密ct，聚合后完成解密并解码。
+// 平均时的重缩放改变了密文的尺度，解码时使用密文的尺度
+func decrypt(params hefloat.Parameters, parties []*party, ct *rlwe.Ciphertext, round string) ([]float64, error) {
+	pt, err := examples.SmudgedDecrypt(params, ct, secretKeys(parties), indices(parties), seeded, audit, round)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]float64, params.MaxSlots())
+	if err := hefloat.NewEncoder(params).Decode(pt, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// indices 参与方的编号
+func indices(parties []*party) []int {
+	idx := make([]int, len(parties))
+	for k, p := range parties {
+		idx[k] = p.i
+	}
+	return idx
+}
+
+// secretKeys 参与方的私钥
+func secretKeys(parties []*party) []*rlwe.SecretKey {
+	sks := make([]*rlwe.SecretKey, len(parties))
+	for k, p := range parties {
+		sks[k] = p.sk
+	}
+	return sks
+}
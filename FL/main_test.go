@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+func TestRun(t *testing.T) {
+	type runCase struct{ N, t int }
+	examples.RunTable(t, []runCase{{N: 2}, {N: 5, t: 3}}, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, t: tc.t, rounds: 5, epochs: 5, rate: 1, samples: 500, features: 6, literal: paramsLiteral})
+	}, func(t *testing.T, _ runCase, out *result) { checkResult(t, out) })
+
+	// 参数没有用于重缩放的层级，样本少于参与方
+	literal := paramsLiteral
+	literal.LogQ = literal.LogQ[:1]
+	if _, err := run(config{N: 2, rounds: 1, epochs: 1, rate: 1, samples: 100, features: 4, literal: literal}); err == nil {
+		t.Error("parameters without a level: no error")
+	}
+	if _, err := run(config{N: 4, rounds: 1, epochs: 1, rate: 1, samples: 3, features: 4, literal: paramsLiteral}); err == nil {
+		t.Error("fewer samples than parties: no error")
+	}
+}
+
+// checkResult 比较每轮门限解密出的模型与明文联邦平均的模型，并检查训练后的模型优于随机猜测
+func checkResult(t *testing.T, out *result) {
+	t.Helper()
+	for r, res := range out.rounds {
+		for j := range res.plaintext {
+			if d := res.encrypted[j] - res.plaintext[j]; d > 1e-3 || d < -1e-3 {
+				t.Errorf("round %d: weight %d decrypted %f, plaintext %f", r+1, j, res.encrypted[j], res.plaintext[j])
+			}
+		}
+		if d := res.accuracy - res.baseline; d > 0.01 || d < -0.01 {
+			t.Errorf("round %d: accuracy %.4f, plaintext %.4f", r+1, res.accuracy, res.baseline)
+		}
+	}
+	if last := out.rounds[len(out.rounds)-1]; last.accuracy < 0.65 {
+		t.Errorf("accuracy %.4f after %d rounds, want at least 0.65", last.accuracy, len(out.rounds))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// compareResult 两个参与方输入的同态比较的解密结果
+type compareResult struct {
+	bound uint64
+	equal []uint64 // 相等的槽位为1
+	less  []uint64 // 参与方a的输入小于参与方b的槽位为1
+}
+
+// compare 全部参与方生成集体重线性化密钥，Computer在参与方a和b的密文上计算相等和小于的指示向量，
+// 再由全部参与方分布式解密。输入必须在[0, bound)中。
+func compare(params heint.Parameters, parties []*party, a, b int, bound uint64) (*compareResult, error) {
+	for _, i := range []int{a, b} {
+		for _, v := range parties[i].input {
+			if v >= bound {
+				return nil, fmt.Errorf("party %d: input %d is not in [0, %d)", i, v, bound)
+			}
+		}
+	}
+	rlk, err := genRelinearizationKey(params, parties)
+	if err != nil {
+		return nil, err
+	}
+	computer := NewComputer(params).WithRelinearizationKey(rlk)
+	equal, err := computer.Equal(parties[a].ct, parties[b].ct, bound)
+	if err != nil {
+		return nil, err
+	}
+	less, err := computer.LessThan(parties[a].ct, parties[b].ct, bound)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &compareResult{bound: bound}
+	for _, r := range []struct {
+		name string
+		ct   *rlwe.Ciphertext
+		res  *[]uint64
+	}{{"equal", equal, &out.equal}, {"less", less, &out.less}} {
+		// 多项式求值后密文的层级降低，按密文层级分配部分解密的缓冲区
+		ptparts := make([]*rlwe.Plaintext, len(parties))
+		for i := range ptparts {
+			ptparts[i] = heint.NewPlaintext(params, r.ct.Level())
+		}
+		round := "compare/" + r.name
+		pt := decrypt(parties, ptparts, r.ct, parties[a].decryptor, 0, round)
+		audit.DecryptionReleased(round, -1, indices(parties))
+		// 重缩放改变了密文的尺度，解码时使用密文的尺度
+		pt.Scale = r.ct.Scale
+		*r.res = make([]uint64, params.MaxSlots())
+		if err := heint.NewEncoder(params).Decode(pt, *r.res); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// genRelinearizationKey 两轮的集体重线性化密钥生成：第一轮各参与方用临时私钥生成份额并聚合，
+// 第二轮各参与方基于第一轮的聚合结果生成份额并聚合
+func genRelinearizationKey(params heint.Parameters, parties []*party) (*rlwe.RelinearizationKey, error) {
+	var rkg mhe.RelinearizationKeyGenProtocol
+	seeded.With(-1, "rkg", func() { rkg = mhe.NewRelinearizationKeyGenProtocol(params) })
+	crsSeed := seeded.Seed(-1, "rlk-crs")
+	audit.CRPDerived("rlk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("creating CRS: %w", err)
+	}
+	crp := rkg.SampleCRP(crs)
+
+	ephSks := make([]*rlwe.SecretKey, len(parties))
+	round1 := make([]mhe.RelinearizationKeyGenShare, len(parties))
+	round2 := make([]mhe.RelinearizationKeyGenShare, len(parties))
+	for i := range parties {
+		ephSks[i], round1[i], round2[i] = rkg.AllocateShare()
+	}
+	_, agg1, agg2 := rkg.AllocateShare()
+	for i, p := range parties {
+		rkg.GenShareRoundOne(p.sk, crp, ephSks[i], &round1[i])
+		rkg.AggregateShares(round1[i], agg1, &agg1)
+		audit.ShareSent("rlk/1", "rlk-share", p.i, -1)
+	}
+	for i, p := range parties {
+		rkg.GenShareRoundTwo(ephSks[i], p.sk, agg1, &round2[i])
+		rkg.AggregateShares(round2[i], agg2, &agg2)
+		audit.ShareSent("rlk/2", "rlk-share", p.i, -1)
+	}
+	rlk := rlwe.NewRelinearizationKey(params)
+	rkg.GenRelinearizationKey(agg1, agg2, rlk)
+	return rlk, nil
+}
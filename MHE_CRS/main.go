@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"time"
 
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
 	"github.com/tuneinsight/lattigo/v5/he/heint"
 	"github.com/tuneinsight/lattigo/v5/mhe"
 	"github.com/tuneinsight/lattigo/v5/ring"
@@ -20,30 +22,175 @@ type party struct {
 	ct       *rlwe.Ciphertext
 	ptpart   *rlwe.Plaintext
 	input    []uint64
+
+	decryptor *rlwe.Decryptor // 复用的解密器
 }
 
 type Computer struct {
-	ringQ *ring.Ring
+	params heint.Parameters
+	ringQ  *ring.Ring
+	poly   *examples.PolynomialEvaluator           // 多项式求值器，需要集体重线性化密钥，未设置时为nil
+	matrix *examples.MatrixVectorEvaluator[uint64] // 矩阵向量积，需要集体Galois密钥，未设置时为nil
+}
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
 }
 
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagTranscript = flag.String("transcript", "", "record the transcript of the run to the given file, for the REPLAY tool")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagRecommend = flag.Bool("recommend", false, "use parameters recommended for the number of parties instead of the fixed literal")
+var flagK = flag.Int("k", 0, "the arity of the aggregation tree, 0 for linear aggregation at a single aggregator")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+var flagPIR = flag.Int("pir", -1, "privately retrieve the record with this index from the database, -1 to disable")
+var flagRecords = flag.Int("records", 16, "the number of records of the PIR database")
+var flagCompare = flag.Uint64("compare", 0, "compare the inputs of two parties homomorphically, the inputs being in [0, bound); 0 to disable")
+var flagMatrix = flag.Int("matrix", 0, "multiply a random public n×n matrix by the encrypted input of a party with the diagonal method; 0 to disable")
+
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// transcript 记录协议消息，未开启时为nil，记录操作为空操作
+var transcript *examples.Transcript
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
 func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -transcript时把协议的全部消息记录到文件，供回放工具检查
+	if *flagTranscript != "" {
+		var err error
+		if transcript, err = examples.CreateTranscript(*flagTranscript); err != nil {
+			fmt.Println("Error creating transcript:", err)
+			return
+		}
+		defer func() {
+			if err := transcript.Close(); err != nil {
+				fmt.Println("Error recording transcript:", err)
+			}
+		}()
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEIntLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	// -pir时在CRS流程之后执行一次私有信息检索
+	cfg := config{N: 40, literal: literal, recommend: *flagRecommend, k: *flagK}
+	if *flagPIR >= 0 {
+		cfg.records, cfg.index = *flagRecords, *flagPIR
+	}
+	cfg.compare = *flagCompare
+	cfg.matrix = *flagMatrix
+
+	//假设有N个参与方
+	if _, err := run(cfg); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N          int                     // 参与方数量
+	literal    heint.ParametersLiteral // 参数字面量
+	recommend  bool                    // 按参与方数量推荐参数，忽略literal
+	k          int                     // 聚合树的叉数
+	decryptors int                     // 参与解密的参与方数量，0表示全部参与方
+	records    int                     // PIR数据库的记录数，0表示不执行PIR
+	index      int                     // PIR检索的记录编号
+	compare    uint64                  // 比较的输入上界，0表示不执行比较
+	matrix     int                     // 矩阵向量积的矩阵维数，0表示不执行
+}
+
+// result 一次运行的输入与解密结果
+type result struct {
+	params    heint.Parameters
+	inputs    [][]uint64     // 各参与方的输入
+	decrypted [][]uint64     // 各参与方密文的解密结果
+	summands  [2]int         // 同态加法的两个参与方
+	sum       []uint64       // ctadd的解密结果
+	pir       *pirResult     // PIR的数据库和检索结果，未执行PIR时为nil
+	compare   *compareResult // 比较的解密结果，未执行比较时为nil
+	matrix    *matrixResult  // 矩阵向量积的解密结果，未执行时为nil
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、加密、同态加法和解密
+func run(cfg config) (*result, error) {
+	N := cfg.N
+	decryptors := N
+	if cfg.decryptors > 0 && cfg.decryptors < N {
+		decryptors = cfg.decryptors
+	}
+
 	var start, end time.Time
 	var duration time.Duration
 	var durationall time.Duration
 
 	//*****初始化参数*****
 	fmt.Println("> Parameter initialization Phase")
+	transcript.Phase("Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
 	start = time.Now()
-	// 创建参数字面量
-	params, err := heint.NewParametersFromLiteral(heint.ParametersLiteral{
-		LogN:             15,
-		LogQ:             []int{54, 54, 54},
-		LogP:             []int{55},
-		PlaintextModulus: 65537,
-	})
+	// -recommend时按参与方数量推荐参数
+	literal := cfg.literal
+	if cfg.recommend {
+		rec, err := examples.RecommendHEIntParameters(examples.MultipartyRequirements{Parties: N, Inputs: 2, PlaintextModulus: literal.PlaintextModulus, Security: 128})
+		if err != nil {
+			return nil, fmt.Errorf("recommending parameters: %w", err)
+		}
+		fmt.Print(rec)
+		literal = rec.Literal
+	}
+	params, err := heint.NewParametersFromLiteral(literal)
 	if err != nil {
-		fmt.Println("Error creating parameters:", err)
-		return
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	out := &result{params: params, inputs: make([][]uint64, N), decrypted: make([][]uint64, N)}
+	transcript.Params(examples.HEIntConfig("", literal))
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEIntFingerprint(params))
+	// 估计参数的安全性，低于128比特时给出警告
+	security := examples.EstimateSecurity(params)
+	fmt.Println("security:", security)
+	if !security.Meets(128) {
+		fmt.Println("Warning: the parameters are below 128-bit security")
 	}
 	// 打印参数信息
 	// fmt.Println("Parameters created successfully:", params)
@@ -54,23 +201,21 @@ func main() {
 
 	//*****私钥生成*****
 	fmt.Println("> Private key generation Phase")
+	transcript.Phase("Private key generation Phase")
+	audit.Phase("Private key generation Phase")
 	start = time.Now()
-	// 创建密钥生成器
-	kgen := rlwe.NewKeyGenerator(params)
 
 	//假设有N个参与方，自定义输入
 	// fmt.Println("输入参与方数量：")
-	N := 40
 	//_, err = fmt.Scanln(&N)
-	if err != nil {
-		fmt.Println("Error reading input:", err)
-		return
-	}
 
 	parties := make([]*party, N)
 	for i := 0; i < N; i++ {
-		sk := kgen.GenSecretKeyNew()
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
 		parties[i] = &party{i: i, sk: sk}
+		transcript.Record("keys", examples.TranscriptSecretKey, i, sk)
+		audit.PartyJoined(i)
 		//fmt.Printf("Party %d: Secret key generated successfully\n", i)
 	}
 	end = time.Now()
@@ -80,15 +225,20 @@ func main() {
 
 	//*****公钥生成*****
 	fmt.Println("> Public key generation Phase")
+	transcript.Phase("Public key generation Phase")
+	audit.Phase("Public key generation Phase")
 	start = time.Now()
 	// 创建公钥生成协议实例
-	ckg := mhe.NewPublicKeyGenProtocol(params)
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
 
-	// 生成CRS
-	crs, err := sampling.NewPRNG()
+	// 生成CRS，种子记录在transcript中以便回放
+	crsSeed := seeded.Seed(-1, "crs")
+	transcript.RecordBytes("pk", examples.TranscriptCRSSeed, -1, crsSeed)
+	audit.CRPDerived("pk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
 	if err != nil {
-		fmt.Println("Error creating CRS:", err)
-		return
+		return nil, fmt.Errorf("creating CRS: %w", err)
 	}
 	//从CRS中抽样记作CRP
 	crp := ckg.SampleCRP(crs)
@@ -96,16 +246,23 @@ func main() {
 	// fmt.Println("CRS generated successfully!")
 	// 生成公钥
 	pk := rlwe.NewPublicKey(params)
-	// 假设这是从参与方处接收到的最终聚合的共享
-	roundShare := ckg.AllocateShare()
-
+	// 每个参与方生成份额
+	pkShares := make([]mhe.PublicKeyGenShare, N)
 	for i := 0; i < N; i++ {
 		parties[i].shareOut = ckg.AllocateShare()
 		ckg.GenShare(parties[i].sk, crp, &parties[i].shareOut)
-		ckg.AggregateShares(parties[i].shareOut, roundShare, &roundShare)
+		pkShares[i] = parties[i].shareOut
+		transcript.Record("pk", examples.TranscriptPublicKeyShare, i, &parties[i].shareOut)
 	}
 
+	// 按k叉树聚合份额，根节点得到最终聚合的共享
+	roundShare := examples.AggregateTree(pkShares, cfg.k, ckg.AllocateShare, func(share mhe.PublicKeyGenShare, acc *mhe.PublicKeyGenShare) {
+		ckg.AggregateShares(share, *acc, acc)
+	})
+	examples.AuditTree(audit, "pk", examples.TranscriptPublicKeyShare, indices(parties), cfg.k)
+
 	ckg.GenPublicKey(roundShare, crp, pk)
+	transcript.Record("pk", examples.TranscriptPublicKey, -1, pk)
 	//公钥生成成功
 	// fmt.Println("Public key generated successfully!")
 	end = time.Now()
@@ -115,9 +272,10 @@ func main() {
 
 	//*****加密*****
 	fmt.Println("> Encrypt Phase")
+	transcript.Phase("Encrypt Phase")
+	audit.Phase("Encrypt Phase")
 	start = time.Now()
-	//初始化加密生成器和编码生成器
-	encryptor := rlwe.NewEncryptor(params, pk)
+	//初始化编码生成器
 	encoder := heint.NewEncoder(params)
 	//生成明文
 	for i := 0; i < N; i++ {
@@ -131,6 +289,7 @@ func main() {
 		for j := range parties[i].input {
 			parties[i].input[j] = uint64(i)
 		}
+		out.inputs[i] = parties[i].input
 		//编码
 		if err := encoder.Encode(parties[i].input, parties[i].pt); err != nil {
 			panic(err)
@@ -142,7 +301,9 @@ func main() {
 		fmt.Printf("参与方 %d加密\t%v...%v\n", i, res0[:8], res0[params.N()-8:]) //打印前八个元素和后八个元素
 
 		// fmt.Printf("Party %d: Plaintext encoded successfully!\n", i)
-		//加密
+		//加密，每个参与方使用自己的加密器
+		var encryptor *rlwe.Encryptor
+		seeded.With(i, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, pk) })
 		if err := encryptor.Encrypt(parties[i].pt, parties[i].ct); err != nil {
 			panic(err)
 		}
@@ -155,10 +316,21 @@ func main() {
 
 	//*****同态加法*****
 	fmt.Println("> Computation Phase")
+	transcript.Phase("Computation Phase")
+	audit.Phase("Computation Phase")
+	// 计算前预测解密后剩余的噪声预算，预算不足时告警
+	noiseModel := examples.NoiseModel{Params: params, Parties: N, Decryptors: decryptors}
+	prediction := noiseModel.Predict(2, params.MaxLevel())
+	if err := prediction.Err(); err != nil {
+		fmt.Println("Warning:", err)
+	}
 	start = time.Now()
 	ctadd := heint.NewCiphertext(params, 1, params.MaxLevel())
 	computer := NewComputer(params)
-	computer.Add(parties[1].ct, parties[2].ct, ctadd, N)
+	// 参与同态加法的两个参与方，N<3时回绕
+	a, b := 1%N, 2%N
+	out.summands = [2]int{a, b}
+	computer.Add(parties[a].ct, parties[b].ct, ctadd, N)
 	// fmt.Printf("The sum of parties[1].ct and parties[2].ct generated successfully!\n")
 
 	end = time.Now()
@@ -168,49 +340,36 @@ func main() {
 
 	//*****解密*****
 	fmt.Println("> Decrypt Phase")
+	transcript.Phase("Decrypt Phase")
+	audit.Phase("Decrypt Phase")
 	start = time.Now()
+	fmt.Printf("aggregation tree: arity %d, depth %d, root inbound %d\n", examples.TreeArity(decryptors, cfg.k), examples.TreeDepth(decryptors, cfg.k), examples.TreeRootInbound(decryptors, cfg.k))
+	ptparts := allocateDecryptionBuffers(params, parties)
+	// 只有前decryptors个参与方参与解密，少于N时解密结果错误
+	online, onlineparts := parties[:decryptors], ptparts[:decryptors]
+	res := make([]uint64, params.MaxSlots())
 	for j := 0; j < N; j++ {
-		//解密分额的和
-		hisigema := heint.NewPlaintext(params, params.MaxLevel())
-
-		for i := 0; i < N; i++ {
-			parties[i].ptpart = heint.NewPlaintext(params, params.MaxLevel())
-			decryptor := rlwe.NewDecryptor(params, parties[i].sk)
-			decryptor.Decryptpart(parties[j].ct, parties[i].ptpart) //部分解密
-
-			decryptor.Decryptadd(parties[i].ptpart, hisigema) //求和
-		}
-		decryptor := rlwe.NewDecryptor(params, parties[j].sk)
-		decryptor.Decryptall(parties[j].ct, hisigema) //全部解密
+		hisigema := decrypt(online, onlineparts, parties[j].ct, parties[j].decryptor, cfg.k, fmt.Sprintf("decrypt/%d", j))
 
-		res := make([]uint64, params.MaxSlots())
 		if err := encoder.Decode(hisigema, res); err != nil {
 			panic(err)
 		}
 		fmt.Printf("参与方 %d解密得\t%v...%v\n", j, res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+		out.decrypted[j] = append([]uint64(nil), res...)
+		audit.DecryptionReleased(fmt.Sprintf("decrypt/%d", j), j, indices(online))
 
 		// fmt.Printf("Party %d: NewPlaintext generated successfully!\n", j)
 	}
 
 	//*****同态加法解密*****
-	//解密份额的和
-	hisigema := heint.NewPlaintext(params, params.MaxLevel())
-
-	for i := 0; i < N; i++ {
-		ptaddpart := heint.NewPlaintext(params, params.MaxLevel())
-		decryptor := rlwe.NewDecryptor(params, parties[i].sk)
-		decryptor.Decryptpart(ctadd, ptaddpart) //部分解密
-
-		decryptor.Decryptadd(ptaddpart, hisigema) //求和
-	}
-	decryptor := rlwe.NewDecryptor(params, parties[1].sk)
-	decryptor.Decryptall(ctadd, hisigema) //全部解密
+	hisigema := decrypt(online, onlineparts, ctadd, parties[a].decryptor, cfg.k, "decrypt/ctadd")
+	audit.DecryptionReleased("decrypt/ctadd", a, indices(online))
 
-	res := make([]uint64, params.MaxSlots())
 	if err := encoder.Decode(hisigema, res); err != nil {
 		panic(err)
 	}
 	fmt.Printf("ctadd 解密得%v...%v\n", res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+	out.sum = append([]uint64(nil), res...)
 
 	// fmt.Printf("Party %d: NewPlaintext generated successfully!\n", j)
 	end = time.Now()
@@ -218,15 +377,104 @@ func main() {
 	durationall += duration
 	fmt.Printf("解密time: %s\n", duration)
 
+	//*****私有信息检索*****
+	if cfg.records > 0 {
+		fmt.Println("> PIR Phase")
+		transcript.Phase("PIR Phase")
+		audit.Phase("PIR Phase")
+		start = time.Now()
+		if out.pir, err = retrieve(params, parties, pk, cfg.records, cfg.index); err != nil {
+			return nil, err
+		}
+		fmt.Printf("记录 %d检索得\t%v...\n", cfg.index, out.pir.record[:min(8, len(out.pir.record))])
+		end = time.Now()
+		duration = end.Sub(start)
+		durationall += duration
+		fmt.Printf("PIR time: %s\n", duration)
+	}
+
+	//*****同态比较*****
+	if cfg.compare > 0 {
+		fmt.Println("> Comparison Phase")
+		transcript.Phase("Comparison Phase")
+		audit.Phase("Comparison Phase")
+		start = time.Now()
+		if out.compare, err = compare(params, parties, a, b, cfg.compare); err != nil {
+			return nil, err
+		}
+		fmt.Printf("参与方 %d == 参与方 %d\t%v...\n", a, b, out.compare.equal[:8])
+		fmt.Printf("参与方 %d < 参与方 %d\t%v...\n", a, b, out.compare.less[:8])
+		end = time.Now()
+		duration = end.Sub(start)
+		durationall += duration
+		fmt.Printf("比较time: %s\n", duration)
+	}
+
+	//*****矩阵向量积*****
+	if cfg.matrix > 0 {
+		fmt.Println("> Matrix-vector product Phase")
+		transcript.Phase("Matrix-vector product Phase")
+		audit.Phase("Matrix-vector product Phase")
+		start = time.Now()
+		if out.matrix, err = mulMatrix(params, parties, a, cfg.matrix); err != nil {
+			return nil, err
+		}
+		fmt.Printf("矩阵 × 参与方 %d\t%v...\n", a, out.matrix.product[:min(8, cfg.matrix)])
+		end = time.Now()
+		duration = end.Sub(start)
+		durationall += duration
+		fmt.Printf("矩阵time: %s\n", duration)
+	}
+
 	fmt.Printf("All time: %s\n", durationall)
+
+	//*****噪声分析*****
+	// 模拟中已知所有私钥，测量实际噪声并与理论估计比较
+	fmt.Println("> Noise analysis")
+	transcript.Phase("Noise analysis")
+	audit.Phase("Noise analysis")
+	sks := make([]*rlwe.SecretKey, 0, len(parties))
+	for _, p := range parties {
+		sks = append(sks, p.sk)
+	}
+	skAgg := examples.SumSecretKeys(params, sks...)
+	noiseFresh, err := examples.CiphertextNoise(params, parties[a].ct, parties[a].pt, skAgg)
+	if err != nil {
+		return nil, err
+	}
+	printNoise(params, "fresh", parties[a].ct.Level(), noiseFresh, prediction.Fresh)
+	// ctadd的期望明文
+	sum := make([]uint64, params.N())
+	for k := range sum {
+		sum[k] = (parties[a].input[k] + parties[b].input[k]) % params.PlaintextModulus()
+	}
+	ptadd := heint.NewPlaintext(params, ctadd.Level())
+	ptadd.Scale = ctadd.Scale
+	if err := encoder.Encode(sum, ptadd); err != nil {
+		panic(err)
+	}
+	noiseAdd, err := examples.CiphertextNoise(params, ctadd, ptadd, skAgg)
+	if err != nil {
+		return nil, err
+	}
+	printNoise(params, "ctadd", ctadd.Level(), noiseAdd, prediction.Computed)
+	printNoise(params, "decrypted ctadd", ctadd.Level(), examples.PlaintextNoise(params, hisigema, ptadd), prediction.Decrypted)
+	return out, nil
 }
 
 func NewComputer(params heint.Parameters) *Computer {
 	return &Computer{
-		ringQ: params.RingQ(),
+		params: params,
+		ringQ:  params.RingQ(),
 	}
 }
 
+// WithRelinearizationKey 返回使用集体重线性化密钥rlk的Computer，密文乘法和多项式求值需要它
+func (c Computer) WithRelinearizationKey(rlk *rlwe.RelinearizationKey) *Computer {
+	c.poly = examples.NewPolynomialEvaluator(c.params, rlk)
+	return &c
+}
+
 func (c Computer) Add(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctadd *rlwe.Ciphertext, N int) {
 	level := ct1.Level()
 	ringQ := c.ringQ.AtLevel(level)
@@ -234,3 +482,187 @@ func (c Computer) Add(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctadd *rlwe.Ci
 		ringQ.Add(ct1.Value[i], ct2.Value[i], ctadd.Value[i])
 	}
 }
+
+// WithGaloisKeys 返回使用集体Galois密钥gks的Computer，矩阵向量积的旋转需要它们
+func (c Computer) WithGaloisKeys(gks []*rlwe.GaloisKey) *Computer {
+	c.matrix = examples.NewHEIntMatrixVectorEvaluator(c.params, rlwe.NewMemEvaluationKeySet(nil, gks...))
+	return &c
+}
+
+// MulMatrix 用对角线方法计算公开矩阵m与加密向量之积，baby-step giant-step旋转需要
+// examples.MatrixVectorRotations给出的旋转的集体Galois密钥。向量须按矩阵维数的周期复制在槽位上
+func (c Computer) MulMatrix(m [][]uint64, ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	if c.matrix == nil {
+		return nil, fmt.Errorf("matrix-vector products need the collective Galois keys, see WithGaloisKeys")
+	}
+	return c.matrix.Mul(m, ct)
+}
+
+// Sub 密文相减，ctsub = ct1 - ct2
+func (c Computer) Sub(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctsub *rlwe.Ciphertext) {
+	level := ct1.Level()
+	ringQ := c.ringQ.AtLevel(level)
+	for i := 0; i < len(ct1.Value); i++ {
+		ringQ.Sub(ct1.Value[i], ct2.Value[i], ctsub.Value[i])
+	}
+	*ctsub.MetaData = *ct1.MetaData
+}
+
+// Neg 密文取负，ctneg = -ct
+func (c Computer) Neg(ct *rlwe.Ciphertext, ctneg *rlwe.Ciphertext) {
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.Neg(ct.Value[i], ctneg.Value[i])
+	}
+	*ctneg.MetaData = *ct.MetaData
+}
+
+// MulScalar 密文的每个槽位乘以同一个公开的整数，scalar按明文模数约简，尺度不变
+func (c Computer) MulScalar(ct *rlwe.Ciphertext, scalar uint64, ctmul *rlwe.Ciphertext) {
+	scalar %= c.params.PlaintextModulus()
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.MulScalar(ct.Value[i], scalar, ctmul.Value[i])
+	}
+	*ctmul.MetaData = *ct.MetaData
+}
+
+// AddPlain 密文加明文，明文只加到c0上。明文的尺度须与密文相同(编码前设置pt.Scale = ct.Scale)
+func (c Computer) AddPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctadd *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot AddPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ringQ.Add(ct.Value[0], pt.Value, ctadd.Value[0])
+	for i := 1; i < len(ct.Value); i++ {
+		ctadd.Value[i].Copy(ct.Value[i])
+	}
+	*ctadd.MetaData = *ct.MetaData
+	return nil
+}
+
+// SubPlain 密文减明文，明文只从c0中减去，尺度要求与AddPlain相同
+func (c Computer) SubPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctsub *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot SubPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ringQ.Sub(ct.Value[0], pt.Value, ctsub.Value[0])
+	for i := 1; i < len(ct.Value); i++ {
+		ctsub.Value[i].Copy(ct.Value[i])
+	}
+	*ctsub.MetaData = *ct.MetaData
+	return nil
+}
+
+// MulPlain 密文逐槽位乘以明文，结果写入ctmul，尺度为两者尺度之积。
+// 密文和明文都在NTT域，明文先转为Montgomery形式再逐系数相乘
+func (c Computer) MulPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctmul *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, false); err != nil {
+		return fmt.Errorf("cannot MulPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ptm := ringQ.NewPoly()
+	ringQ.MForm(pt.Value, ptm)
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.MulCoeffsMontgomery(ct.Value[i], ptm, ctmul.Value[i])
+	}
+	*ctmul.MetaData = *ct.MetaData
+	ctmul.Scale = ct.Scale.Mul(pt.Scale)
+	return nil
+}
+
+// checkPlaintext 明文须与密文同在NTT域且层级不低于密文；加减时尺度还须与密文相同
+func checkPlaintext(md *rlwe.MetaData, level int, pt *rlwe.Plaintext, sameScale bool) error {
+	if pt.Level() < level {
+		return fmt.Errorf("plaintext at level %d, below the ciphertext at level %d", pt.Level(), level)
+	}
+	if pt.IsNTT != md.IsNTT {
+		return fmt.Errorf("plaintext in NTT domain: %v, ciphertext: %v", pt.IsNTT, md.IsNTT)
+	}
+	if sameScale && !pt.Scale.Equal(md.Scale) {
+		return fmt.Errorf("plaintext scale %v, ciphertext scale %v: encode the plaintext with pt.Scale = ct.Scale", pt.Scale.Uint64(), md.Scale.Uint64())
+	}
+	return nil
+}
+
+// EvaluatePolynomial 在密文的每个槽位上计算Z_t上的多项式，coeffs按次数递增排列。
+// 多项式次数为d时消耗ceil(log2(d))个层级，层级不足时返回的错误给出所需的层级数
+func (c Computer) EvaluatePolynomial(ct *rlwe.Ciphertext, coeffs []uint64) (*rlwe.Ciphertext, error) {
+	if c.poly == nil {
+		return nil, fmt.Errorf("polynomial evaluation needs the collective relinearization key, see WithRelinearizationKey")
+	}
+	return c.poly.Evaluate(ct, coeffs)
+}
+
+// Equal 逐槽位比较相等，相等的槽位为1，其余为0。输入在[0, bound)中时多项式次数为2*bound-2；
+// bound为0时输入可以是Z_t中任意值，使用费马小定理1-(x-y)^(t-1)，t=65537时需要16个层级
+func (c Computer) Equal(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, bound uint64) (*rlwe.Ciphertext, error) {
+	coeffs, err := examples.EqualPolynomial(c.params.PlaintextModulus(), bound)
+	if err != nil {
+		return nil, err
+	}
+	return c.evaluateDifference(ct1, ct2, coeffs)
+}
+
+// LessThan 逐槽位比较大小，ct1小于ct2的槽位为1，其余为0。输入必须在[0, bound)中，bound不超过(t+1)/2，
+// 多项式次数为2*bound-2，消耗ceil(log2(2*bound-2))个层级
+func (c Computer) LessThan(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, bound uint64) (*rlwe.Ciphertext, error) {
+	coeffs, err := examples.LessThanPolynomial(c.params.PlaintextModulus(), bound)
+	if err != nil {
+		return nil, err
+	}
+	return c.evaluateDifference(ct1, ct2, coeffs)
+}
+
+// evaluateDifference 在ct1-ct2上计算多项式
+func (c Computer) evaluateDifference(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, coeffs []uint64) (*rlwe.Ciphertext, error) {
+	diff := ct1.CopyNew()
+	c.Sub(ct1, ct2, diff)
+	return c.EvaluatePolynomial(diff, coeffs)
+}
+
+// printNoise 打印实测噪声、理论估计和剩余的噪声预算
+func printNoise(params heint.Parameters, label string, level int, measured, predicted float64) {
+	fmt.Printf("%s noise: measured 2^%.2f, predicted 2^%.2f, budget %.2f bits\n", label, measured, predicted, examples.NoiseBudget(params, level, measured))
+}
+
+// allocateDecryptionBuffers 为每个参与方创建一次解密器和部分解密的缓冲区，
+// 之后所有密文的解密都复用它们
+func allocateDecryptionBuffers(params heint.Parameters, parties []*party) []*rlwe.Plaintext {
+	ptparts := make([]*rlwe.Plaintext, len(parties))
+	for i, p := range parties {
+		p.decryptor = rlwe.NewDecryptor(params, p.sk)
+		p.ptpart = heint.NewPlaintext(params, params.MaxLevel())
+		ptparts[i] = p.ptpart
+	}
+	return ptparts
+}
+
+// decrypt 各参与方对ct部分解密并写入自己的缓冲区，份额按k叉树原地求和，
+// 最后由dec完成解密。返回的明文是参与方0的缓冲区，下一次调用会覆盖它。
+func decrypt(parties []*party, ptparts []*rlwe.Plaintext, ct *rlwe.Ciphertext, dec *rlwe.Decryptor, k int, round string) *rlwe.Plaintext {
+	transcript.Record(round, examples.TranscriptCiphertext, -1, ct)
+	for i, p := range parties {
+		p.decryptor.Decryptpart(ct, ptparts[i]) //部分解密
+		transcript.Record(round, examples.TranscriptPartialDecryption, p.i, ptparts[i])
+		audit.PartialDecryption(round, p.i)
+	}
+	hisigema := examples.AggregateTree(ptparts, k, nil, func(share *rlwe.Plaintext, acc **rlwe.Plaintext) {
+		dec.Decryptadd(share, *acc) //求和
+	})
+	examples.AuditTree(audit, round, examples.TranscriptPartialDecryption, indices(parties), k)
+	transcript.Record(round, examples.TranscriptDecryptionSum, -1, hisigema)
+	dec.Decryptall(ct, hisigema) //全部解密
+	transcript.Record(round, examples.TranscriptDecryption, -1, hisigema)
+	return hisigema
+}
+
+// indices 参与方的编号
+func indices(parties []*party) []int {
+	idx := make([]int, len(parties))
+	for k, p := range parties {
+		idx[k] = p.i
+	}
+	return idx
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// TestRun ok为false时参与解密的参与方不足，都不应解密出正确的明文
+func TestRun(t *testing.T) {
+	type runCase struct {
+		N, decryptors int
+		ok            bool
+	}
+	cases := []runCase{
+		{N: 2, ok: true},
+		{N: 3, ok: true},
+		{N: 10, ok: true},
+		{N: 3, decryptors: 2},
+		{N: 10, decryptors: 9},
+	}
+	examples.RunTable(t, cases, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, literal: examples.HEIntParamsN12QP109, decryptors: tc.decryptors})
+	}, func(t *testing.T, tc runCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, tc.ok, tc.ok)
+	})
+}
+
+func TestPIR(t *testing.T) {
+	type pirCase struct{ N, records, index int }
+	cases := []pirCase{
+		{N: 2, records: 1, index: 0},
+		{N: 3, records: 16, index: 5},
+		{N: 10, records: 2048, index: 2047},
+	}
+	examples.RunTable(t, cases, func(tc pirCase) (*result, error) {
+		return run(config{N: tc.N, literal: examples.HEIntParamsN12QP109, records: tc.records, index: tc.index})
+	}, func(t *testing.T, tc pirCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, true, true)
+		if !reflect.DeepEqual(out.pir.record, out.pir.database[tc.index]) {
+			t.Errorf("record %d: decrypted %v..., want %v...", tc.index, out.pir.record[:1], out.pir.database[tc.index][:1])
+		}
+		// 选中记录之外的槽位不泄露数据库的内容
+		L := len(out.pir.record)
+		for k, v := range out.pir.decrypted {
+			if (k < tc.index*L || k >= (tc.index+1)*L) && v != 0 {
+				t.Fatalf("slot %d outside the record: decrypted %d, want 0", k, v)
+			}
+		}
+	})
+
+	if _, err := run(config{N: 2, literal: examples.HEIntParamsN12QP109, records: 4, index: 4}); err == nil {
+		t.Error("record outside the database: no error")
+	}
+
+	// 查询不带格式正确的证明：半诚实客户端的假设被违反时，全1的查询解密出整个数据库
+	params, err := heint.NewParametersFromLiteral(examples.HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parties, pk := benchmarkParties(t, params, 3)
+	ones := make([]uint64, params.MaxSlots())
+	for k := range ones {
+		ones[k] = 1
+	}
+	out, err := retrieveSelection(params, parties, pk, 16, 0, ones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	L := params.MaxSlots() / 16
+	for r, row := range out.database {
+		if !reflect.DeepEqual(out.decrypted[r*L:(r+1)*L], row) {
+			t.Fatalf("malformed query: record %d is not decrypted", r)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	type compareCase struct{ N int }
+	examples.RunTable(t, []compareCase{{N: 2}, {N: 3}}, func(tc compareCase) (*result, error) {
+		return run(config{N: tc.N, literal: examples.HEIntParamsN13QP218, compare: 8})
+	}, func(t *testing.T, _ compareCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, true, true)
+		x, y := out.inputs[out.summands[0]], out.inputs[out.summands[1]]
+		for k := range x {
+			if out.compare.equal[k] != b2u(x[k] == y[k]) || out.compare.less[k] != b2u(x[k] < y[k]) {
+				t.Fatalf("slot %d: %d == %d decrypted %d, %d < %d decrypted %d", k, x[k], y[k], out.compare.equal[k], x[k], y[k], out.compare.less[k])
+			}
+		}
+	})
+
+	// 参与方的输入超出比较的上界
+	if _, err := run(config{N: 3, literal: examples.HEIntParamsN13QP218, compare: 2}); err == nil {
+		t.Error("input outside [0, bound): no error")
+	}
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestMatrix(t *testing.T) {
+	type matrixCase struct{ N, n int }
+	examples.RunTable(t, []matrixCase{{N: 2, n: 4}, {N: 3, n: 12}}, func(tc matrixCase) (*result, error) {
+		return run(config{N: tc.N, literal: examples.HEIntParamsN12QP109, matrix: tc.n})
+	}, func(t *testing.T, _ matrixCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, true, true)
+		T := out.params.PlaintextModulus()
+		x := out.inputs[out.matrix.party]
+		for r, row := range out.matrix.matrix {
+			var want uint64
+			for c, v := range row {
+				want = (want + v*x[c]) % T
+			}
+			if out.matrix.product[r] != want {
+				t.Errorf("entry %d: decrypted %d, want %d", r, out.matrix.product[r], want)
+			}
+		}
+	})
+
+	// 矩阵维数超出一行的槽位数
+	if _, err := run(config{N: 2, literal: examples.HEIntParamsN12QP109, matrix: 4096}); err == nil {
+		t.Error("matrix larger than the rows: no error")
+	}
+}
+
+func TestComputer(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(examples.HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := params.PlaintextModulus()
+	sk := rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	encoder := heint.NewEncoder(params)
+	encryptor := rlwe.NewEncryptor(params, sk)
+	decryptor := rlwe.NewDecryptor(params, sk)
+	x, y, w := make([]uint64, params.MaxSlots()), make([]uint64, params.MaxSlots()), make([]uint64, params.MaxSlots())
+	for k := range x {
+		x[k], y[k], w[k] = uint64(k)%T, uint64(3*k+1)%T, uint64(k%7)
+	}
+	encode := func(v []uint64) *rlwe.Plaintext {
+		pt := heint.NewPlaintext(params, params.MaxLevel())
+		if err := encoder.Encode(v, pt); err != nil {
+			t.Fatal(err)
+		}
+		return pt
+	}
+	encrypt := func(v []uint64) *rlwe.Ciphertext {
+		ct, err := encryptor.EncryptNew(encode(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ct
+	}
+	ctx, cty, ptw := encrypt(x), encrypt(y), encode(w)
+
+	computer := NewComputer(params)
+	out := heint.NewCiphertext(params, 1, params.MaxLevel())
+	for _, tc := range []struct {
+		name string
+		op   func() error
+		f    func(x, y, w uint64) uint64
+	}{
+		{"Sub", func() error { computer.Sub(ctx, cty, out); return nil }, func(x, y, w uint64) uint64 { return (x + T - y) % T }},
+		{"Neg", func() error { computer.Neg(ctx, out); return nil }, func(x, y, w uint64) uint64 { return (T - x) % T }},
+		{"MulScalar", func() error { computer.MulScalar(ctx, T+5, out); return nil }, func(x, y, w uint64) uint64 { return 5 * x % T }},
+		{"AddPlain", func() error { return computer.AddPlain(ctx, ptw, out) }, func(x, y, w uint64) uint64 { return (x + w) % T }},
+		{"SubPlain", func() error { return computer.SubPlain(ctx, ptw, out) }, func(x, y, w uint64) uint64 { return (x + T - w) % T }},
+		{"MulPlain", func() error { return computer.MulPlain(ctx, ptw, out) }, func(x, y, w uint64) uint64 { return x * w % T }},
+	} {
+		if err := tc.op(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got := make([]uint64, params.MaxSlots())
+		if err := encoder.Decode(decryptor.DecryptNew(out), got); err != nil {
+			t.Fatal(err)
+		}
+		for k := range got {
+			if want := tc.f(x[k], y[k], w[k]); got[k] != want {
+				t.Fatalf("%s: slot %d decrypted %d, want %d", tc.name, k, got[k], want)
+			}
+		}
+	}
+
+	// 尺度或层级与密文不一致的明文
+	ptScaled := encode(w)
+	ptScaled.Scale = params.NewScale(2)
+	if err := computer.AddPlain(ctx, ptScaled, out); err == nil {
+		t.Error("AddPlain: plaintext with another scale: no error")
+	}
+	if err := computer.MulPlain(ctx, heint.NewPlaintext(params, 0), out); err == nil {
+		t.Error("MulPlain: plaintext below the ciphertext level: no error")
+	}
+}
+
+// benchmarkParties 生成N个参与方的私钥、聚合公钥，并加密每个参与方的输入
+func benchmarkParties(b testing.TB, params heint.Parameters, N int) ([]*party, *rlwe.PublicKey) {
+	kgen := rlwe.NewKeyGenerator(params)
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	crs, err := sampling.NewPRNG()
+	if err != nil {
+		b.Fatal(err)
+	}
+	crp := ckg.SampleCRP(crs)
+
+	parties := make([]*party, N)
+	roundShare := ckg.AllocateShare()
+	for i := range parties {
+		parties[i] = &party{i: i, sk: kgen.GenSecretKeyNew()}
+		parties[i].shareOut = ckg.AllocateShare()
+		ckg.GenShare(parties[i].sk, crp, &parties[i].shareOut)
+		ckg.AggregateShares(parties[i].shareOut, roundShare, &roundShare)
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(roundShare, crp, pk)
+
+	encryptor := rlwe.NewEncryptor(params, pk)
+	encoder := heint.NewEncoder(params)
+	for i, p := range parties {
+		p.input = make([]uint64, params.N())
+		for j := range p.input {
+			p.input[j] = uint64(i)
+		}
+		p.pt = heint.NewPlaintext(params, params.MaxLevel())
+		if err := encoder.Encode(p.input, p.pt); err != nil {
+			b.Fatal(err)
+		}
+		p.ct = heint.NewCiphertext(params, 1, params.MaxLevel())
+		if err := encryptor.Encrypt(p.pt, p.ct); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return parties, pk
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	params, err := heint.NewParametersFromLiteral(paramsLiteral)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, N := range []int{10, 40} {
+		parties, _ := benchmarkParties(b, params, N)
+
+		// 改造前：每个(i, j)都新建解密器和部分解密的明文
+		b.Run(fmt.Sprintf("Alloc/LogN=%d/N=%d", params.LogN(), N), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				for j := 0; j < N; j++ {
+					hisigema := heint.NewPlaintext(params, params.MaxLevel())
+					for i := 0; i < N; i++ {
+						ptpart := heint.NewPlaintext(params, params.MaxLevel())
+						decryptor := rlwe.NewDecryptor(params, parties[i].sk)
+						decryptor.Decryptpart(parties[j].ct, ptpart)
+						decryptor.Decryptadd(ptpart, hisigema)
+					}
+					decryptor := rlwe.NewDecryptor(params, parties[j].sk)
+					decryptor.Decryptall(parties[j].ct, hisigema)
+				}
+			}
+		})
+
+		// 改造后：复用每个参与方的解密器和缓冲区
+		b.Run(fmt.Sprintf("Reuse/LogN=%d/N=%d", params.LogN(), N), func(b *testing.B) {
+			ptparts := allocateDecryptionBuffers(params, parties)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				for j := 0; j < N; j++ {
+					decrypt(parties, ptparts, parties[j].ct, parties[j].decryptor, 0, "")
+				}
+			}
+		})
+	}
+}
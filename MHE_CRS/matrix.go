@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// matrixResult 公开矩阵与参与方加密向量之积的解密结果
+type matrixResult struct {
+	matrix  [][]uint64 // 公开的权重矩阵
+	party   int        // 输入向量所属的参与方
+	product []uint64   // 解密出的矩阵向量积，长度为矩阵的行数
+}
+
+// mulMatrix 全部参与方为对角线方法的旋转生成集体Galois密钥，Computer把公开的n×n随机矩阵乘以参与方a的加密输入，
+// 再由全部参与方分布式解密。
+func mulMatrix(params heint.Parameters, parties []*party, a, n int) (*matrixResult, error) {
+	// 加密的输入须以填充后的矩阵维数为周期，旋转才相当于长度为该维数的循环移位
+	period := examples.MatrixVectorDimension(n, n)
+	if period > params.MaxSlots()/2 {
+		return nil, fmt.Errorf("a %dx%d matrix does not fit in the rows of %d slots", n, n, params.MaxSlots()/2)
+	}
+	for k, v := range parties[a].input {
+		if v != parties[a].input[k%period] {
+			return nil, fmt.Errorf("party %d: the input does not repeat with period %d", a, period)
+		}
+	}
+	prng, err := seeded.PRNG(-1, "matrix")
+	if err != nil {
+		return nil, err
+	}
+	out := &matrixResult{matrix: randomMatrix(prng, n, n, params.PlaintextModulus()), party: a}
+
+	galEls := params.GaloisElements(examples.MatrixVectorRotations(n, n))
+	fmt.Printf("matrix %dx%d: %d rotations\n", n, n, len(galEls))
+	gks, err := genGaloisKeys(params, parties, galEls)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := NewComputer(params).WithGaloisKeys(gks).MulMatrix(out.matrix, parties[a].ct)
+	if err != nil {
+		return nil, err
+	}
+
+	ptparts := make([]*rlwe.Plaintext, len(parties))
+	for i := range ptparts {
+		ptparts[i] = heint.NewPlaintext(params, ct.Level())
+	}
+	pt := decrypt(parties, ptparts, ct, parties[a].decryptor, 0, "matrix")
+	audit.DecryptionReleased("matrix", -1, indices(parties))
+	pt.Scale = ct.Scale
+	res := make([]uint64, params.MaxSlots())
+	if err := heint.NewEncoder(params).Decode(pt, res); err != nil {
+		return nil, err
+	}
+	out.product = res[:n]
+	return out, nil
+}
+
+// genGaloisKeys 集体Galois密钥生成：对每个Galois元素，各参与方用自己的私钥和公共CRP生成份额并聚合
+func genGaloisKeys(params heint.Parameters, parties []*party, galEls []uint64) ([]*rlwe.GaloisKey, error) {
+	var gkg mhe.GaloisKeyGenProtocol
+	seeded.With(-1, "gkg", func() { gkg = mhe.NewGaloisKeyGenProtocol(params) })
+	share := gkg.AllocateShare()
+	gks := make([]*rlwe.GaloisKey, len(galEls))
+	for k, galEl := range galEls {
+		round := fmt.Sprintf("gk/%d", galEl)
+		crsSeed := seeded.Seed(-1, round)
+		audit.CRPDerived(round, -1, crsSeed)
+		crs, err := sampling.NewKeyedPRNG(crsSeed)
+		if err != nil {
+			return nil, fmt.Errorf("creating CRS: %w", err)
+		}
+		crp := gkg.SampleCRP(crs)
+		agg := gkg.AllocateShare()
+		for _, p := range parties {
+			if err := gkg.GenShare(p.sk, galEl, crp, &share); err != nil {
+				return nil, err
+			}
+			if err := gkg.AggregateShares(share, agg, &agg); err != nil {
+				return nil, err
+			}
+			audit.ShareSent(round, "gk-share", p.i, -1)
+		}
+		gks[k] = rlwe.NewGaloisKey(params)
+		if err := gkg.GenGaloisKey(agg, crp, gks[k]); err != nil {
+			return nil, err
+		}
+	}
+	return gks, nil
+}
+
+// randomMatrix 随机的rows×cols矩阵，元素由16比特随机数对T取模得到
+func randomMatrix(prng sampling.PRNG, rows, cols int, T uint64) [][]uint64 {
+	buf := make([]byte, 2*rows*cols)
+	if _, err := prng.Read(buf); err != nil {
+		panic(err)
+	}
+	m := make([][]uint64, rows)
+	for r := range m {
+		m[r] = make([]uint64, cols)
+		for c := range m[r] {
+			j := 2 * (r*cols + c)
+			m[r][c] = (uint64(buf[j])<<8 | uint64(buf[j+1])) % T
+		}
+	}
+	return m
+}
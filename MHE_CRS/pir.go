@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// pirNoiseFlooding 密钥切换份额的噪声淹没分布，掩盖参与方私钥对份额误差的影响
+var pirNoiseFlooding = ring.DiscreteGaussian{Sigma: 1 << 30, Bound: 6 * (1 << 30)}
+
+// pirResult 一次私有信息检索的数据库和客户端得到的结果
+type pirResult struct {
+	index     int        // 检索的记录编号
+	database  [][]uint64 // 数据库的各条记录
+	record    []uint64   // 客户端解密出的记录
+	decrypted []uint64   // 客户端解密出的全部槽位，查询为独热向量时选中记录之外全为0
+}
+
+// retrieve 私有信息检索：客户端在集体公钥下加密独热选择向量，数据库持有方用Computer把查询密文逐槽位乘以明文数据库，
+// 全部参与方再把结果从集体私钥协同切换到客户端的公钥下。参与方和数据库持有方都不知道检索的是哪条记录，
+// 只有客户端能解密。
+//
+// 假定客户端是半诚实的：查询没有附带格式正确的证明，数据库持有方无法检查它是独热向量，
+// 恶意客户端加密全1向量即可解密整个数据库。只有按协议构造查询时，解密出的才只有选中的记录。
+//
+// 数据库共records条记录，每条占MaxSlots/records个连续槽位，第r条记录位于[r*L, (r+1)*L)。
+func retrieve(params heint.Parameters, parties []*party, pk *rlwe.PublicKey, records, index int) (*pirResult, error) {
+	slots := params.MaxSlots()
+	if records > slots {
+		return nil, fmt.Errorf("%d records do not fit in %d slots", records, slots)
+	}
+	if index < 0 || index >= records {
+		return nil, fmt.Errorf("record %d is not in [0, %d)", index, records)
+	}
+	L := slots / records
+	// 客户端的独热选择向量：选中记录的槽位为1，其余为0
+	selection := make([]uint64, slots)
+	for k := index * L; k < (index+1)*L; k++ {
+		selection[k] = 1
+	}
+	return retrieveSelection(params, parties, pk, records, index, selection)
+}
+
+// retrieveSelection 用客户端给出的选择向量完成检索，数据库持有方和参与方不检查selection
+func retrieveSelection(params heint.Parameters, parties []*party, pk *rlwe.PublicKey, records, index int, selection []uint64) (*pirResult, error) {
+	slots := params.MaxSlots()
+	L := slots / records
+	encoder := heint.NewEncoder(params)
+	out := &pirResult{index: index}
+
+	// 客户端的密钥对，不属于集体私钥
+	var skClient *rlwe.SecretKey
+	var pkClient *rlwe.PublicKey
+	seeded.With(-1, "pir-client", func() { skClient, pkClient = rlwe.NewKeyGenerator(params).GenKeyPairNew() })
+
+	// 客户端在集体公钥下加密选择向量
+	ptQuery := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(selection, ptQuery); err != nil {
+		return nil, err
+	}
+	var encryptor *rlwe.Encryptor
+	seeded.With(-1, "pir-query", func() { encryptor = rlwe.NewEncryptor(params, pk) })
+	query, err := encryptor.EncryptNew(ptQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据库持有方把各条记录编码为一个明文，与查询逐槽位相乘
+	prng, err := seeded.PRNG(-1, "database")
+	if err != nil {
+		return nil, err
+	}
+	out.database = randomDatabase(prng, records, L, params.PlaintextModulus())
+	packed := make([]uint64, slots)
+	for r, row := range out.database {
+		copy(packed[r*L:], row)
+	}
+	ptDatabase := heint.NewPlaintext(params, query.Level())
+	if err := encoder.Encode(packed, ptDatabase); err != nil {
+		return nil, err
+	}
+	answer := heint.NewCiphertext(params, 1, query.Level())
+	if err := NewComputer(params).MulPlain(query, ptDatabase, answer); err != nil {
+		return nil, err
+	}
+
+	// 各参与方生成公钥切换份额并聚合，结果切换到客户端的公钥下
+	var pcks mhe.PublicKeySwitchProtocol
+	var share, agg mhe.PublicKeySwitchShare
+	for i, p := range parties {
+		seeded.With(p.i, "pcks", func() { pcks, err = mhe.NewPublicKeySwitchProtocol(params, pirNoiseFlooding) })
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			share, agg = pcks.AllocateShare(answer.Level()), pcks.AllocateShare(answer.Level())
+		}
+		pcks.GenShare(p.sk, pkClient, answer, &share)
+		if err := pcks.AggregateShares(share, agg, &agg); err != nil {
+			return nil, err
+		}
+		audit.ShareSent("pir", "pcks-share", p.i, -1)
+	}
+	switched := heint.NewCiphertext(params, 1, answer.Level())
+	pcks.KeySwitch(answer, agg, switched)
+
+	// 客户端用自己的私钥解密
+	pt := rlwe.NewDecryptor(params, skClient).DecryptNew(switched)
+	out.decrypted = make([]uint64, slots)
+	if err := encoder.Decode(pt, out.decrypted); err != nil {
+		return nil, err
+	}
+	out.record = out.decrypted[index*L : (index+1)*L]
+	return out, nil
+}
+
+// randomDatabase 数据库的随机记录，每条L个取值，由16比特随机数对T取模得到
+func randomDatabase(prng sampling.PRNG, records, L int, T uint64) [][]uint64 {
+	buf := make([]byte, 2*records*L)
+	if _, err := prng.Read(buf); err != nil {
+		panic(err)
+	}
+	database := make([][]uint64, records)
+	for r := range database {
+		database[r] = make([]uint64, L)
+		for k := range database[r] {
+			j := 2 * (r*L + k)
+			database[r][k] = (uint64(buf[j])<<8 | uint64(buf[j+1])) % T
+		}
+	}
+	return database
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"time"
 
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
 	"github.com/tuneinsight/lattigo/v5/he/heint"
 	"github.com/tuneinsight/lattigo/v5/mhe"
 	"github.com/tuneinsight/lattigo/v5/ring"
@@ -18,17 +20,128 @@ type party struct {
 	shareOut  mhe.PublicKeyGenShare
 	pt        *rlwe.Plaintext
 	ct        *rlwe.Ciphertext
+	mkct      *examples.MultiKeyCiphertext // 扩展后的多密钥密文
 	ptpart    *rlwe.Plaintext
 	ptaddpart *rlwe.Plaintext
 	pk        *rlwe.PublicKey
+	pkSeed    []byte // 公钥中均匀分量的种子
 	input     []uint64
+
+	decryptor *rlwe.Decryptor // 复用的解密器
 }
 
 type Computer struct {
-	ringQ *ring.Ring
+	params heint.Parameters
+	ringQ  *ring.Ring
+	eval   *heint.Evaluator
+}
+
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagTranscript = flag.String("transcript", "", "record the transcript of the run to the given file, for the REPLAY tool")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagRecommend = flag.Bool("recommend", false, "use parameters recommended for the number of parties instead of the fixed literal")
+var flagK = flag.Int("k", 0, "the arity of the aggregation tree, 0 for linear aggregation at a single aggregator")
+var flagRescale = flag.Int("rescale", 0, "the number of times ctadd is rescaled before decryption")
+var flagCompress = flag.Bool("compress", false, "encrypt with the party's secret key and send ciphertexts seed-compressed")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
 }
 
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// transcript 记录协议消息，未开启时为nil，记录操作为空操作
+var transcript *examples.Transcript
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
 func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -transcript时把协议的全部消息记录到文件，供回放工具检查
+	if *flagTranscript != "" {
+		var err error
+		if transcript, err = examples.CreateTranscript(*flagTranscript); err != nil {
+			fmt.Println("Error creating transcript:", err)
+			return
+		}
+		defer func() {
+			if err := transcript.Close(); err != nil {
+				fmt.Println("Error recording transcript:", err)
+			}
+		}()
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEIntLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	//假设有N个参与方
+	if _, err := run(config{N: 100, literal: literal, recommend: *flagRecommend, k: *flagK, rescale: *flagRescale, compress: *flagCompress}); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N          int                     // 参与方数量
+	literal    heint.ParametersLiteral // 参数字面量
+	recommend  bool                    // 按参与方数量推荐参数，忽略literal
+	k          int                     // 聚合树的叉数
+	rescale    int                     // ctadd解密前重缩放的次数
+	compress   bool                    // 私钥加密并以种子压缩传输密文
+	decryptors int                     // 参与ctadd解密的分量数量，0表示全部分量
+}
+
+// result 一次运行的输入与解密结果
+type result struct {
+	params    heint.Parameters
+	inputs    [][]uint64 // 各参与方的输入
+	decrypted [][]uint64 // 各参与方密文的解密结果
+	summands  [2]int     // 同态加法的两个参与方
+	sum       []uint64   // ctadd的解密结果
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、加密、同态加法和解密
+func run(cfg config) (*result, error) {
+	N := cfg.N
 
 	var start, end time.Time
 	var duration time.Duration
@@ -36,17 +149,33 @@ func main() {
 
 
 	fmt.Println("> Parameter initialization Phase")
+	transcript.Phase("Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
 	start = time.Now()
 
-	params, err := heint.NewParametersFromLiteral(heint.ParametersLiteral{
-		LogN:             15,
-		LogQ:             []int{54, 54, 54},
-		LogP:             []int{55},
-		PlaintextModulus: 65537,
-	})
+	// -recommend时按参与方数量推荐参数
+	literal := cfg.literal
+	if cfg.recommend {
+		rec, err := examples.RecommendHEIntParameters(examples.MultipartyRequirements{Parties: N, MultiKey: true, Inputs: 2, Depth: cfg.rescale, PlaintextModulus: literal.PlaintextModulus, Security: 128})
+		if err != nil {
+			return nil, fmt.Errorf("recommending parameters: %w", err)
+		}
+		fmt.Print(rec)
+		literal = rec.Literal
+	}
+	params, err := heint.NewParametersFromLiteral(literal)
 	if err != nil {
-		fmt.Println("Error creating parameters:", err)
-		return
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	out := &result{params: params, inputs: make([][]uint64, N), decrypted: make([][]uint64, N)}
+	transcript.Params(examples.HEIntConfig("", literal))
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEIntFingerprint(params))
+	// 估计参数的安全性，低于128比特时给出警告
+	security := examples.EstimateSecurity(params)
+	fmt.Println("security:", security)
+	if !security.Meets(128) {
+		fmt.Println("Warning: the parameters are below 128-bit security")
 	}
 	end = time.Now()
 	duration = end.Sub(start)
@@ -57,25 +186,23 @@ func main() {
 
 
 	fmt.Println("> Private key generation Phase")
+	transcript.Phase("Private key generation Phase")
+	audit.Phase("Private key generation Phase")
 	start = time.Now()
 
-	kgen := rlwe.NewKeyGenerator(params)
 
 
 
 	// fmt.
 	// Println("输入参与方数量：")
-	N := 100
 	//_, err = fmt.Scanln(&N)
-	if err != nil {
-		fmt.Println("Error reading input:", err)
-		return
-	}
 
 	parties := make([]*party, N)
 	for i := 0; i < N; i++ {
-		sk := kgen.GenSecretKeyNew()
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
 		parties[i] = &party{i: i, sk: sk}
+		audit.PartyJoined(i)
 		// fmt.Printf("Party %d: Secret key generated successfully\n", i)
 	}
 	end = time.Now()
@@ -85,44 +212,65 @@ func main() {
 
 
 	fmt.Println("> Public key generation Phase")
+	transcript.Phase("Public key generation Phase")
+	audit.Phase("Public key generation Phase")
 	start = time.Now()
 
-	ckg := mhe.NewPublicKeyGenProtocol(params)
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
 
+	var pkTraffic examples.Traffic
 	for i := 0; i < N; i++ {
-
+		// 初始化公钥
 		parties[i].pk = rlwe.NewPublicKey(params)
 
-
-		crs, err := sampling.NewPRNG()
+		// 生成CRS，用种子生成以便公钥压缩传输
+		parties[i].pkSeed = seeded.Seed(i, "crs")
+		round := fmt.Sprintf("pk/%d", i)
+		transcript.Record("keys", examples.TranscriptSecretKey, i, parties[i].sk)
+		transcript.RecordBytes(round, examples.TranscriptCRSSeed, i, parties[i].pkSeed)
+		audit.CRPDerived(round, i, parties[i].pkSeed)
+		crs, err := sampling.NewKeyedPRNG(parties[i].pkSeed)
 		if err != nil {
-			fmt.Println("Error creating CRS:", err)
-			return
+			return nil, fmt.Errorf("creating CRS: %w", err)
 		}
-
+		//从CRS中抽样记作CRP
 		crpi := ckg.SampleCRP(crs)
 
 		parties[i].shareOut = ckg.AllocateShare()
 		ckg.GenShare(parties[i].sk, crpi, &parties[i].shareOut) //p_(1,i)*s_i+e_i
-		ckg.GenPublicKey(parties[i].shareOut, crpi, parties[i].pk)
-	}
+		transcript.Record(round, examples.TranscriptPublicKeyShare, i, &parties[i].shareOut)
+		audit.ShareSent(round, examples.TranscriptPublicKeyShare, i, -1)
 
+		// 公钥以(份额, 种子)的形式发布，接收方展开
+		cpk := examples.CompressedPublicKey{Share: parties[i].shareOut, Seed: parties[i].pkSeed}
+		if err := cpk.Expand(ckg, parties[i].pk); err != nil {
+			panic(err)
+		}
+		transcript.Record(round, examples.TranscriptPublicKey, i, parties[i].pk)
+		pkTraffic.Full += parties[i].pk.BinarySize()
+		pkTraffic.Compressed += cpk.BinarySize()
+	}
 
-	// fmt.Println("Public key generated successfully!")
 	end = time.Now()
 	duration = end.Sub(start)
 	durationall += duration
 	fmt.Printf("Public key generation Phase time: %s\n", duration)
+	fmt.Printf("Public key traffic: %s\n", pkTraffic)
 
 
 	fmt.Println("> Encrypt Phase")
+	transcript.Phase("Encrypt Phase")
+	audit.Phase("Encrypt Phase")
 	start = time.Now()
 
 	encoder := heint.NewEncoder(params)
+	var ctTraffic examples.Traffic
 
 	for i := 0; i < N; i++ {
 
-		encryptor := rlwe.NewEncryptor(params, parties[i].pk)
+		var encryptor *rlwe.Encryptor
+		seeded.With(i, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, parties[i].pk) })
 
 		parties[i].pt = heint.NewPlaintext(params, params.MaxLevel())
 		// fmt.Printf("Party %d: Plaintext generated successfully\n", i)
@@ -133,6 +281,7 @@ func main() {
 		for j := range parties[i].input {
 			parties[i].input[j] = uint64(i)
 		}
+		out.inputs[i] = parties[i].input
 
 		if err := encoder.Encode(parties[i].input, parties[i].pt); err != nil {
 			panic(err)
@@ -145,24 +294,60 @@ func main() {
 
 		// fmt.Printf("Party %d: Plaintext encoded successfully!\n", i)
 		//加密
-		if err := encryptor.Encrypt(parties[i].pt, parties[i].ct); err != nil {
-			panic(err)
+		if cfg.compress {
+			// 私钥加密，c1由种子生成，传输(c0, 种子)后由接收方展开
+			var cct examples.CompressedCiphertext
+			var err error
+			seeded.With(i, "encrypt-sk", func() { cct, err = examples.EncryptSeeded(params, parties[i].sk, parties[i].pt, seeded.Seed(i, "ct")) })
+			if err != nil {
+				panic(err)
+			}
+			if err := cct.Expand(params, parties[i].ct); err != nil {
+				panic(err)
+			}
+			ctTraffic.Compressed += cct.BinarySize()
+		} else {
+			if err := encryptor.Encrypt(parties[i].pt, parties[i].ct); err != nil {
+				panic(err)
+			}
+			ctTraffic.Compressed += parties[i].ct.BinarySize()
 		}
+		ctTraffic.Full += parties[i].ct.BinarySize()
 		// fmt.Printf("Party %d: Ciphertext generated successfully!\n", i)
-		parties[i].ct = extendCiphertext(parties[i].ct, N, params, i)
+		// 扩展后的密文编码后发送给计算方，计算方解码时检查分量的编号、模数数量和系数范围
+		if parties[i].mkct, err = sendMKCiphertext(params, N, examples.ExtendCiphertext(parties[i].ct, i)); err != nil {
+			return nil, fmt.Errorf("sending the ciphertext of party %d: %w", i, err)
+		}
 	}
 	end = time.Now()
 	duration = end.Sub(start)
 	durationall += duration
 	fmt.Printf("time: %s\n", duration)
+	fmt.Printf("Ciphertext traffic: %s\n", ctTraffic)
 
 
 	fmt.Println("> Computation Phase")
+	transcript.Phase("Computation Phase")
+	audit.Phase("Computation Phase")
+	// 计算前预测解密后剩余的噪声预算，预算不足时告警
+	// 参与同态加法的两个参与方，N<3时回绕
+	a, b := 1%N, 2%N
+	out.summands = [2]int{a, b}
+	noiseModel := examples.NoiseModel{Params: params, Parties: 1, Decryptors: len(examples.MultiKeyParties(parties[a].mkct, parties[b].mkct))}
+	prediction := noiseModel.Predict(2, params.MaxLevel())
+	if err := prediction.Err(); err != nil {
+		fmt.Println("Warning:", err)
+	}
 	start = time.Now()
-	ctadd := heint.NewCiphertext(params, 1, params.MaxLevel())
-	ctadd = extendCiphertext(ctadd, N, params, 2)
+	ctadd := examples.NewMultiKeyCiphertext(params, params.MaxLevel())
 	computer := NewComputer(params)
-	computer.Add(parties[1].ct, parties[2].ct, ctadd, N)
+	computer.Add(parties[a].mkct, parties[b].mkct, ctadd, N)
+	for r := 0; r < cfg.rescale; r++ {
+		if err := computer.Rescale(ctadd, ctadd); err != nil {
+			panic(err)
+		}
+	}
+	fmt.Printf("ctadd level: %d\n", ctadd.Level())
 	// fmt.Printf("The sum of parties[1].ct and parties[2].ct generated successfully!\n")
 
 	end = time.Now()
@@ -172,20 +357,30 @@ func main() {
 
 
 	fmt.Println("> Decrypt Phase")
+	transcript.Phase("Decrypt Phase")
+	audit.Phase("Decrypt Phase")
 	start = time.Now()
+	allocateDecryptionBuffers(params, parties)
+	res := make([]uint64, params.MaxSlots())
 	for j := 0; j < N; j++ {
 		//解密份额的和
-		parties[j].ct = reCiphertext(parties[j].ct, N, params, j)
-		decryptor := rlwe.NewDecryptor(params, parties[j].sk)
-		parties[j].ptpart = heint.NewPlaintext(params, params.MaxLevel())
+		parties[j].ct = parties[j].mkct.Project(params, j)
+		decryptor := parties[j].decryptor
+		parties[j].ptpart = fitPlaintext(params, parties[j].ptpart, parties[j].ct.Level())
+		round := fmt.Sprintf("decrypt/%d", j)
+		transcript.Record(round, examples.TranscriptCiphertext, j, parties[j].ct)
 		decryptor.Decryptpart(parties[j].ct, parties[j].ptpart)
+		transcript.Record(round, examples.TranscriptPartialDecryption, j, parties[j].ptpart)
+		audit.PartialDecryption(round, j)
 		decryptor.Decryptall(parties[j].ct, parties[j].ptpart) //全部解密
+		transcript.Record(round, examples.TranscriptDecryption, j, parties[j].ptpart)
 
-		res := make([]uint64, params.MaxSlots())
 		if err := encoder.Decode(parties[j].ptpart, res); err != nil {
 			panic(err)
 		}
 		fmt.Printf("\t%v...%v\n", res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+		out.decrypted[j] = append([]uint64(nil), res...)
+		audit.DecryptionReleased(round, j, []int{j})
 
 		// fmt.Printf("Party %d: NewPlaintext generated successfully!\n", j)
 	}
@@ -197,61 +392,301 @@ func main() {
 
 	//start = time.Now()
 
-	hisigema := heint.NewPlaintext(params, params.MaxLevel())
-	for i := 1; i < N; i++ {
-		ctaddi := reCiphertext(ctadd, N, params, i)
-		decryptor := rlwe.NewDecryptor(params, parties[i].sk)
-		parties[i].ptaddpart = heint.NewPlaintext(params, params.MaxLevel())
-		decryptor.Decryptpart(ctaddi, parties[i].ptaddpart)  //部分解密
-		decryptor.Decryptadd(parties[i].ptaddpart, hisigema) //求和
+	// 只有在ctadd中有非零分量的参与方需要参与部分解密
+	contributors := ctadd.Parties()
+	fmt.Printf("ctadd components: %d of %d\n", len(contributors), N)
+	// 只有前decryptors个分量的参与方参与部分解密，少于全部分量时解密结果错误
+	if cfg.decryptors > 0 && cfg.decryptors < len(contributors) {
+		contributors = contributors[:cfg.decryptors]
 	}
-	ctaddzero := ctaddzero(ctadd, N, params)
-	decryptor := rlwe.NewDecryptor(params, parties[0].sk)
+	fmt.Printf("aggregation tree: arity %d, depth %d, root inbound %d\n", examples.TreeArity(len(contributors), cfg.k), examples.TreeDepth(len(contributors), cfg.k), examples.TreeRootInbound(len(contributors), cfg.k))
+	ptaddparts := make([]*rlwe.Plaintext, 0, len(contributors))
+	for _, i := range contributors {
+		ctaddi := ctadd.Project(params, i)
+		parties[i].ptaddpart = fitPlaintext(params, parties[i].ptaddpart, ctadd.Level())
+		transcript.Record("decrypt/ctadd", examples.TranscriptCiphertext, i, ctaddi)
+		parties[i].decryptor.Decryptpart(ctaddi, parties[i].ptaddpart) //部分解密
+		transcript.Record("decrypt/ctadd", examples.TranscriptPartialDecryption, i, parties[i].ptaddpart)
+		audit.PartialDecryption("decrypt/ctadd", i)
+		ptaddparts = append(ptaddparts, parties[i].ptaddpart)
+	}
+	ctaddzero := ctadd.Body(params)
+	decryptor := parties[0].decryptor
+	//按k叉树在各参与方的缓冲区中原地求和
+	hisigema := examples.AggregateTree(ptaddparts, cfg.k, nil, func(share *rlwe.Plaintext, acc **rlwe.Plaintext) {
+		decryptor.Decryptadd(share, *acc)
+	})
+	examples.AuditTree(audit, "decrypt/ctadd", examples.TranscriptPartialDecryption, contributors, cfg.k)
+	transcript.Record("decrypt/ctadd", examples.TranscriptDecryptionSum, -1, hisigema)
+	transcript.Record("decrypt/ctadd", examples.TranscriptCiphertext, -1, ctaddzero)
 	decryptor.Decryptall(ctaddzero, hisigema) //全部解密
-	res := make([]uint64, params.MaxSlots())
+	transcript.Record("decrypt/ctadd", examples.TranscriptDecryption, -1, hisigema)
+	hisigema.Scale = ctadd.Scale //重缩放后scale已改变，解码需要
 	if err := encoder.Decode(hisigema, res); err != nil {
 		panic(err)
 	}
 	fmt.Println("The decryption result of ct_add:")
 	fmt.Printf("\t%v...%v\n", res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+	out.sum = append([]uint64(nil), res...)
+	// 聚合树的根节点得到ctadd的解密结果
+	audit.DecryptionReleased("decrypt/ctadd", contributors[0], contributors)
 	end = time.Now()
 	duration = end.Sub(start)
 	durationall += duration
 	fmt.Printf("time: %s\n", duration)
 	fmt.Printf("all time: %s\n", durationall)
 
+	//*****噪声分析*****
+	// 模拟中已知所有私钥，测量实际噪声并与理论估计比较
+	fmt.Println("> Noise analysis")
+	transcript.Phase("Noise analysis")
+	audit.Phase("Noise analysis")
+	noiseFresh, err := examples.CiphertextNoise(params, parties[a].ct, parties[a].pt, parties[a].sk)
+	if err != nil {
+		panic(err)
+	}
+	printNoise(params, "fresh", parties[a].ct.Level(), noiseFresh, prediction.Fresh)
+	// ctadd的期望明文
+	sum := make([]uint64, params.N())
+	for k := range sum {
+		sum[k] = (parties[a].input[k] + parties[b].input[k]) % params.PlaintextModulus()
+	}
+	ptadd := heint.NewPlaintext(params, ctadd.Level())
+	ptadd.Scale = ctadd.Scale
+	if err := encoder.Encode(sum, ptadd); err != nil {
+		panic(err)
+	}
+	ctaddDense, idx := ctadd.Dense(params)
+	sks := make([]*rlwe.SecretKey, len(idx))
+	for k, i := range idx {
+		sks[k] = parties[i].sk
+	}
+	noiseAdd, err := examples.CiphertextNoise(params, ctaddDense, ptadd, sks...)
+	if err != nil {
+		panic(err)
+	}
+	printNoise(params, "ctadd", ctadd.Level(), noiseAdd, prediction.Computed)
+	printNoise(params, "decrypted ctadd", ctadd.Level(), examples.PlaintextNoise(params, hisigema, ptadd), prediction.Decrypted)
+	return out, nil
+}
+
+// sendMKCiphertext 模拟多密钥密文的传输：发送方编码，接收方解码并检查，畸形的消息返回错误而不是panic
+func sendMKCiphertext(params heint.Parameters, N int, ct *examples.MultiKeyCiphertext) (*examples.MultiKeyCiphertext, error) {
+	data, err := ct.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	received, err := examples.DecodeMultiKeyCiphertext(params, N, data)
+	if err != nil {
+		return nil, err
+	}
+	return &received, nil
+}
+
+// Add 在两个输入的较低层级上逐分量相加，只有一方含有的分量直接复制，ctadd中两者都没有的分量被删除
+func (c Computer) Add(ct1 *examples.MultiKeyCiphertext, ct2 *examples.MultiKeyCiphertext, ctadd *examples.MultiKeyCiphertext, N int) {
+	level := min(ct1.Level(), ct2.Level(), ctadd.Level())
+	ringQ := c.ringQ.AtLevel(level)
+	dropComponents(ctadd, ct1, ct2)
+	for _, i := range examples.MultiKeyParties(ct1, ct2) {
+		c1, ok1 := ct1.C[i]
+		c2, ok2 := ct2.C[i]
+		out, ok := ctadd.C[i]
+		if !ok {
+			out = ringQ.NewPoly()
+		}
+		switch {
+		case ok1 && ok2:
+			ringQ.Add(c1, c2, out)
+		case ok1:
+			out.Copy(c1)
+		default:
+			out.Copy(c2)
+		}
+		ctadd.C[i] = out
+	}
+	ringQ.Add(ct1.C0, ct2.C0, ctadd.C0)
+	*ctadd.MetaData = *ct1.MetaData
+	ctadd.Resize(level)
+}
+
+// DropLevel 所有N+1个分量同时丢弃最后levels个模数，levels须在0和密文的层级之间
+func (c Computer) DropLevel(ct *examples.MultiKeyCiphertext, levels int) error {
+	if levels < 0 || levels > ct.Level() {
+		return fmt.Errorf("cannot DropLevel: %d levels, the ciphertext is at level %d", levels, ct.Level())
+	}
+	ct.Resize(ct.Level() - levels)
+	return nil
+}
+
+// Rescale 所有N+1个分量同时除以最后一个模数并舍入，结果降一层，scale随之更新
+func (c Computer) Rescale(ct *examples.MultiKeyCiphertext, ctOut *examples.MultiKeyCiphertext) error {
+	level := ct.Level()
+	if level == 0 {
+		return fmt.Errorf("cannot Rescale: ciphertext is already at level 0")
+	}
+
+	// 把非零分量打包成一个多分量密文，由heint逐分量重缩放
+	in, idx := ct.Dense(c.params)
+	out := heint.NewCiphertext(c.params, len(idx), level-1)
+	if err := c.eval.Rescale(in, out); err != nil {
+		return err
+	}
+
+	ctOut.MetaData = out.MetaData
+	ctOut.C0 = out.Value[0]
+	ctOut.C = make(map[int]ring.Poly, len(idx))
+	for k, i := range idx {
+		ctOut.C[i] = out.Value[k+1]
+	}
+	return nil
+}
+
+// Sub 在两个输入的较低层级上逐分量相减，只有ct2含有的分量取负，ctsub中两者都没有的分量被删除
+func (c Computer) Sub(ct1 *examples.MultiKeyCiphertext, ct2 *examples.MultiKeyCiphertext, ctsub *examples.MultiKeyCiphertext) {
+	level := min(ct1.Level(), ct2.Level(), ctsub.Level())
+	ringQ := c.ringQ.AtLevel(level)
+	dropComponents(ctsub, ct1, ct2)
+	for _, i := range examples.MultiKeyParties(ct1, ct2) {
+		c1, ok1 := ct1.C[i]
+		c2, ok2 := ct2.C[i]
+		out, ok := ctsub.C[i]
+		if !ok {
+			out = ringQ.NewPoly()
+		}
+		switch {
+		case ok1 && ok2:
+			ringQ.Sub(c1, c2, out)
+		case ok1:
+			out.Copy(c1)
+		default:
+			ringQ.Neg(c2, out)
+		}
+		ctsub.C[i] = out
+	}
+	ringQ.Sub(ct1.C0, ct2.C0, ctsub.C0)
+	*ctsub.MetaData = *ct1.MetaData
+	ctsub.Resize(level)
 }
 
-func extendCiphertext(ct *rlwe.Ciphertext, N int, params heint.Parameters, i int) *rlwe.Ciphertext {
-	ctext := heint.NewCiphertext(params, N, params.MaxLevel())
-	ctext.Value[0] = ct.Value[0]
-	ctext.Value[i+1] = ct.Value[1]
-	return ctext
+// Neg 所有N+1个分量取负
+func (c Computer) Neg(ct *examples.MultiKeyCiphertext, ctneg *examples.MultiKeyCiphertext) {
+	c.each(ct, ctneg, func(ringQ *ring.Ring, in, out ring.Poly) { ringQ.Neg(in, out) })
 }
 
-func reCiphertext(ct *rlwe.Ciphertext, N int, params heint.Parameters, i int) *rlwe.Ciphertext {
-	ctre := heint.NewCiphertext(params, 1, params.MaxLevel())
-	ctre.Value[0] = ct.Value[0]
-	ctre.Value[1] = ct.Value[i+1]
-	return ctre
+// MulScalar 所有N+1个分量乘以同一个公开的整数，scalar按明文模数约简，尺度不变
+func (c Computer) MulScalar(ct *examples.MultiKeyCiphertext, scalar uint64, ctmul *examples.MultiKeyCiphertext) {
+	scalar %= c.params.PlaintextModulus()
+	c.each(ct, ctmul, func(ringQ *ring.Ring, in, out ring.Poly) { ringQ.MulScalar(in, scalar, out) })
 }
 
-func ctaddzero(ct *rlwe.Ciphertext, N int, params heint.Parameters) *rlwe.Ciphertext {
-	ctzero := heint.NewCiphertext(params, 1, params.MaxLevel())
-	ctzero.Value[0] = ct.Value[0]
-	return ctzero
+// AddPlain 明文只加到c0上，掩码分量不变。明文的尺度须与密文相同(编码前设置pt.Scale = ct.Scale)
+func (c Computer) AddPlain(ct *examples.MultiKeyCiphertext, pt *rlwe.Plaintext, ctadd *examples.MultiKeyCiphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot AddPlain: %w", err)
+	}
+	c.each(ct, ctadd, func(ringQ *ring.Ring, in, out ring.Poly) { out.Copy(in) })
+	c.ringQ.AtLevel(ctadd.Level()).Add(ctadd.C0, pt.Value, ctadd.C0)
+	return nil
+}
+
+// SubPlain 明文只从c0中减去，尺度要求与AddPlain相同
+func (c Computer) SubPlain(ct *examples.MultiKeyCiphertext, pt *rlwe.Plaintext, ctsub *examples.MultiKeyCiphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot SubPlain: %w", err)
+	}
+	c.each(ct, ctsub, func(ringQ *ring.Ring, in, out ring.Poly) { out.Copy(in) })
+	c.ringQ.AtLevel(ctsub.Level()).Sub(ctsub.C0, pt.Value, ctsub.C0)
+	return nil
 }
 
-func (c Computer) Add(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctadd *rlwe.Ciphertext, N int) {
-	level := ct1.Level()
+// MulPlain 所有N+1个分量逐槽位乘以明文，尺度为两者尺度之积。明文先转为Montgomery形式再逐系数相乘
+func (c Computer) MulPlain(ct *examples.MultiKeyCiphertext, pt *rlwe.Plaintext, ctmul *examples.MultiKeyCiphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, false); err != nil {
+		return fmt.Errorf("cannot MulPlain: %w", err)
+	}
+	ptm := c.ringQ.AtLevel(ct.Level()).NewPoly()
+	c.ringQ.AtLevel(ct.Level()).MForm(pt.Value, ptm)
+	scale := ct.Scale.Mul(pt.Scale)
+	c.each(ct, ctmul, func(ringQ *ring.Ring, in, out ring.Poly) { ringQ.MulCoeffsMontgomery(in, ptm, out) })
+	ctmul.Scale = scale
+	return nil
+}
+
+// each 在两者的较低层级上对c0和每个掩码分量计算f，结果写入ctOut的对应分量，ctOut可以是ct。
+// ctOut中ct没有的分量被删除
+func (c Computer) each(ct *examples.MultiKeyCiphertext, ctOut *examples.MultiKeyCiphertext, f func(ringQ *ring.Ring, in, out ring.Poly)) {
+	level := min(ct.Level(), ctOut.Level())
 	ringQ := c.ringQ.AtLevel(level)
-	for i := 0; i < len(ct1.Value); i++ {
-		ringQ.Add(ct1.Value[i], ct2.Value[i], ctadd.Value[i])
+	dropComponents(ctOut, ct)
+	for i, ci := range ct.C {
+		out, ok := ctOut.C[i]
+		if !ok {
+			out = ringQ.NewPoly()
+		}
+		f(ringQ, ci, out)
+		ctOut.C[i] = out
+	}
+	f(ringQ, ct.C0, ctOut.C0)
+	*ctOut.MetaData = *ct.MetaData
+	ctOut.Resize(level)
+}
+
+// dropComponents 删除ctOut中输入都没有的分量，复用的ctOut不留下过期的分量。ctOut可以是某个输入
+func dropComponents(ctOut *examples.MultiKeyCiphertext, cts ...*examples.MultiKeyCiphertext) {
+	for i := range ctOut.C {
+		found := false
+		for _, ct := range cts {
+			_, ok := ct.C[i]
+			found = found || ok
+		}
+		if !found {
+			delete(ctOut.C, i)
+		}
+	}
+}
+
+// checkPlaintext 明文须与密文同在NTT域且层级不低于密文；加减时尺度还须与密文相同
+func checkPlaintext(md *rlwe.MetaData, level int, pt *rlwe.Plaintext, sameScale bool) error {
+	if pt.Level() < level {
+		return fmt.Errorf("plaintext at level %d, below the ciphertext at level %d", pt.Level(), level)
+	}
+	if pt.IsNTT != md.IsNTT {
+		return fmt.Errorf("plaintext in NTT domain: %v, ciphertext: %v", pt.IsNTT, md.IsNTT)
 	}
+	if sameScale && !pt.Scale.Equal(md.Scale) {
+		return fmt.Errorf("plaintext scale %v, ciphertext scale %v: encode the plaintext with pt.Scale = ct.Scale", pt.Scale.Uint64(), md.Scale.Uint64())
+	}
+	return nil
 }
 
 func NewComputer(params heint.Parameters) *Computer {
 	return &Computer{
-		ringQ: params.RingQ(),
+		params: params,
+		ringQ:  params.RingQ(),
+		eval:   heint.NewEvaluator(params, nil),
+	}
+}
+
+// allocateDecryptionBuffers 为每个参与方创建一次解密器和部分解密的缓冲区，
+// 之后所有密文的解密都复用它们
+func allocateDecryptionBuffers(params heint.Parameters, parties []*party) {
+	for _, p := range parties {
+		p.decryptor = rlwe.NewDecryptor(params, p.sk)
+		p.ptpart = heint.NewPlaintext(params, params.MaxLevel())
+		p.ptaddpart = heint.NewPlaintext(params, params.MaxLevel())
+	}
+}
+
+// printNoise 打印实测噪声、理论估计和剩余的噪声预算
+func printNoise(params heint.Parameters, label string, level int, measured, predicted float64) {
+	fmt.Printf("%s noise: measured 2^%.2f, predicted 2^%.2f, budget %.2f bits\n", label, measured, predicted, examples.NoiseBudget(params, level, measured))
+}
+
+// fitPlaintext 缓冲区层级与待解密密文不一致时按密文层级重新分配
+func fitPlaintext(params heint.Parameters, pt *rlwe.Plaintext, level int) *rlwe.Plaintext {
+	if pt.Level() != level {
+		return heint.NewPlaintext(params, level)
 	}
+	return pt
 }
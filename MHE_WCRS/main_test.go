@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		N, decryptors int
+		compress      bool
+		ok            bool
+	}{
+		{N: 2, ok: true},
+		{N: 3, ok: true},
+		{N: 10, ok: true},
+		{N: 10, compress: true, ok: true},
+		{N: 2, decryptors: 1},
+		{N: 10, decryptors: 1},
+	} {
+		t.Run(fmt.Sprintf("N=%d/compress=%v/decryptors=%d", tc.N, tc.compress, tc.decryptors), func(t *testing.T) {
+			out, err := run(config{N: tc.N, literal: examples.HEIntParamsN12QP109, compress: tc.compress, decryptors: tc.decryptors})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResult(t, out, tc.ok)
+		})
+	}
+}
+
+// TestComputer 在两个参与方各自密钥下的多密钥密文ctx = x_0 + x_1上检查Computer的明文与标量运算
+func TestComputer(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(examples.HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := params.PlaintextModulus()
+	encoder := heint.NewEncoder(params)
+	sks := make([]*rlwe.SecretKey, 2)
+	xs := make([][]uint64, 2)
+	cts := make([]*examples.MultiKeyCiphertext, 2)
+	for i := range sks {
+		sks[i] = rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+		xs[i] = make([]uint64, params.MaxSlots())
+		for k := range xs[i] {
+			xs[i][k] = uint64(k*(i+1)) % T
+		}
+		pt := heint.NewPlaintext(params, params.MaxLevel())
+		if err := encoder.Encode(xs[i], pt); err != nil {
+			t.Fatal(err)
+		}
+		ct, err := rlwe.NewEncryptor(params, sks[i]).EncryptNew(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts[i] = examples.ExtendCiphertext(ct, i)
+	}
+	w := make([]uint64, params.MaxSlots())
+	for k := range w {
+		w[k] = uint64(k % 7)
+	}
+	ptw := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(w, ptw); err != nil {
+		t.Fatal(err)
+	}
+
+	computer := NewComputer(params)
+	ctx := examples.NewMultiKeyCiphertext(params, params.MaxLevel())
+	computer.Add(cts[0], cts[1], ctx, 2)
+	x := make([]uint64, params.MaxSlots())
+	for k := range x {
+		x[k] = (xs[0][k] + xs[1][k]) % T
+	}
+	for _, tc := range []struct {
+		name string
+		op   func(out *examples.MultiKeyCiphertext) error
+		f    func(k int) uint64
+	}{
+		{"Sub", func(out *examples.MultiKeyCiphertext) error { computer.Sub(ctx, cts[1], out); return nil }, func(k int) uint64 { return xs[0][k] }},
+		{"Sub/missing", func(out *examples.MultiKeyCiphertext) error { computer.Sub(cts[0], cts[1], out); return nil }, func(k int) uint64 { return (xs[0][k] + T - xs[1][k]) % T }},
+		{"Neg", func(out *examples.MultiKeyCiphertext) error { computer.Neg(ctx, out); return nil }, func(k int) uint64 { return (T - x[k]) % T }},
+		{"MulScalar", func(out *examples.MultiKeyCiphertext) error { computer.MulScalar(ctx, 3, out); return nil }, func(k int) uint64 { return 3 * x[k] % T }},
+		{"AddPlain", func(out *examples.MultiKeyCiphertext) error { return computer.AddPlain(ctx, ptw, out) }, func(k int) uint64 { return (x[k] + w[k]) % T }},
+		{"SubPlain", func(out *examples.MultiKeyCiphertext) error { return computer.SubPlain(ctx, ptw, out) }, func(k int) uint64 { return (x[k] + T - w[k]) % T }},
+		{"MulPlain", func(out *examples.MultiKeyCiphertext) error { return computer.MulPlain(ctx, ptw, out) }, func(k int) uint64 { return x[k] * w[k] % T }},
+	} {
+		// 复用的输出中过期的分量被删除
+		out := examples.NewMultiKeyCiphertext(params, params.MaxLevel())
+		out.C[5] = params.RingQ().NewPoly()
+		if err := tc.op(out); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(out.Parties(), []int{0, 1}) {
+			t.Fatalf("%s: components of parties %v, want [0 1]", tc.name, out.Parties())
+		}
+		got := decryptMK(t, params, out, sks)
+		for k := range got {
+			if want := tc.f(k); got[k] != want {
+				t.Fatalf("%s: slot %d decrypted %d, want %d", tc.name, k, got[k], want)
+			}
+		}
+	}
+
+	// 原地运算
+	computer.MulScalar(ctx, 2, ctx)
+	if got := decryptMK(t, params, ctx, sks); got[5] != 2*x[5]%T {
+		t.Errorf("in-place MulScalar: slot 5 decrypted %d, want %d", got[5], 2*x[5]%T)
+	}
+
+	// 丢弃的层数须在0和密文的层级之间
+	for _, levels := range []int{-1, ctx.Level() + 1} {
+		if err := computer.DropLevel(ctx, levels); err == nil {
+			t.Errorf("DropLevel(%d) at level %d: no error", levels, ctx.Level())
+		}
+	}
+	if err := computer.DropLevel(ctx, ctx.Level()); err != nil || ctx.Level() != 0 {
+		t.Errorf("DropLevel to level 0: level %d, %v", ctx.Level(), err)
+	}
+}
+
+// decryptMK 各分量的参与方用自己的私钥部分解密，求和后加上c0完成解密
+func decryptMK(t *testing.T, params heint.Parameters, ct *examples.MultiKeyCiphertext, sks []*rlwe.SecretKey) []uint64 {
+	t.Helper()
+	sum := heint.NewPlaintext(params, ct.Level())
+	part := heint.NewPlaintext(params, ct.Level())
+	for _, i := range ct.Parties() {
+		decryptor := rlwe.NewDecryptor(params, sks[i])
+		decryptor.Decryptpart(ct.Project(params, i), part)
+		decryptor.Decryptadd(part, sum)
+	}
+	rlwe.NewDecryptor(params, sks[0]).Decryptall(ct.Body(params), sum)
+	sum.Scale = ct.Scale
+	res := make([]uint64, params.MaxSlots())
+	if err := heint.NewEncoder(params).Decode(sum, res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// checkResult 检查每个参与方的解密结果和ctadd的解密结果；每个参与方独立解密自己的密文，总是正确，
+// ok为false时参与ctadd解密的参与方不足，ctadd不应解密出正确的明文
+func checkResult(t *testing.T, out *result, ok bool) {
+	t.Helper()
+	T := out.params.PlaintextModulus()
+	for i, want := range out.inputs {
+		if got := out.decrypted[i]; !reflect.DeepEqual(got, want) {
+			t.Errorf("party %d: decrypted %v..., want %v...", i, got[:4], want[:4])
+		}
+	}
+	a, b := out.summands[0], out.summands[1]
+	want := make([]uint64, len(out.sum))
+	for k := range want {
+		want[k] = (out.inputs[a][k] + out.inputs[b][k]) % T
+	}
+	if reflect.DeepEqual(out.sum, want) != ok {
+		t.Errorf("ctadd: decrypted %v..., want %v... (correct: %v)", out.sum[:4], want[:4], ok)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// config 一次运行的配置
+type config struct {
+	clients []PartyClient           // 参与方节点的客户端，下标即参与方编号
+	t       int                     // 阈值，0或等于参与方数量时为CRS流程，否则为门限流程
+	literal heint.ParametersLiteral // 参数字面量
+	session string                  // 会话ID
+	k       int                     // 聚合树的叉数，小于2时协调方逐个收集份额并聚合
+	addrs   []string                // 参与方节点的地址，发给参与方，以便门限流程中互发Shamir份额、k≥2时父节点连接子节点
+}
+
+// result 一次运行的解密结果
+type result struct {
+	params    heint.Parameters
+	decrypted [][]uint64 // 各参与方密文的解密结果
+	summands  [2]int     // 同态加法的两个参与方
+	sum       []uint64   // ctadd的解密结果
+}
+
+// coordinator 通过RPC驱动参与方节点，只接触公开的协议消息：它聚合公钥份额和部分解密，不持有任何私钥，
+// 门限流程中的Shamir份额在参与方之间直接发送，不经过协调方。
+// 它持有会话令牌，参与方只接受带有令牌的请求：持有令牌就能让参与方解密任意密文，协调方被信任只登记计算结果。
+// k≥2时份额沿聚合树在参与方之间聚合，协调方每轮只收到根节点的聚合份额
+type coordinator struct {
+	params     heint.Parameters
+	config     examples.ParametersConfig // 发给参与方的参数配置
+	clients    []PartyClient             // 下标即参与方编号
+	decryptors []PartyClient             // 参与公钥生成和解密的在线参与方
+	online     []int32                   // 在线参与方的编号，CRS流程中为空
+	k          int                       // 聚合树的叉数
+	token      []byte                    // 会话令牌，在Setup时发给参与方
+	pk         *rlwe.PublicKey
+	ks         mhe.KeySwitchProtocol // 聚合参与方的密钥切换份额
+	dec        *rlwe.Decryptor
+	encoder    *heint.Encoder
+}
+
+// newCoordinator 建立会话，门限流程中转发Shamir份额，然后生成集体公钥
+func newCoordinator(ctx context.Context, cfg config) (*coordinator, error) {
+	N := len(cfg.clients)
+	t := cfg.t
+	if t == 0 {
+		t = N
+	}
+	if (cfg.k >= 2 || t < N) && len(cfg.addrs) != N {
+		return nil, fmt.Errorf("the aggregation tree and the threshold flow need the addresses of the %d parties, got %d", N, len(cfg.addrs))
+	}
+
+	//*****会话建立*****
+	fmt.Println("> Setup Phase")
+	params, err := heint.NewParametersFromLiteral(cfg.literal)
+	if err != nil {
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	config := examples.HEIntConfig("", cfg.literal)
+	paramsJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fingerprint := examples.HEIntFingerprint(params)
+	fmt.Println("fingerprint:", fingerprint)
+	// 会话令牌认证协调方，Setup之后的请求都带有它
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	for i, c := range cfg.clients {
+		resp, err := c.Setup(ctx, &SetupRequest{Session: cfg.session, Params: paramsJSON, Party: int32(i), Parties: int32(N), Threshold: int32(cfg.t), Arity: int32(cfg.k), Peers: cfg.addrs, Token: token})
+		if err != nil {
+			return nil, fmt.Errorf("party %d: setup: %w", i, err)
+		}
+		if resp.Fingerprint != fingerprint {
+			return nil, fmt.Errorf("party %d: parameter fingerprint %s, expected %s", i, resp.Fingerprint, fingerprint)
+		}
+	}
+	// 参与方把密文切换到零私钥，聚合和最终解密不依赖私钥
+	ks, err := mhe.NewKeySwitchProtocol(params, smudging)
+	if err != nil {
+		return nil, err
+	}
+	c := &coordinator{
+		params:     params,
+		config:     config,
+		clients:    cfg.clients,
+		decryptors: cfg.clients[:t],
+		k:          cfg.k,
+		token:      token,
+		ks:         ks,
+		dec:        rlwe.NewDecryptor(params, rlwe.NewSecretKey(params)),
+		encoder:    heint.NewEncoder(params),
+	}
+	ctx = c.auth(ctx)
+
+	//*****秘密共享*****
+	// 每个参与方把Shamir份额直接发给其他参与方，协调方只发起发送
+	if t != N {
+		fmt.Println("> Shamir Secret Share Phase")
+		for i, ci := range cfg.clients {
+			if _, err := ci.DealShamirShares(ctx, &DealShamirSharesRequest{}); err != nil {
+				return nil, fmt.Errorf("party %d: dealing the Shamir shares: %w", i, err)
+			}
+		}
+		// 前t个参与方在线
+		for i := 0; i < t; i++ {
+			c.online = append(c.online, int32(i))
+		}
+	}
+
+	//*****公钥生成*****
+	fmt.Println("> Public key generation Phase")
+	if c.k >= 2 {
+		fmt.Printf("aggregation tree: arity %d, depth %d, root inbound %d\n", examples.TreeArity(t, c.k), examples.TreeDepth(t, c.k), examples.TreeRootInbound(t, c.k))
+	}
+	crsSeed := make([]byte, 32)
+	if _, err := rand.Read(crsSeed); err != nil {
+		return nil, err
+	}
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("creating CRS: %w", err)
+	}
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	crp := ckg.SampleCRP(crs)
+	roundShare := ckg.AllocateShare()
+	shares, err := c.collect("public-key share", func(d PartyClient, aggregate bool) (*ShareResponse, error) {
+		return d.GenPublicKeyShare(ctx, &PublicKeyShareRequest{CrsSeed: crsSeed, Online: c.online, Aggregate: aggregate})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, data := range shares {
+		share, err := examples.DecodePublicKeyGenShare(params, data)
+		if err != nil {
+			return nil, fmt.Errorf("party %d: %w", i, err)
+		}
+		ckg.AggregateShares(share, roundShare, &roundShare)
+	}
+	c.pk = rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(roundShare, crp, c.pk)
+	return c, nil
+}
+
+// auth 在请求的元数据中附上会话令牌
+func (c *coordinator) auth(ctx context.Context) context.Context {
+	return withToken(ctx, c.token)
+}
+
+// collect 收集在线参与方的份额。k<2时协调方向每个在线参与方请求份额；否则只向聚合树的根节点请求，
+// 父节点聚合子节点转发的份额，协调方只收到根节点的一个聚合份额。返回的份额下标即参与方编号
+func (c *coordinator) collect(what string, request func(d PartyClient, aggregate bool) (*ShareResponse, error)) ([][]byte, error) {
+	decryptors, aggregate := c.decryptors, c.k >= 2
+	if aggregate {
+		decryptors = decryptors[:1]
+	}
+	shares := make([][]byte, len(decryptors))
+	for i, d := range decryptors {
+		resp, err := request(d, aggregate)
+		if err != nil {
+			return nil, fmt.Errorf("party %d: %s: %w", i, what, err)
+		}
+		shares[i] = resp.Share
+	}
+	return shares, nil
+}
+
+// register 在在线参与方处登记待解密的密文，参与方只对登记过的密文作答
+func (c *coordinator) register(ctx context.Context, ct *rlwe.Ciphertext) error {
+	data, err := ct.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for i, d := range c.decryptors {
+		if _, err := d.RegisterCiphertext(c.auth(ctx), &RegisterCiphertextRequest{Ciphertext: data}); err != nil {
+			return fmt.Errorf("party %d: registering a ciphertext: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// decrypt 在线参与方对登记过的ct生成带噪声淹没的密钥切换份额，协调方聚合份额，把ct切换到零私钥后解密并解码
+func (c *coordinator) decrypt(ctx context.Context, ct *rlwe.Ciphertext) ([]uint64, error) {
+	data, err := ct.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	shares, err := c.collect("partial decryption", func(d PartyClient, aggregate bool) (*ShareResponse, error) {
+		return d.PartialDecrypt(c.auth(ctx), &PartialDecryptRequest{Ciphertext: data, Online: c.online, Aggregate: aggregate})
+	})
+	if err != nil {
+		return nil, err
+	}
+	var agg mhe.KeySwitchShare
+	for i, data := range shares {
+		share, err := examples.DecodeKeySwitchShare(c.params, ct.Level(), data)
+		if err != nil {
+			return nil, fmt.Errorf("party %d: %w", i, err)
+		}
+		if i == 0 {
+			agg = share
+		} else if err := c.ks.AggregateShares(agg, share, &agg); err != nil {
+			return nil, fmt.Errorf("party %d: %w", i, err)
+		}
+	}
+	ctOut := heint.NewCiphertext(c.params, 1, ct.Level())
+	c.ks.KeySwitch(ct, agg, ctOut)
+	res := make([]uint64, c.params.MaxSlots())
+	if err := c.encoder.Decode(c.dec.DecryptNew(ctOut), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// run 通过RPC驱动参与方节点完成一次流程：会话建立、秘密共享、公钥生成、加密、同态加法和门限解密
+func run(ctx context.Context, cfg config) (*result, error) {
+	c, err := newCoordinator(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	params := c.params
+	N := len(c.clients)
+	pkData, err := c.pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	//*****加密*****
+	fmt.Println("> Encrypt Phase")
+	cts := make([]*rlwe.Ciphertext, N)
+	for i, p := range c.clients {
+		resp, err := p.Encrypt(c.auth(ctx), &EncryptRequest{PublicKey: pkData})
+		if err != nil {
+			return nil, fmt.Errorf("party %d: encrypt: %w", i, err)
+		}
+		if cts[i], err = examples.DecodeCiphertext(params, resp.Ciphertext); err != nil {
+			return nil, fmt.Errorf("party %d: %w", i, err)
+		}
+	}
+
+	//*****同态加法*****
+	fmt.Println("> Computation Phase")
+	out := &result{params: params, decrypted: make([][]uint64, N)}
+	// 参与同态加法的两个参与方，N<3时回绕
+	a, b := 1%N, 2%N
+	out.summands = [2]int{a, b}
+	ctadd := heint.NewCiphertext(params, 1, params.MaxLevel())
+	if err := heint.NewEvaluator(params, nil).Add(cts[a], cts[b], ctadd); err != nil {
+		return nil, fmt.Errorf("adding: %w", err)
+	}
+
+	// 登记参与方的密文和计算结果，只有它们会被解密
+	for _, ct := range append(cts, ctadd) {
+		if err := c.register(ctx, ct); err != nil {
+			return nil, err
+		}
+	}
+
+	//*****解密*****
+	fmt.Println("> Decrypt Phase")
+	for i, ct := range cts {
+		if out.decrypted[i], err = c.decrypt(ctx, ct); err != nil {
+			return nil, err
+		}
+		fmt.Printf("\t%v...%v\n", out.decrypted[i][:8], out.decrypted[i][params.N()-8:]) //打印前八个元素和后八个元素
+	}
+	if out.sum, err = c.decrypt(ctx, ctadd); err != nil {
+		return nil, err
+	}
+	fmt.Printf("ctadd 解密得%v...%v\n", out.sum[:8], out.sum[params.N()-8:]) //打印前八个元素和后八个元素
+	return out, nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+// openAPI 网关接口的OpenAPI描述，由GET /v1/openapi.yaml返回
+//
+//go:embed openapi.yaml
+var openAPI []byte
+
+const (
+	maxOwnerLength = 64 // 数据拥有者名称的最大长度
+	minInputs      = 2  // 一次计算的最少输入数量
+)
+
+// gateway 供数据拥有者使用的HTTP/JSON接口：获取集体公钥，上传用rlwe.NewEncryptor(params, pk)加密的输入，
+// 发起计算，并请求对计算结果做门限解密。网关运行在协调方上，和协调方一样不持有任何私钥。
+//
+// 数据拥有者的名称没有认证，网关不保护单个输入：客户端可以用伪造的拥有者上传0的加密，与他人的输入求和后解密，
+// 或者解密只差一个输入的两个求和再相减。需要保护输入的部署必须认证数据拥有者，并限制每个拥有者能发起的计算。
+type gateway struct {
+	mu sync.Mutex
+
+	c         *coordinator
+	eval      *heint.Evaluator
+	pkData    []byte
+	maxBody   int64 // 请求体的最大字节数，足以容纳一个base64编码的密文
+	owners    map[string]bool
+	inputs    []*gatewayInput
+	computing []*gatewayComputation
+}
+
+type gatewayInput struct {
+	ID    int    `json:"id"`
+	Owner string `json:"owner"`
+
+	ct *rlwe.Ciphertext
+}
+
+type gatewayComputation struct {
+	ID        int    `json:"id"`
+	Operation string `json:"operation"`
+	Inputs    []int  `json:"inputs"`
+	Decrypted bool   `json:"decrypted"`
+
+	ct         *rlwe.Ciphertext
+	values     []uint64
+	decrypting bool // 门限解密进行中
+}
+
+// 请求和响应的JSON格式，见openapi.yaml
+type (
+	paramsResponse struct {
+		Params      examples.ParametersConfig `json:"params"`
+		Fingerprint string                    `json:"fingerprint"`
+		Slots       int                       `json:"slots"`
+	}
+	publicKeyResponse struct {
+		PublicKey []byte `json:"publicKey"`
+	}
+	inputRequest struct {
+		Owner      string `json:"owner"`
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	inputsResponse struct {
+		Inputs []*gatewayInput `json:"inputs"`
+	}
+	computationRequest struct {
+		Operation string `json:"operation"`
+		Inputs    []int  `json:"inputs"`
+	}
+	decryptionResponse struct {
+		ID     int      `json:"id"`
+		Values []uint64 `json:"values"`
+	}
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+// operationSum 目前唯一支持的计算：输入的逐槽位求和
+const operationSum = "sum"
+
+func newGateway(c *coordinator) (*gateway, error) {
+	pkData, err := c.pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	ctSize := heint.NewCiphertext(c.params, 1, c.params.MaxLevel()).BinarySize()
+	return &gateway{
+		c:       c,
+		eval:    heint.NewEvaluator(c.params, nil),
+		pkData:  pkData,
+		maxBody: int64(4*(ctSize+2)/3 + 1<<10),
+		owners:  map[string]bool{},
+	}, nil
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/v1/openapi.yaml":
+		g.method(w, r, http.MethodGet, func() {
+			w.Header().Set("Content-Type", "application/yaml")
+			w.Write(openAPI)
+		})
+	case path == "/v1/params":
+		g.method(w, r, http.MethodGet, func() { g.getParams(w) })
+	case path == "/v1/public-key":
+		g.method(w, r, http.MethodGet, func() { writeJSON(w, http.StatusOK, publicKeyResponse{PublicKey: g.pkData}) })
+	case path == "/v1/inputs" && r.Method == http.MethodGet:
+		g.listInputs(w)
+	case path == "/v1/inputs":
+		g.method(w, r, http.MethodPost, func() { g.postInput(w, r) })
+	case path == "/v1/computations":
+		g.method(w, r, http.MethodPost, func() { g.postComputation(w, r) })
+	case strings.HasPrefix(path, "/v1/computations/"):
+		id, action, _ := strings.Cut(strings.TrimPrefix(path, "/v1/computations/"), "/")
+		switch action {
+		case "":
+			g.method(w, r, http.MethodGet, func() { g.getComputation(w, id) })
+		case "decryption":
+			g.method(w, r, http.MethodPost, func() { g.postDecryption(w, r, id) })
+		default:
+			writeError(w, http.StatusNotFound, "no route for %s", r.URL.Path)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "no route for %s", r.URL.Path)
+	}
+}
+
+// method 只接受给定的HTTP方法
+func (g *gateway) method(w http.ResponseWriter, r *http.Request, method string, handle func()) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "%s is not allowed, use %s", r.Method, method)
+		return
+	}
+	handle()
+}
+
+func (g *gateway) getParams(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, paramsResponse{
+		Params:      g.c.config,
+		Fingerprint: examples.HEIntFingerprint(g.c.params),
+		Slots:       g.c.params.MaxSlots(),
+	})
+}
+
+func (g *gateway) listInputs(w http.ResponseWriter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	writeJSON(w, http.StatusOK, inputsResponse{Inputs: append([]*gatewayInput{}, g.inputs...)})
+}
+
+func (g *gateway) postInput(w http.ResponseWriter, r *http.Request) {
+	var req inputRequest
+	if !g.readJSON(w, r, &req) {
+		return
+	}
+	if req.Owner == "" || len(req.Owner) > maxOwnerLength {
+		writeError(w, http.StatusBadRequest, "owner must have between 1 and %d characters", maxOwnerLength)
+		return
+	}
+	ct, err := examples.DecodeCiphertext(g.c.params, req.Ciphertext)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ciphertext: %v", err)
+		return
+	}
+	// 只接受新鲜的加密：同一层级和默认缩放因子，求和时不需要对齐
+	if ct.Level() != g.c.params.MaxLevel() || ct.Scale.Cmp(g.c.params.DefaultScale()) != 0 {
+		writeError(w, http.StatusBadRequest, "ciphertext: not a fresh encryption at level %d", g.c.params.MaxLevel())
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.owners[req.Owner] {
+		writeError(w, http.StatusConflict, "owner %q already submitted an input", req.Owner)
+		return
+	}
+	g.owners[req.Owner] = true
+	in := &gatewayInput{ID: len(g.inputs), Owner: req.Owner, ct: ct}
+	g.inputs = append(g.inputs, in)
+	writeJSON(w, http.StatusCreated, in)
+}
+
+func (g *gateway) postComputation(w http.ResponseWriter, r *http.Request) {
+	var req computationRequest
+	if !g.readJSON(w, r, &req) {
+		return
+	}
+	if req.Operation == "" {
+		req.Operation = operationSum
+	}
+	if req.Operation != operationSum {
+		writeError(w, http.StatusBadRequest, "unsupported operation %q, expected %q", req.Operation, operationSum)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// 未指定输入时对全部输入求和
+	if req.Inputs == nil {
+		for _, in := range g.inputs {
+			req.Inputs = append(req.Inputs, in.ID)
+		}
+	}
+	if len(req.Inputs) < minInputs {
+		writeError(w, http.StatusBadRequest, "%d inputs, a computation needs at least %d", len(req.Inputs), minInputs)
+		return
+	}
+	seen := map[int]bool{}
+	for _, id := range req.Inputs {
+		if id < 0 || id >= len(g.inputs) || seen[id] {
+			writeError(w, http.StatusBadRequest, "invalid inputs %v", req.Inputs)
+			return
+		}
+		seen[id] = true
+	}
+	ct := g.inputs[req.Inputs[0]].ct.CopyNew()
+	for _, id := range req.Inputs[1:] {
+		if err := g.eval.Add(ct, g.inputs[id].ct, ct); err != nil {
+			writeError(w, http.StatusInternalServerError, "adding input %d: %v", id, err)
+			return
+		}
+	}
+	c := &gatewayComputation{ID: len(g.computing), Operation: req.Operation, Inputs: req.Inputs, ct: ct}
+	g.computing = append(g.computing, c)
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// computation 返回编号为id的计算，调用方持有锁
+func (g *gateway) computation(w http.ResponseWriter, id string) *gatewayComputation {
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 0 || i >= len(g.computing) {
+		writeError(w, http.StatusNotFound, "no computation %q", id)
+		return nil
+	}
+	return g.computing[i]
+}
+
+func (g *gateway) getComputation(w http.ResponseWriter, id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c := g.computation(w, id); c != nil {
+		writeJSON(w, http.StatusOK, c)
+	}
+}
+
+// postDecryption 请求在线参与方对计算结果做门限解密，结果缓存，重复请求不再解密。
+// 解密的RPC不持有锁，一个慢或不可达的参与方不会阻塞其他接口
+func (g *gateway) postDecryption(w http.ResponseWriter, r *http.Request, id string) {
+	g.mu.Lock()
+	c := g.computation(w, id)
+	if c == nil {
+		g.mu.Unlock()
+		return
+	}
+	if c.Decrypted {
+		resp := decryptionResponse{ID: c.ID, Values: c.values}
+		g.mu.Unlock()
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	// 同一计算的并发请求不再发起解密；失败后可以重试，参与方对同一密文返回同一份额
+	if c.decrypting {
+		g.mu.Unlock()
+		writeError(w, http.StatusConflict, "the decryption of computation %d is in progress", c.ID)
+		return
+	}
+	c.decrypting = true
+	ct := c.ct
+	g.mu.Unlock()
+
+	values, err := g.decrypt(r.Context(), ct)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c.decrypting = false
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "threshold decryption: %v", err)
+		return
+	}
+	c.values, c.Decrypted = values, true
+	writeJSON(w, http.StatusOK, decryptionResponse{ID: c.ID, Values: c.values})
+}
+
+// decrypt 登记ct并请求门限解密，不持有锁
+func (g *gateway) decrypt(ctx context.Context, ct *rlwe.Ciphertext) ([]uint64, error) {
+	if err := g.c.register(ctx, ct); err != nil {
+		return nil, err
+	}
+	return g.c.decrypt(ctx, ct)
+}
+
+// readJSON 解码请求体，拒绝非JSON、过大、含未知字段或多余数据的请求
+func (g *gateway) readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" && !strings.HasPrefix(ct, "application/json;") {
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, g.maxBody))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("unexpected data after the JSON object")
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body larger than %d bytes", tooLarge.Limit)
+		} else {
+			writeError(w, http.StatusBadRequest, "invalid request: %v", err)
+		}
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, errorResponse{Error: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,4 @@
+package main
+
+// party.pb.go和party_grpc.pb.go由party.proto生成，修改party.proto后重新运行go generate
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative party.proto
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+var flagListen = flag.String("listen", "", "run a party node serving the gRPC API of party.proto on the given address, e.g. \":7000\"")
+var flagCoordinate = flag.String("coordinate", "", "run the coordinator over the comma-separated addresses of the party nodes, in the order of the parties")
+var flagT = flag.Int("t", 0, "the threshold, 0 for the CRS flow where every party must take part in the decryption")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagSession = flag.String("session", "", "the session ID, random if empty")
+var flagTimeout = flag.Duration("timeout", 10*time.Minute, "the timeout of a coordinated run")
+var flagK = flag.Int("k", 0, "the arity of the aggregation tree, 0 for the coordinator to collect the share of every party; the parties connect to each other at the addresses of -coordinate")
+var flagHTTP = flag.String("http", "", "with -coordinate: after the key generation, serve the HTTP/JSON gateway of openapi.yaml on the given address instead of running the demo")
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
+}
+
+// 节点之间的连接没有认证和加密，仅用于演示；实际部署时应使用TLS
+func main() {
+	flag.Parse()
+	// 节点和网关对外提供服务，禁止替换crypto/rand的种子随机性
+	examples.RefuseSeededRandomness()
+
+	switch {
+	case *flagListen != "":
+		lis, err := net.Listen("tcp", *flagListen)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		srv := grpc.NewServer()
+		RegisterPartyServer(srv, newNode())
+		fmt.Println("party node listening on", lis.Addr())
+		if err := srv.Serve(lis); err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case *flagCoordinate != "":
+		// 创建参数字面量，-params时从配置文件读取
+		literal := paramsLiteral
+		if *flagParams != "" {
+			config, err := examples.LoadParametersConfig(*flagParams)
+			if err != nil {
+				fmt.Println("Error loading parameters:", err)
+				return
+			}
+			if literal, err = config.HEIntLiteral(); err != nil {
+				fmt.Println("Error loading parameters:", err)
+				return
+			}
+		}
+		session := *flagSession
+		if session == "" {
+			seed := make([]byte, 32)
+			if _, err := rand.Read(seed); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			session = examples.NewSessionID(seed)
+		}
+
+		var clients []PartyClient
+		var addrs []string
+		for _, addr := range strings.Split(*flagCoordinate, ",") {
+			addr = strings.TrimSpace(addr)
+			addrs = append(addrs, addr)
+			conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			defer conn.Close()
+			clients = append(clients, NewPartyClient(conn))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), *flagTimeout)
+		defer cancel()
+		cfg := config{clients: clients, t: *flagT, literal: literal, session: session, k: *flagK, addrs: addrs}
+
+		// -http时由数据拥有者通过网关上传输入并请求解密
+		if *flagHTTP != "" {
+			c, err := newCoordinator(ctx, cfg)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			g, err := newGateway(c)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			fmt.Println("gateway listening on", *flagHTTP)
+			if err := http.ListenAndServe(*flagHTTP, g); err != nil {
+				fmt.Println("Error:", err)
+			}
+			return
+		}
+
+		if _, err := run(ctx, cfg); err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	default:
+		fmt.Println("Error: one of -listen and -coordinate is required")
+		flag.Usage()
+	}
+}
@@ -0,0 +1,644 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+)
+
+// startNodes 在本地监听端口上启动N个参与方节点，返回连接它们的客户端和它们的地址
+func startNodes(t *testing.T, N int) ([]PartyClient, []string) {
+	t.Helper()
+	nodes := make([]*node, N)
+	for i := range nodes {
+		nodes[i] = newNode()
+	}
+	return serveNodes(t, nodes)
+}
+
+// serveNodes 在本地监听端口上为每个节点启动gRPC服务，返回连接它们的客户端和它们的地址
+func serveNodes(t *testing.T, nodes []*node) ([]PartyClient, []string) {
+	t.Helper()
+	clients := make([]PartyClient, len(nodes))
+	addrs := make([]string, len(nodes))
+	for i, n := range nodes {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := grpc.NewServer()
+		RegisterPartyServer(srv, n)
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+
+		addrs[i] = lis.Addr().String()
+		conn, err := grpc.NewClient(addrs[i], grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		clients[i] = NewPartyClient(conn)
+	}
+	return clients, addrs
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		N, t int
+	}{
+		{N: 2},
+		{N: 3},
+		{N: 3, t: 3},
+		{N: 3, t: 2},
+		{N: 4, t: 3},
+	} {
+		t.Run(fmt.Sprintf("N=%d/t=%d", tc.N, tc.t), func(t *testing.T) {
+			clients, addrs := startNodes(t, tc.N)
+			ctx := context.Background()
+			out, err := run(ctx, config{clients: clients, t: tc.t, literal: examples.HEIntParamsN12QP109, session: t.Name(), addrs: addrs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResult(t, out)
+
+			for i, c := range clients {
+				s, err := c.Status(ctx, &StatusRequest{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if s.Session != t.Name() || int(s.Party) != i || s.Fingerprint != examples.HEIntFingerprint(out.params) {
+					t.Errorf("party %d: status %+v", i, s)
+				}
+				want := tc.N
+				if tc.t == 0 || tc.t == tc.N {
+					want = 0
+				}
+				if int(s.ShamirSharesReceived) != want {
+					t.Errorf("party %d: received %d Shamir shares, want %d", i, s.ShamirSharesReceived, want)
+				}
+			}
+		})
+	}
+}
+
+// checkResult 检查每个参与方的解密结果和ctadd的解密结果
+func checkResult(t *testing.T, out *result) {
+	t.Helper()
+	T := out.params.PlaintextModulus()
+	for i, got := range out.decrypted {
+		if want := demoInput(out.params, i); !reflect.DeepEqual(got, want) {
+			t.Errorf("party %d: decrypted %v..., want %v...", i, got[:4], want[:4])
+		}
+	}
+	a, b := out.summands[0], out.summands[1]
+	want := make([]uint64, len(out.sum))
+	for k := range want {
+		want[k] = uint64(a+b) % T
+	}
+	if !reflect.DeepEqual(out.sum, want) {
+		t.Errorf("ctadd: decrypted %v..., want %v...", out.sum[:4], want[:4])
+	}
+}
+
+// countingClient 统计协调方从参与方收到的份额
+type countingClient struct {
+	PartyClient
+	shares int
+}
+
+func (c *countingClient) GenPublicKeyShare(ctx context.Context, in *PublicKeyShareRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	c.shares++
+	return c.PartyClient.GenPublicKeyShare(ctx, in, opts...)
+}
+
+func (c *countingClient) PartialDecrypt(ctx context.Context, in *PartialDecryptRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	c.shares++
+	return c.PartyClient.PartialDecrypt(ctx, in, opts...)
+}
+
+// TestTree 按k叉树聚合时，根节点每轮只收到k个子节点的聚合份额，协调方只收到根节点的份额
+func TestTree(t *testing.T) {
+	for _, tc := range []struct {
+		N, t, k int
+	}{
+		{N: 7, k: 2},
+		{N: 7, t: 5, k: 2},
+		{N: 10, k: 3},
+	} {
+		t.Run(fmt.Sprintf("N=%d/t=%d/k=%d", tc.N, tc.t, tc.k), func(t *testing.T) {
+			nodes := make([]*node, tc.N)
+			for i := range nodes {
+				nodes[i] = newNode()
+			}
+			// 根节点向子节点的每个请求带回一个份额
+			var mu sync.Mutex
+			rootInbound := map[string]int{}
+			nodes[0].dialOptions = append(nodes[0].dialOptions, grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				mu.Lock()
+				rootInbound[method]++
+				mu.Unlock()
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}))
+			clients, addrs := serveNodes(t, nodes)
+			counted := make([]*countingClient, tc.N)
+			for i := range clients {
+				counted[i] = &countingClient{PartyClient: clients[i]}
+				clients[i] = counted[i]
+			}
+
+			out, err := run(context.Background(), config{clients: clients, t: tc.t, literal: examples.HEIntParamsN12QP109, session: t.Name(), k: tc.k, addrs: addrs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResult(t, out)
+
+			// 每个参与方的密文和ctadd各解密一轮
+			rounds := tc.N + 1
+			if got := rootInbound[Party_GenPublicKeyShare_FullMethodName]; got != tc.k {
+				t.Errorf("the root received %d public-key shares, want %d", got, tc.k)
+			}
+			if got := rootInbound[Party_PartialDecrypt_FullMethodName]; got != tc.k*rounds {
+				t.Errorf("the root received %d partial decryptions in %d rounds, want %d", got, rounds, tc.k*rounds)
+			}
+			for i, c := range counted {
+				want := 0
+				if i == 0 {
+					want = 1 + rounds
+				}
+				if c.shares != want {
+					t.Errorf("the coordinator received %d shares from party %d, want %d", c.shares, i, want)
+				}
+			}
+		})
+	}
+
+	// 缺少参与方地址时无法建立聚合树，门限流程中参与方也无法互发Shamir份额
+	clients, _ := startNodes(t, 3)
+	if _, err := newCoordinator(context.Background(), config{clients: clients, literal: examples.HEIntParamsN12QP109, session: t.Name(), k: 2}); err == nil {
+		t.Error("aggregation tree without the addresses of the parties: no error")
+	}
+	if _, err := newCoordinator(context.Background(), config{clients: clients, t: 2, literal: examples.HEIntParamsN12QP109, session: t.Name()}); err == nil {
+		t.Error("threshold flow without the addresses of the parties: no error")
+	}
+}
+
+// TestMalformed 参与方拒绝格式错误、不符合会话状态或没有会话令牌的请求，而不是崩溃
+func TestMalformed(t *testing.T) {
+	clients, addrs := startNodes(t, 1)
+	c := clients[0]
+	ctx := context.Background()
+
+	if _, err := c.Encrypt(ctx, &EncryptRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("encrypt before setup: %v, want %v", err, codes.FailedPrecondition)
+	}
+	if _, err := c.Setup(ctx, &SetupRequest{Params: []byte("{")}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("setup with invalid parameters: %v, want %v", err, codes.InvalidArgument)
+	}
+
+	params, err := json.Marshal(examples.HEIntConfig("", examples.HEIntParamsN12QP109))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := bytes.Repeat([]byte{1}, minTokenSize)
+	if _, err := c.Setup(ctx, &SetupRequest{Params: params, Parties: 3, Threshold: 2, Token: token}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("threshold setup without the addresses of the parties: %v, want %v", err, codes.InvalidArgument)
+	}
+	// 参与方1和2的地址不会被连接
+	peers := []string{addrs[0], addrs[0], addrs[0]}
+	if _, err := c.Setup(ctx, &SetupRequest{Params: params, Parties: 3, Threshold: 2, Peers: peers, Token: make([]byte, minTokenSize-1)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("setup with a short session token: %v, want %v", err, codes.InvalidArgument)
+	}
+	if _, err := c.Setup(ctx, &SetupRequest{Params: params, Parties: 3, Threshold: 2, Peers: peers, Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	// 没有会话令牌或令牌错误的请求被拒绝
+	if _, err := c.RegisterCiphertext(ctx, &RegisterCiphertextRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("request without the session token: %v, want %v", err, codes.Unauthenticated)
+	}
+	if _, err := c.RegisterCiphertext(withToken(ctx, make([]byte, minTokenSize)), &RegisterCiphertextRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("request with a wrong session token: %v, want %v", err, codes.Unauthenticated)
+	}
+	ctx = withToken(ctx, token)
+	if _, err := c.PutShamirShare(ctx, &PutShamirShareRequest{From: 1, Share: []byte{1, 2, 3}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed Shamir share: %v, want %v", err, codes.InvalidArgument)
+	}
+	if _, err := c.PutShamirShare(ctx, &PutShamirShareRequest{From: 0}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Shamir share from the party itself: %v, want %v", err, codes.InvalidArgument)
+	}
+	if _, err := c.RegisterCiphertext(ctx, &RegisterCiphertextRequest{Ciphertext: []byte{1, 2, 3}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed ciphertext: %v, want %v", err, codes.InvalidArgument)
+	}
+	if _, err := c.GenPublicKeyShare(ctx, &PublicKeyShareRequest{CrsSeed: make([]byte, 32), Online: []int32{0, 1}}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("public-key share before the Shamir shares: %v, want %v", err, codes.FailedPrecondition)
+	}
+}
+
+// TestPartialDecrypt 参与方只对登记过的密文作答，每个密文只生成一次份额，且份额带有噪声淹没
+func TestPartialDecrypt(t *testing.T) {
+	clients, _ := startNodes(t, 1)
+	c := clients[0]
+	ctx := context.Background()
+	paramsJSON, err := json.Marshal(examples.HEIntConfig("", examples.HEIntParamsN12QP109))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := bytes.Repeat([]byte{1}, minTokenSize)
+	if _, err := c.Setup(ctx, &SetupRequest{Params: paramsJSON, Parties: 1, Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	ctx = withToken(ctx, token)
+	params, err := heint.NewParametersFromLiteral(examples.HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 两个c1相同(为0)的构造密文：没有噪声淹没时两个份额c1·sk都为0
+	var shares []mhe.KeySwitchShare
+	for _, c0 := range []uint64{0, 1} {
+		ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+		ct.Value[0].Coeffs[0][0] = c0
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.PartialDecrypt(ctx, &PartialDecryptRequest{Ciphertext: data}); status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("unregistered ciphertext: %v, want %v", err, codes.FailedPrecondition)
+		}
+		if _, err := c.RegisterCiphertext(ctx, &RegisterCiphertextRequest{Ciphertext: data}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.PartialDecrypt(ctx, &PartialDecryptRequest{Ciphertext: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, err := examples.DecodeKeySwitchShare(params, ct.Level(), resp.Share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, share)
+		// 重试返回同一份额，不会产生新的噪声淹没样本
+		again, err := c.PartialDecrypt(ctx, &PartialDecryptRequest{Ciphertext: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again.Share, resp.Share) {
+			t.Error("second partial decryption: a new share")
+		}
+	}
+	if shares[0].Value.Equal(&shares[1].Value) {
+		t.Error("the shares of c1·sk for equal c1 are equal: no smudging noise")
+	}
+}
+
+// TestGateway 通过HTTP网关走完整个流程：获取参数和公钥、上传加密的输入、计算求和、门限解密
+func TestGateway(t *testing.T) {
+	ctx := context.Background()
+	clients, addrs := startNodes(t, 3)
+	c, err := newCoordinator(ctx, config{clients: clients, t: 2, literal: examples.HEIntParamsN12QP109, session: t.Name(), addrs: addrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个参与方只发一次Shamir份额
+	if _, err := clients[0].DealShamirShares(c.auth(ctx), &DealShamirSharesRequest{}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("dealing the Shamir shares twice: %v, want %v", err, codes.AlreadyExists)
+	}
+	g, err := newGateway(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	// do 发送请求并把响应解码到out，检查状态码
+	do := func(method, path string, body any, want int, out any) {
+		t.Helper()
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, srv.URL+path, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, want, data)
+		}
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+
+	var params paramsResponse
+	do(http.MethodGet, "/v1/params", nil, http.StatusOK, &params)
+	literal, err := params.Params.HEIntLiteral()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := heint.NewParametersFromLiteral(literal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Fingerprint != examples.HEIntFingerprint(p) || params.Slots != p.MaxSlots() {
+		t.Fatalf("params: %+v", params)
+	}
+
+	var pkResp publicKeyResponse
+	do(http.MethodGet, "/v1/public-key", nil, http.StatusOK, &pkResp)
+	pk, err := examples.DecodePublicKey(p, pkResp.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 数据拥有者在本地加密自己的输入
+	encoder, encryptor := heint.NewEncoder(p), rlwe.NewEncryptor(p, pk)
+	owners := []string{"alice", "bob", "carol"}
+	want := make([]uint64, p.MaxSlots())
+	for k, owner := range owners {
+		values := make([]uint64, p.MaxSlots())
+		for j := range values {
+			values[j] = uint64(10*k + j%7)
+			want[j] = (want[j] + values[j]) % p.PlaintextModulus()
+		}
+		pt := heint.NewPlaintext(p, p.MaxLevel())
+		if err := encoder.Encode(values, pt); err != nil {
+			t.Fatal(err)
+		}
+		ct, err := encryptor.EncryptNew(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var in gatewayInput
+		do(http.MethodPost, "/v1/inputs", inputRequest{Owner: owner, Ciphertext: data}, http.StatusCreated, &in)
+		if in.ID != k || in.Owner != owner {
+			t.Fatalf("input: %+v", in)
+		}
+		do(http.MethodPost, "/v1/inputs", inputRequest{Owner: owner, Ciphertext: data}, http.StatusConflict, nil)
+	}
+
+	// 请求校验
+	do(http.MethodPost, "/v1/inputs", inputRequest{Owner: "mallory", Ciphertext: []byte{1, 2, 3}}, http.StatusBadRequest, nil)
+	do(http.MethodPost, "/v1/inputs", map[string]any{"owner": "mallory", "extra": 1}, http.StatusBadRequest, nil)
+	do(http.MethodPost, "/v1/inputs", inputRequest{Owner: "mallory", Ciphertext: make([]byte, g.maxBody)}, http.StatusRequestEntityTooLarge, nil)
+	do(http.MethodPost, "/v1/computations", computationRequest{Inputs: []int{1}}, http.StatusBadRequest, nil)
+	do(http.MethodPost, "/v1/computations", computationRequest{Inputs: []int{0, 0}}, http.StatusBadRequest, nil)
+	do(http.MethodPost, "/v1/computations", computationRequest{Operation: "mul"}, http.StatusBadRequest, nil)
+	do(http.MethodDelete, "/v1/inputs", nil, http.StatusMethodNotAllowed, nil)
+	do(http.MethodGet, "/v1/computations/0", nil, http.StatusNotFound, nil)
+
+	var inputs inputsResponse
+	do(http.MethodGet, "/v1/inputs", nil, http.StatusOK, &inputs)
+	if len(inputs.Inputs) != len(owners) {
+		t.Fatalf("%d inputs, want %d", len(inputs.Inputs), len(owners))
+	}
+
+	var comp gatewayComputation
+	do(http.MethodPost, "/v1/computations", computationRequest{}, http.StatusCreated, &comp)
+	if comp.Operation != operationSum || !reflect.DeepEqual(comp.Inputs, []int{0, 1, 2}) || comp.Decrypted {
+		t.Fatalf("computation: %+v", comp)
+	}
+	path := fmt.Sprintf("/v1/computations/%d", comp.ID)
+	var dec decryptionResponse
+	do(http.MethodPost, path+"/decryption", nil, http.StatusOK, &dec)
+	if !reflect.DeepEqual(dec.Values, want) {
+		t.Errorf("decrypted %v..., want %v...", dec.Values[:8], want[:8])
+	}
+	do(http.MethodGet, path, nil, http.StatusOK, &comp)
+	if !comp.Decrypted {
+		t.Errorf("computation not marked as decrypted")
+	}
+
+	// OpenAPI描述覆盖网关的每个路径
+	var doc struct {
+		Paths map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(openAPI, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/v1/openapi.yaml", "/v1/params", "/v1/public-key", "/v1/inputs", "/v1/computations", "/v1/computations/{id}", "/v1/computations/{id}/decryption"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("openapi.yaml does not describe %s", path)
+		}
+	}
+}
+
+// blockingClient 在release关闭前阻塞部分解密，模拟慢的参与方
+type blockingClient struct {
+	PartyClient
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingClient) PartialDecrypt(ctx context.Context, in *PartialDecryptRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	close(c.started)
+	<-c.release
+	return c.PartyClient.PartialDecrypt(ctx, in, opts...)
+}
+
+// TestGatewaySlowParty 一个慢的参与方不阻塞网关的其他接口，同一计算的并发解密请求被拒绝
+func TestGatewaySlowParty(t *testing.T) {
+	ctx := context.Background()
+	clients, _ := startNodes(t, 2)
+	c, err := newCoordinator(ctx, config{clients: clients, literal: examples.HEIntParamsN12QP109, session: t.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := &blockingClient{PartyClient: c.decryptors[1], started: make(chan struct{}), release: make(chan struct{})}
+	c.decryptors[1] = slow
+	g, err := newGateway(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	post := func(path string, body any) *http.Response {
+		t.Helper()
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := srv.Client().Post(srv.URL+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	encryptor := rlwe.NewEncryptor(c.params, c.pk)
+	for _, owner := range []string{"alice", "bob"} {
+		ct, err := encryptor.EncryptNew(heint.NewPlaintext(c.params, c.params.MaxLevel()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp := post("/v1/inputs", inputRequest{Owner: owner, Ciphertext: data}); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("input: status %d", resp.StatusCode)
+		}
+	}
+	if resp := post("/v1/computations", computationRequest{}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("computation: status %d", resp.StatusCode)
+	}
+
+	done := make(chan int)
+	go func() {
+		resp, err := srv.Client().Post(srv.URL+"/v1/computations/0/decryption", "application/json", nil)
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	<-slow.started
+
+	// 解密阻塞在参与方1时，其他接口照常响应
+	resp, err := srv.Client().Get(srv.URL + "/v1/computations/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("computation during the decryption: status %d", resp.StatusCode)
+	}
+	if resp := post("/v1/computations/0/decryption", nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("concurrent decryption: status %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	close(slow.release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("decryption: status %d, want %d", code, http.StatusOK)
+	}
+}
+
+// failingClient 前fail次部分解密失败，模拟暂时不可达的参与方
+type failingClient struct {
+	PartyClient
+	fail int
+}
+
+func (c *failingClient) PartialDecrypt(ctx context.Context, in *PartialDecryptRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	if c.fail > 0 {
+		c.fail--
+		return nil, status.Error(codes.Unavailable, "party unavailable")
+	}
+	return c.PartyClient.PartialDecrypt(ctx, in, opts...)
+}
+
+// TestGatewayRetry 一个参与方失败后重试解密：已经作答的参与方返回同一份额，重试得到正确的结果
+func TestGatewayRetry(t *testing.T) {
+	ctx := context.Background()
+	clients, addrs := startNodes(t, 3)
+	c, err := newCoordinator(ctx, config{clients: clients, t: 2, literal: examples.HEIntParamsN12QP109, session: t.Name(), addrs: addrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 参与方0先作答，参与方1第一次失败
+	c.decryptors[1] = &failingClient{PartyClient: c.decryptors[1], fail: 1}
+	g, err := newGateway(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	post := func(path string, body any, out any) int {
+		t.Helper()
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := srv.Client().Post(srv.URL+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+	encoder, encryptor := heint.NewEncoder(c.params), rlwe.NewEncryptor(c.params, c.pk)
+	want := make([]uint64, c.params.MaxSlots())
+	for k, owner := range []string{"alice", "bob"} {
+		values := make([]uint64, c.params.MaxSlots())
+		for j := range values {
+			values[j] = uint64(k + 3)
+			want[j] += values[j]
+		}
+		pt := heint.NewPlaintext(c.params, c.params.MaxLevel())
+		if err := encoder.Encode(values, pt); err != nil {
+			t.Fatal(err)
+		}
+		ct, err := encryptor.EncryptNew(pt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := post("/v1/inputs", inputRequest{Owner: owner, Ciphertext: data}, nil); code != http.StatusCreated {
+			t.Fatalf("input: status %d", code)
+		}
+	}
+	if code := post("/v1/computations", computationRequest{}, nil); code != http.StatusCreated {
+		t.Fatalf("computation: status %d", code)
+	}
+
+	if code := post("/v1/computations/0/decryption", nil, nil); code != http.StatusBadGateway {
+		t.Fatalf("decryption with a failing party: status %d, want %d", code, http.StatusBadGateway)
+	}
+	var dec decryptionResponse
+	if code := post("/v1/computations/0/decryption", nil, &dec); code != http.StatusOK {
+		t.Fatalf("retried decryption: status %d, want %d", code, http.StatusOK)
+	}
+	if !reflect.DeepEqual(dec.Values, want) {
+		t.Errorf("retried decryption: %v..., want %v...", dec.Values[:8], want[:8])
+	}
+}
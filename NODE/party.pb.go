@@ -0,0 +1,936 @@
+// 参与方节点的gRPC接口：协调方通过它驱动长期运行的参与方完成CRS流程和门限流程。
+// 多项式、密钥和密文以Lattigo的二进制编码放在bytes字段中，接收方用examples.Decode*解码并检查。
+// 聚合树由online中的参与方按顺序构成(CRS流程中为全部参与方按编号)，与examples.AggregateTree相同，
+// 位置i的父节点为位置(i-1)/arity，协调方只向位置0的根节点请求聚合份额。
+// 除Setup和Status外，每个请求都必须在元数据session-token中带有Setup时协调方给出的会话令牌(十六进制)。
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: party.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SetupRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Session   string                 `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Params    []byte                 `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`        // JSON编码的examples.ParametersConfig
+	Party     int32                  `protobuf:"varint,3,opt,name=party,proto3" json:"party,omitempty"`         // 参与方编号，从0开始
+	Parties   int32                  `protobuf:"varint,4,opt,name=parties,proto3" json:"parties,omitempty"`     // 参与方数量
+	Threshold int32                  `protobuf:"varint,5,opt,name=threshold,proto3" json:"threshold,omitempty"` // 门限，0或等于parties时不做秘密共享
+	Arity     int32                  `protobuf:"varint,6,opt,name=arity,proto3" json:"arity,omitempty"`         // 聚合树的叉数，小于2时协调方逐个收集份额
+	// 各参与方节点的地址，下标即参与方编号；门限流程中参与方按地址互发Shamir份额，arity不小于2时还连接子节点
+	Peers         []string `protobuf:"bytes,7,rep,name=peers,proto3" json:"peers,omitempty"`
+	Token         []byte   `protobuf:"bytes,8,opt,name=token,proto3" json:"token,omitempty"` // 会话令牌，至少16字节，之后的请求必须带有它
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetupRequest) Reset() {
+	*x = SetupRequest{}
+	mi := &file_party_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetupRequest) ProtoMessage() {}
+
+func (x *SetupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetupRequest.ProtoReflect.Descriptor instead.
+func (*SetupRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SetupRequest) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *SetupRequest) GetParams() []byte {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *SetupRequest) GetParty() int32 {
+	if x != nil {
+		return x.Party
+	}
+	return 0
+}
+
+func (x *SetupRequest) GetParties() int32 {
+	if x != nil {
+		return x.Parties
+	}
+	return 0
+}
+
+func (x *SetupRequest) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *SetupRequest) GetArity() int32 {
+	if x != nil {
+		return x.Arity
+	}
+	return 0
+}
+
+func (x *SetupRequest) GetPeers() []string {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *SetupRequest) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+type SetupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"` // 参数指纹，协调方比对以确认各参与方使用相同的参数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetupResponse) Reset() {
+	*x = SetupResponse{}
+	mi := &file_party_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetupResponse) ProtoMessage() {}
+
+func (x *SetupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetupResponse.ProtoReflect.Descriptor instead.
+func (*SetupResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetupResponse) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+type PublicKeyShareRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CrsSeed       []byte                 `protobuf:"bytes,1,opt,name=crs_seed,json=crsSeed,proto3" json:"crs_seed,omitempty"`
+	Online        []int32                `protobuf:"varint,2,rep,packed,name=online,proto3" json:"online,omitempty"`
+	Aggregate     bool                   `protobuf:"varint,3,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublicKeyShareRequest) Reset() {
+	*x = PublicKeyShareRequest{}
+	mi := &file_party_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublicKeyShareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublicKeyShareRequest) ProtoMessage() {}
+
+func (x *PublicKeyShareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublicKeyShareRequest.ProtoReflect.Descriptor instead.
+func (*PublicKeyShareRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PublicKeyShareRequest) GetCrsSeed() []byte {
+	if x != nil {
+		return x.CrsSeed
+	}
+	return nil
+}
+
+func (x *PublicKeyShareRequest) GetOnline() []int32 {
+	if x != nil {
+		return x.Online
+	}
+	return nil
+}
+
+func (x *PublicKeyShareRequest) GetAggregate() bool {
+	if x != nil {
+		return x.Aggregate
+	}
+	return false
+}
+
+type ShareResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Share         []byte                 `protobuf:"bytes,1,opt,name=share,proto3" json:"share,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareResponse) Reset() {
+	*x = ShareResponse{}
+	mi := &file_party_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareResponse) ProtoMessage() {}
+
+func (x *ShareResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareResponse.ProtoReflect.Descriptor instead.
+func (*ShareResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ShareResponse) GetShare() []byte {
+	if x != nil {
+		return x.Share
+	}
+	return nil
+}
+
+type DealShamirSharesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DealShamirSharesRequest) Reset() {
+	*x = DealShamirSharesRequest{}
+	mi := &file_party_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DealShamirSharesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DealShamirSharesRequest) ProtoMessage() {}
+
+func (x *DealShamirSharesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DealShamirSharesRequest.ProtoReflect.Descriptor instead.
+func (*DealShamirSharesRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{4}
+}
+
+type DealShamirSharesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DealShamirSharesResponse) Reset() {
+	*x = DealShamirSharesResponse{}
+	mi := &file_party_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DealShamirSharesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DealShamirSharesResponse) ProtoMessage() {}
+
+func (x *DealShamirSharesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DealShamirSharesResponse.ProtoReflect.Descriptor instead.
+func (*DealShamirSharesResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{5}
+}
+
+type PutShamirShareRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          int32                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	Share         []byte                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutShamirShareRequest) Reset() {
+	*x = PutShamirShareRequest{}
+	mi := &file_party_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutShamirShareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutShamirShareRequest) ProtoMessage() {}
+
+func (x *PutShamirShareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutShamirShareRequest.ProtoReflect.Descriptor instead.
+func (*PutShamirShareRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PutShamirShareRequest) GetFrom() int32 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *PutShamirShareRequest) GetShare() []byte {
+	if x != nil {
+		return x.Share
+	}
+	return nil
+}
+
+type PutShamirShareResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Received      int32                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"` // 已收到的Shamir份额数量
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutShamirShareResponse) Reset() {
+	*x = PutShamirShareResponse{}
+	mi := &file_party_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutShamirShareResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutShamirShareResponse) ProtoMessage() {}
+
+func (x *PutShamirShareResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutShamirShareResponse.ProtoReflect.Descriptor instead.
+func (*PutShamirShareResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PutShamirShareResponse) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+type EncryptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EncryptRequest) Reset() {
+	*x = EncryptRequest{}
+	mi := &file_party_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EncryptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncryptRequest) ProtoMessage() {}
+
+func (x *EncryptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncryptRequest.ProtoReflect.Descriptor instead.
+func (*EncryptRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EncryptRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type CiphertextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ciphertext    []byte                 `protobuf:"bytes,1,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CiphertextResponse) Reset() {
+	*x = CiphertextResponse{}
+	mi := &file_party_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CiphertextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CiphertextResponse) ProtoMessage() {}
+
+func (x *CiphertextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CiphertextResponse.ProtoReflect.Descriptor instead.
+func (*CiphertextResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CiphertextResponse) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+type RegisterCiphertextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ciphertext    []byte                 `protobuf:"bytes,1,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterCiphertextRequest) Reset() {
+	*x = RegisterCiphertextRequest{}
+	mi := &file_party_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterCiphertextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterCiphertextRequest) ProtoMessage() {}
+
+func (x *RegisterCiphertextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterCiphertextRequest.ProtoReflect.Descriptor instead.
+func (*RegisterCiphertextRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RegisterCiphertextRequest) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+type RegisterCiphertextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Digest        []byte                 `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"` // 密文编码的SHA-256摘要
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterCiphertextResponse) Reset() {
+	*x = RegisterCiphertextResponse{}
+	mi := &file_party_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterCiphertextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterCiphertextResponse) ProtoMessage() {}
+
+func (x *RegisterCiphertextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterCiphertextResponse.ProtoReflect.Descriptor instead.
+func (*RegisterCiphertextResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RegisterCiphertextResponse) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+type PartialDecryptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ciphertext    []byte                 `protobuf:"bytes,1,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	Online        []int32                `protobuf:"varint,2,rep,packed,name=online,proto3" json:"online,omitempty"`
+	Aggregate     bool                   `protobuf:"varint,3,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartialDecryptRequest) Reset() {
+	*x = PartialDecryptRequest{}
+	mi := &file_party_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartialDecryptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialDecryptRequest) ProtoMessage() {}
+
+func (x *PartialDecryptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialDecryptRequest.ProtoReflect.Descriptor instead.
+func (*PartialDecryptRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PartialDecryptRequest) GetCiphertext() []byte {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return nil
+}
+
+func (x *PartialDecryptRequest) GetOnline() []int32 {
+	if x != nil {
+		return x.Online
+	}
+	return nil
+}
+
+func (x *PartialDecryptRequest) GetAggregate() bool {
+	if x != nil {
+		return x.Aggregate
+	}
+	return false
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_party_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{13}
+}
+
+type StatusResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Session              string                 `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Party                int32                  `protobuf:"varint,2,opt,name=party,proto3" json:"party,omitempty"`
+	Phase                string                 `protobuf:"bytes,3,opt,name=phase,proto3" json:"phase,omitempty"`
+	Fingerprint          string                 `protobuf:"bytes,4,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	ShamirSharesReceived int32                  `protobuf:"varint,5,opt,name=shamir_shares_received,json=shamirSharesReceived,proto3" json:"shamir_shares_received,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_party_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_party_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_party_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StatusResponse) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetParty() int32 {
+	if x != nil {
+		return x.Party
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetShamirSharesReceived() int32 {
+	if x != nil {
+		return x.ShamirSharesReceived
+	}
+	return 0
+}
+
+var File_party_proto protoreflect.FileDescriptor
+
+const file_party_proto_rawDesc = "" +
+	"\n" +
+	"\vparty.proto\x12\bmhe.node\"\xd0\x01\n" +
+	"\fSetupRequest\x12\x18\n" +
+	"\asession\x18\x01 \x01(\tR\asession\x12\x16\n" +
+	"\x06params\x18\x02 \x01(\fR\x06params\x12\x14\n" +
+	"\x05party\x18\x03 \x01(\x05R\x05party\x12\x18\n" +
+	"\aparties\x18\x04 \x01(\x05R\aparties\x12\x1c\n" +
+	"\tthreshold\x18\x05 \x01(\x05R\tthreshold\x12\x14\n" +
+	"\x05arity\x18\x06 \x01(\x05R\x05arity\x12\x14\n" +
+	"\x05peers\x18\a \x03(\tR\x05peers\x12\x14\n" +
+	"\x05token\x18\b \x01(\fR\x05token\"1\n" +
+	"\rSetupResponse\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\"h\n" +
+	"\x15PublicKeyShareRequest\x12\x19\n" +
+	"\bcrs_seed\x18\x01 \x01(\fR\acrsSeed\x12\x16\n" +
+	"\x06online\x18\x02 \x03(\x05R\x06online\x12\x1c\n" +
+	"\taggregate\x18\x03 \x01(\bR\taggregate\"%\n" +
+	"\rShareResponse\x12\x14\n" +
+	"\x05share\x18\x01 \x01(\fR\x05share\"\x19\n" +
+	"\x17DealShamirSharesRequest\"\x1a\n" +
+	"\x18DealShamirSharesResponse\"A\n" +
+	"\x15PutShamirShareRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x05R\x04from\x12\x14\n" +
+	"\x05share\x18\x02 \x01(\fR\x05share\"4\n" +
+	"\x16PutShamirShareResponse\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x05R\breceived\"/\n" +
+	"\x0eEncryptRequest\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\"4\n" +
+	"\x12CiphertextResponse\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x01 \x01(\fR\n" +
+	"ciphertext\";\n" +
+	"\x19RegisterCiphertextRequest\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x01 \x01(\fR\n" +
+	"ciphertext\"4\n" +
+	"\x1aRegisterCiphertextResponse\x12\x16\n" +
+	"\x06digest\x18\x01 \x01(\fR\x06digest\"m\n" +
+	"\x15PartialDecryptRequest\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x01 \x01(\fR\n" +
+	"ciphertext\x12\x16\n" +
+	"\x06online\x18\x02 \x03(\x05R\x06online\x12\x1c\n" +
+	"\taggregate\x18\x03 \x01(\bR\taggregate\"\x0f\n" +
+	"\rStatusRequest\"\xae\x01\n" +
+	"\x0eStatusResponse\x12\x18\n" +
+	"\asession\x18\x01 \x01(\tR\asession\x12\x14\n" +
+	"\x05party\x18\x02 \x01(\x05R\x05party\x12\x14\n" +
+	"\x05phase\x18\x03 \x01(\tR\x05phase\x12 \n" +
+	"\vfingerprint\x18\x04 \x01(\tR\vfingerprint\x124\n" +
+	"\x16shamir_shares_received\x18\x05 \x01(\x05R\x14shamirSharesReceived2\xed\x04\n" +
+	"\x05Party\x128\n" +
+	"\x05Setup\x12\x16.mhe.node.SetupRequest\x1a\x17.mhe.node.SetupResponse\x12M\n" +
+	"\x11GenPublicKeyShare\x12\x1f.mhe.node.PublicKeyShareRequest\x1a\x17.mhe.node.ShareResponse\x12Y\n" +
+	"\x10DealShamirShares\x12!.mhe.node.DealShamirSharesRequest\x1a\".mhe.node.DealShamirSharesResponse\x12S\n" +
+	"\x0ePutShamirShare\x12\x1f.mhe.node.PutShamirShareRequest\x1a .mhe.node.PutShamirShareResponse\x12A\n" +
+	"\aEncrypt\x12\x18.mhe.node.EncryptRequest\x1a\x1c.mhe.node.CiphertextResponse\x12_\n" +
+	"\x12RegisterCiphertext\x12#.mhe.node.RegisterCiphertextRequest\x1a$.mhe.node.RegisterCiphertextResponse\x12J\n" +
+	"\x0ePartialDecrypt\x12\x1f.mhe.node.PartialDecryptRequest\x1a\x17.mhe.node.ShareResponse\x12;\n" +
+	"\x06Status\x12\x17.mhe.node.StatusRequest\x1a\x18.mhe.node.StatusResponseB6Z4github.com/tuneinsight/lattigo/v5/examples/NODE;mainb\x06proto3"
+
+var (
+	file_party_proto_rawDescOnce sync.Once
+	file_party_proto_rawDescData []byte
+)
+
+func file_party_proto_rawDescGZIP() []byte {
+	file_party_proto_rawDescOnce.Do(func() {
+		file_party_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_party_proto_rawDesc), len(file_party_proto_rawDesc)))
+	})
+	return file_party_proto_rawDescData
+}
+
+var file_party_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_party_proto_goTypes = []any{
+	(*SetupRequest)(nil),               // 0: mhe.node.SetupRequest
+	(*SetupResponse)(nil),              // 1: mhe.node.SetupResponse
+	(*PublicKeyShareRequest)(nil),      // 2: mhe.node.PublicKeyShareRequest
+	(*ShareResponse)(nil),              // 3: mhe.node.ShareResponse
+	(*DealShamirSharesRequest)(nil),    // 4: mhe.node.DealShamirSharesRequest
+	(*DealShamirSharesResponse)(nil),   // 5: mhe.node.DealShamirSharesResponse
+	(*PutShamirShareRequest)(nil),      // 6: mhe.node.PutShamirShareRequest
+	(*PutShamirShareResponse)(nil),     // 7: mhe.node.PutShamirShareResponse
+	(*EncryptRequest)(nil),             // 8: mhe.node.EncryptRequest
+	(*CiphertextResponse)(nil),         // 9: mhe.node.CiphertextResponse
+	(*RegisterCiphertextRequest)(nil),  // 10: mhe.node.RegisterCiphertextRequest
+	(*RegisterCiphertextResponse)(nil), // 11: mhe.node.RegisterCiphertextResponse
+	(*PartialDecryptRequest)(nil),      // 12: mhe.node.PartialDecryptRequest
+	(*StatusRequest)(nil),              // 13: mhe.node.StatusRequest
+	(*StatusResponse)(nil),             // 14: mhe.node.StatusResponse
+}
+var file_party_proto_depIdxs = []int32{
+	0,  // 0: mhe.node.Party.Setup:input_type -> mhe.node.SetupRequest
+	2,  // 1: mhe.node.Party.GenPublicKeyShare:input_type -> mhe.node.PublicKeyShareRequest
+	4,  // 2: mhe.node.Party.DealShamirShares:input_type -> mhe.node.DealShamirSharesRequest
+	6,  // 3: mhe.node.Party.PutShamirShare:input_type -> mhe.node.PutShamirShareRequest
+	8,  // 4: mhe.node.Party.Encrypt:input_type -> mhe.node.EncryptRequest
+	10, // 5: mhe.node.Party.RegisterCiphertext:input_type -> mhe.node.RegisterCiphertextRequest
+	12, // 6: mhe.node.Party.PartialDecrypt:input_type -> mhe.node.PartialDecryptRequest
+	13, // 7: mhe.node.Party.Status:input_type -> mhe.node.StatusRequest
+	1,  // 8: mhe.node.Party.Setup:output_type -> mhe.node.SetupResponse
+	3,  // 9: mhe.node.Party.GenPublicKeyShare:output_type -> mhe.node.ShareResponse
+	5,  // 10: mhe.node.Party.DealShamirShares:output_type -> mhe.node.DealShamirSharesResponse
+	7,  // 11: mhe.node.Party.PutShamirShare:output_type -> mhe.node.PutShamirShareResponse
+	9,  // 12: mhe.node.Party.Encrypt:output_type -> mhe.node.CiphertextResponse
+	11, // 13: mhe.node.Party.RegisterCiphertext:output_type -> mhe.node.RegisterCiphertextResponse
+	3,  // 14: mhe.node.Party.PartialDecrypt:output_type -> mhe.node.ShareResponse
+	14, // 15: mhe.node.Party.Status:output_type -> mhe.node.StatusResponse
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_party_proto_init() }
+func file_party_proto_init() {
+	if File_party_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_party_proto_rawDesc), len(file_party_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_party_proto_goTypes,
+		DependencyIndexes: file_party_proto_depIdxs,
+		MessageInfos:      file_party_proto_msgTypes,
+	}.Build()
+	File_party_proto = out.File
+	file_party_proto_goTypes = nil
+	file_party_proto_depIdxs = nil
+}
@@ -0,0 +1,417 @@
+// 参与方节点的gRPC接口：协调方通过它驱动长期运行的参与方完成CRS流程和门限流程。
+// 多项式、密钥和密文以Lattigo的二进制编码放在bytes字段中，接收方用examples.Decode*解码并检查。
+// 聚合树由online中的参与方按顺序构成(CRS流程中为全部参与方按编号)，与examples.AggregateTree相同，
+// 位置i的父节点为位置(i-1)/arity，协调方只向位置0的根节点请求聚合份额。
+// 除Setup和Status外，每个请求都必须在元数据session-token中带有Setup时协调方给出的会话令牌(十六进制)。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: party.proto
+
+package main
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Party_Setup_FullMethodName              = "/mhe.node.Party/Setup"
+	Party_GenPublicKeyShare_FullMethodName  = "/mhe.node.Party/GenPublicKeyShare"
+	Party_DealShamirShares_FullMethodName   = "/mhe.node.Party/DealShamirShares"
+	Party_PutShamirShare_FullMethodName     = "/mhe.node.Party/PutShamirShare"
+	Party_Encrypt_FullMethodName            = "/mhe.node.Party/Encrypt"
+	Party_RegisterCiphertext_FullMethodName = "/mhe.node.Party/RegisterCiphertext"
+	Party_PartialDecrypt_FullMethodName     = "/mhe.node.Party/PartialDecrypt"
+	Party_Status_FullMethodName             = "/mhe.node.Party/Status"
+)
+
+// PartyClient is the client API for Party service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PartyClient interface {
+	// Setup 开始一个会话：参与方生成私钥，门限流程中还生成Shamir多项式，并记下会话令牌；
+	// 任何人都可以开始新会话，但新会话的私钥与之前的密文无关
+	Setup(ctx context.Context, in *SetupRequest, opts ...grpc.CallOption) (*SetupResponse, error)
+	// GenPublicKeyShare 由CRS种子生成公钥份额，online非空时用在线参与方的加性份额作为私钥；
+	// aggregate为true时参与方还向聚合树中的子节点转发请求，返回自己与子树份额的聚合
+	GenPublicKeyShare(ctx context.Context, in *PublicKeyShareRequest, opts ...grpc.CallOption) (*ShareResponse, error)
+	// DealShamirShares 参与方经PutShamirShare把Shamir份额直接发给其他参与方，每个会话只发一次，协调方看不到份额
+	DealShamirShares(ctx context.Context, in *DealShamirSharesRequest, opts ...grpc.CallOption) (*DealShamirSharesResponse, error)
+	// PutShamirShare 参与方from把发给本参与方的Shamir份额交给它聚合，由参与方之间调用
+	PutShamirShare(ctx context.Context, in *PutShamirShareRequest, opts ...grpc.CallOption) (*PutShamirShareResponse, error)
+	// Encrypt 参与方在集体公钥下加密自己的输入
+	Encrypt(ctx context.Context, in *EncryptRequest, opts ...grpc.CallOption) (*CiphertextResponse, error)
+	// RegisterCiphertext 协调方登记本会话中待解密的计算结果，参与方只对登记过的密文作答
+	RegisterCiphertext(ctx context.Context, in *RegisterCiphertextRequest, opts ...grpc.CallOption) (*RegisterCiphertextResponse, error)
+	// PartialDecrypt 参与方对登记过的密文生成带噪声淹没的密钥切换份额(切换到零私钥)，每个密文只生成一次份额，
+	// 同一在线参与方的重试返回同一份额；
+	// online非空时用在线参与方的加性份额作为私钥；aggregate与GenPublicKeyShare相同
+	PartialDecrypt(ctx context.Context, in *PartialDecryptRequest, opts ...grpc.CallOption) (*ShareResponse, error)
+	// Status 参与方的会话状态
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+}
+
+type partyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPartyClient(cc grpc.ClientConnInterface) PartyClient {
+	return &partyClient{cc}
+}
+
+func (c *partyClient) Setup(ctx context.Context, in *SetupRequest, opts ...grpc.CallOption) (*SetupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetupResponse)
+	err := c.cc.Invoke(ctx, Party_Setup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) GenPublicKeyShare(ctx context.Context, in *PublicKeyShareRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareResponse)
+	err := c.cc.Invoke(ctx, Party_GenPublicKeyShare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) DealShamirShares(ctx context.Context, in *DealShamirSharesRequest, opts ...grpc.CallOption) (*DealShamirSharesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DealShamirSharesResponse)
+	err := c.cc.Invoke(ctx, Party_DealShamirShares_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) PutShamirShare(ctx context.Context, in *PutShamirShareRequest, opts ...grpc.CallOption) (*PutShamirShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutShamirShareResponse)
+	err := c.cc.Invoke(ctx, Party_PutShamirShare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) Encrypt(ctx context.Context, in *EncryptRequest, opts ...grpc.CallOption) (*CiphertextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CiphertextResponse)
+	err := c.cc.Invoke(ctx, Party_Encrypt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) RegisterCiphertext(ctx context.Context, in *RegisterCiphertextRequest, opts ...grpc.CallOption) (*RegisterCiphertextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterCiphertextResponse)
+	err := c.cc.Invoke(ctx, Party_RegisterCiphertext_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) PartialDecrypt(ctx context.Context, in *PartialDecryptRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareResponse)
+	err := c.cc.Invoke(ctx, Party_PartialDecrypt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *partyClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, Party_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PartyServer is the server API for Party service.
+// All implementations must embed UnimplementedPartyServer
+// for forward compatibility.
+type PartyServer interface {
+	// Setup 开始一个会话：参与方生成私钥，门限流程中还生成Shamir多项式，并记下会话令牌；
+	// 任何人都可以开始新会话，但新会话的私钥与之前的密文无关
+	Setup(context.Context, *SetupRequest) (*SetupResponse, error)
+	// GenPublicKeyShare 由CRS种子生成公钥份额，online非空时用在线参与方的加性份额作为私钥；
+	// aggregate为true时参与方还向聚合树中的子节点转发请求，返回自己与子树份额的聚合
+	GenPublicKeyShare(context.Context, *PublicKeyShareRequest) (*ShareResponse, error)
+	// DealShamirShares 参与方经PutShamirShare把Shamir份额直接发给其他参与方，每个会话只发一次，协调方看不到份额
+	DealShamirShares(context.Context, *DealShamirSharesRequest) (*DealShamirSharesResponse, error)
+	// PutShamirShare 参与方from把发给本参与方的Shamir份额交给它聚合，由参与方之间调用
+	PutShamirShare(context.Context, *PutShamirShareRequest) (*PutShamirShareResponse, error)
+	// Encrypt 参与方在集体公钥下加密自己的输入
+	Encrypt(context.Context, *EncryptRequest) (*CiphertextResponse, error)
+	// RegisterCiphertext 协调方登记本会话中待解密的计算结果，参与方只对登记过的密文作答
+	RegisterCiphertext(context.Context, *RegisterCiphertextRequest) (*RegisterCiphertextResponse, error)
+	// PartialDecrypt 参与方对登记过的密文生成带噪声淹没的密钥切换份额(切换到零私钥)，每个密文只生成一次份额，
+	// 同一在线参与方的重试返回同一份额；
+	// online非空时用在线参与方的加性份额作为私钥；aggregate与GenPublicKeyShare相同
+	PartialDecrypt(context.Context, *PartialDecryptRequest) (*ShareResponse, error)
+	// Status 参与方的会话状态
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	mustEmbedUnimplementedPartyServer()
+}
+
+// UnimplementedPartyServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPartyServer struct{}
+
+func (UnimplementedPartyServer) Setup(context.Context, *SetupRequest) (*SetupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Setup not implemented")
+}
+func (UnimplementedPartyServer) GenPublicKeyShare(context.Context, *PublicKeyShareRequest) (*ShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenPublicKeyShare not implemented")
+}
+func (UnimplementedPartyServer) DealShamirShares(context.Context, *DealShamirSharesRequest) (*DealShamirSharesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DealShamirShares not implemented")
+}
+func (UnimplementedPartyServer) PutShamirShare(context.Context, *PutShamirShareRequest) (*PutShamirShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutShamirShare not implemented")
+}
+func (UnimplementedPartyServer) Encrypt(context.Context, *EncryptRequest) (*CiphertextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Encrypt not implemented")
+}
+func (UnimplementedPartyServer) RegisterCiphertext(context.Context, *RegisterCiphertextRequest) (*RegisterCiphertextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterCiphertext not implemented")
+}
+func (UnimplementedPartyServer) PartialDecrypt(context.Context, *PartialDecryptRequest) (*ShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PartialDecrypt not implemented")
+}
+func (UnimplementedPartyServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedPartyServer) mustEmbedUnimplementedPartyServer() {}
+func (UnimplementedPartyServer) testEmbeddedByValue()               {}
+
+// UnsafePartyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PartyServer will
+// result in compilation errors.
+type UnsafePartyServer interface {
+	mustEmbedUnimplementedPartyServer()
+}
+
+func RegisterPartyServer(s grpc.ServiceRegistrar, srv PartyServer) {
+	// If the following call pancis, it indicates UnimplementedPartyServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Party_ServiceDesc, srv)
+}
+
+func _Party_Setup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).Setup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_Setup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).Setup(ctx, req.(*SetupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_GenPublicKeyShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicKeyShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).GenPublicKeyShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_GenPublicKeyShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).GenPublicKeyShare(ctx, req.(*PublicKeyShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_DealShamirShares_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DealShamirSharesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).DealShamirShares(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_DealShamirShares_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).DealShamirShares(ctx, req.(*DealShamirSharesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_PutShamirShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutShamirShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).PutShamirShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_PutShamirShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).PutShamirShare(ctx, req.(*PutShamirShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_Encrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).Encrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_Encrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).Encrypt(ctx, req.(*EncryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_RegisterCiphertext_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterCiphertextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).RegisterCiphertext(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_RegisterCiphertext_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).RegisterCiphertext(ctx, req.(*RegisterCiphertextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_PartialDecrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartialDecryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).PartialDecrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_PartialDecrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).PartialDecrypt(ctx, req.(*PartialDecryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Party_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PartyServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Party_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PartyServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Party_ServiceDesc is the grpc.ServiceDesc for Party service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Party_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mhe.node.Party",
+	HandlerType: (*PartyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Setup",
+			Handler:    _Party_Setup_Handler,
+		},
+		{
+			MethodName: "GenPublicKeyShare",
+			Handler:    _Party_GenPublicKeyShare_Handler,
+		},
+		{
+			MethodName: "DealShamirShares",
+			Handler:    _Party_DealShamirShares_Handler,
+		},
+		{
+			MethodName: "PutShamirShare",
+			Handler:    _Party_PutShamirShare_Handler,
+		},
+		{
+			MethodName: "Encrypt",
+			Handler:    _Party_Encrypt_Handler,
+		},
+		{
+			MethodName: "RegisterCiphertext",
+			Handler:    _Party_RegisterCiphertext_Handler,
+		},
+		{
+			MethodName: "PartialDecrypt",
+			Handler:    _Party_PartialDecrypt_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Party_Status_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "party.proto",
+}
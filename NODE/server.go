@@ -0,0 +1,592 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// smudging 部分解密的噪声淹没分布。没有它，对c1 = 1的构造密文的部分解密就是参与方的私钥，
+// 门限流程中t个这样的部分解密即可重构集体私钥
+var smudging = ring.DiscreteGaussian{Sigma: examples.MultipartySmudgingSigma, Bound: 6 * examples.MultipartySmudgingSigma}
+
+const (
+	tokenKey     = "session-token" // 携带会话令牌的元数据键
+	minTokenSize = 16              // 会话令牌的最少字节数
+)
+
+// 会话的阶段，由Status返回
+const (
+	phaseIdle    = "idle"
+	phaseSetup   = "setup"
+	phaseShamir  = "shamir"
+	phaseKeyGen  = "keygen"
+	phaseEncrypt = "encrypt"
+	phaseDecrypt = "decrypt"
+)
+
+// node 长期运行的参与方，每次Setup开始一个新会话。私钥和Shamir份额只保存在节点内，
+// 对外只发送协议消息；收到的消息都先用examples.Decode*解码并检查。
+// 门限流程中参与方把发给其他参与方的Shamir份额直接发给对方，每个会话一次，协调方看不到份额。
+// Setup之后的请求必须带有Setup时给出的会话令牌，只有协调方(和同一会话的参与方)能让节点登记和解密密文。
+// 部分解密只对协调方登记过的密文作答，每个密文只生成一次份额，且加入噪声淹没，不会直接泄露私钥或其份额；
+// 同一在线参与方的重试返回同一份额，某个参与方失败后协调方可以重新请求整轮解密。
+// 按聚合树聚合时，节点向子节点转发请求，把子树的份额与自己的份额聚合后返回给父节点。
+type node struct {
+	UnimplementedPartyServer
+
+	mu sync.Mutex
+
+	session     string
+	params      heint.Parameters
+	fingerprint string
+	phase       string
+	token       []byte // 会话令牌
+	i, N, t     int
+
+	sk    *rlwe.SecretKey
+	input []uint64
+
+	// 登记过的待解密密文，键为编码的SHA-256摘要，值为已经给出的份额，尚未作答时为nil
+	registered map[[sha256.Size]byte]*answer
+
+	// 门限流程(t<N)
+	thresholdizer mhe.Thresholdizer
+	shamirPoly    mhe.ShamirPolynomial
+	share         mhe.ShamirSecretShare // 收到的Shamir份额之和
+	received      map[int]bool
+	dealt         bool // 已经把Shamir份额发给其他参与方
+	combiner      mhe.Combiner
+
+	// 其他参与方节点，门限流程(t<N)中互发Shamir份额，聚合树(arity≥2)中转发请求
+	arity       int
+	peers       []PartyClient // 下标即参与方编号，自己为nil
+	conns       []*grpc.ClientConn
+	dialOptions []grpc.DialOption // 连接其他参与方节点的选项
+}
+
+func newNode() *node {
+	return &node{
+		phase:       phaseIdle,
+		dialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+	}
+}
+
+func (n *node) Setup(_ context.Context, in *SetupRequest) (*SetupResponse, error) {
+	var config examples.ParametersConfig
+	if err := json.Unmarshal(in.Params, &config); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "parameters: %v", err)
+	}
+	literal, err := config.HEIntLiteral()
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "parameters: %v", err)
+	}
+	params, err := heint.NewParametersFromLiteral(literal)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "parameters: %v", err)
+	}
+	N, i, t := int(in.Parties), int(in.Party), int(in.Threshold)
+	if N < 1 || i < 0 || i >= N {
+		return nil, status.Errorf(codes.InvalidArgument, "party %d of %d parties", i, N)
+	}
+	if t == 0 {
+		t = N
+	}
+	if t < 1 || t > N {
+		return nil, status.Errorf(codes.InvalidArgument, "threshold %d of %d parties", t, N)
+	}
+	if len(in.Token) < minTokenSize {
+		return nil, status.Errorf(codes.InvalidArgument, "session token of %d bytes, expected at least %d", len(in.Token), minTokenSize)
+	}
+	arity := int(in.Arity)
+	if (arity >= 2 || t < N) && len(in.Peers) != N {
+		return nil, status.Errorf(codes.InvalidArgument, "%d peer addresses for %d parties", len(in.Peers), N)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// 新会话丢弃上一个会话的全部状态
+	n.session, n.params, n.fingerprint, n.phase = in.Session, params, examples.HEIntFingerprint(params), phaseSetup
+	n.token = append([]byte{}, in.Token...)
+	n.i, n.N, n.t = i, N, t
+	if err := n.connect(arity, in.Peers); err != nil {
+		n.sk = nil
+		return nil, err
+	}
+	n.sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	n.input = demoInput(params, i)
+	n.registered = map[[sha256.Size]byte]*answer{}
+	n.received, n.dealt = nil, false
+	if t < N {
+		n.thresholdizer = mhe.NewThresholdizer(params)
+		if n.shamirPoly, err = n.thresholdizer.GenShamirPolynomial(t, n.sk); err != nil {
+			return nil, status.Errorf(codes.Internal, "shamir polynomial: %v", err)
+		}
+		n.share = n.thresholdizer.AllocateThresholdSecretShare()
+		n.received = map[int]bool{}
+		points := make([]mhe.ShamirPublicPoint, N)
+		for j := range points {
+			points[j] = shamirPublicPoint(j)
+		}
+		n.combiner = mhe.NewCombiner(*params.GetRLWEParameters(), shamirPublicPoint(i), points, t)
+	}
+	return &SetupResponse{Fingerprint: n.fingerprint}, nil
+}
+
+// connect 关闭上一个会话的连接，门限流程和聚合树中连接其他参与方节点，调用方持有锁。
+// grpc.NewClient不立即建立连接，不可达的参与方在发送请求时才报错
+func (n *node) connect(arity int, peers []string) error {
+	for _, conn := range n.conns {
+		conn.Close()
+	}
+	n.arity, n.peers, n.conns = arity, nil, nil
+	if arity < 2 && n.t == n.N {
+		return nil
+	}
+	n.peers = make([]PartyClient, len(peers))
+	for j, addr := range peers {
+		if j == n.i {
+			continue
+		}
+		conn, err := grpc.NewClient(addr, n.dialOptions...)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "party %d: %v", j, err)
+		}
+		n.conns = append(n.conns, conn)
+		n.peers[j] = NewPartyClient(conn)
+	}
+	return nil
+}
+
+// demoInput 参与方i的输入，每个槽位都是i，与其他示例相同
+func demoInput(params heint.Parameters, i int) []uint64 {
+	input := make([]uint64, params.N())
+	for j := range input {
+		input[j] = uint64(i)
+	}
+	return input
+}
+
+// shamirPublicPoint 参与方i的Shamir公开点，与TMHE相同为i+1
+func shamirPublicPoint(i int) mhe.ShamirPublicPoint {
+	return mhe.ShamirPublicPoint(i + 1)
+}
+
+// ready 检查会话已经开始且请求带有会话令牌，调用方持有锁
+func (n *node) ready(ctx context.Context) error {
+	if n.sk == nil {
+		return status.Error(codes.FailedPrecondition, "no session, call Setup first")
+	}
+	token, err := hex.DecodeString(incomingToken(ctx))
+	if err != nil || subtle.ConstantTimeCompare(token, n.token) != 1 {
+		return status.Error(codes.Unauthenticated, "missing or invalid session token")
+	}
+	return nil
+}
+
+// incomingToken 返回请求元数据中的会话令牌
+func incomingToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tokens := md.Get(tokenKey); len(tokens) == 1 {
+		return tokens[0]
+	}
+	return ""
+}
+
+// withToken 在发出的请求的元数据中附上会话令牌
+func withToken(ctx context.Context, token []byte) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, tokenKey, hex.EncodeToString(token))
+}
+
+// forward 把请求转发给子节点时附上请求带来的会话令牌，它已经由ready检查过
+func forward(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, tokenKey, incomingToken(ctx))
+}
+
+// key 返回参与方在本轮使用的私钥：没有秘密共享时是自己的私钥，否则是online中在线参与方的加性份额
+func (n *node) key(online []int32) (*rlwe.SecretKey, error) {
+	if n.t == n.N {
+		if len(online) != 0 {
+			return nil, status.Error(codes.InvalidArgument, "online parties given without a threshold")
+		}
+		return n.sk, nil
+	}
+	if len(n.received) != n.N {
+		return nil, status.Errorf(codes.FailedPrecondition, "received %d of %d Shamir shares", len(n.received), n.N)
+	}
+	if len(online) != n.t {
+		return nil, status.Errorf(codes.InvalidArgument, "%d online parties, expected the threshold %d", len(online), n.t)
+	}
+	points := make([]mhe.ShamirPublicPoint, len(online))
+	self, seen := false, map[int32]bool{}
+	for k, j := range online {
+		if j < 0 || int(j) >= n.N || seen[j] {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid online parties %v", online)
+		}
+		seen[j] = true
+		self = self || int(j) == n.i
+		points[k] = shamirPublicPoint(int(j))
+	}
+	if !self {
+		return nil, status.Errorf(codes.InvalidArgument, "party %d is not among the online parties %v", n.i, online)
+	}
+	sk := rlwe.NewSecretKey(n.params)
+	if err := n.combiner.GenAdditiveShare(points, shamirPublicPoint(n.i), n.share, sk); err != nil {
+		return nil, status.Errorf(codes.Internal, "additive share: %v", err)
+	}
+	return sk, nil
+}
+
+// child 聚合树中的子节点
+type child struct {
+	i      int
+	client PartyClient
+}
+
+// children 返回参与方在聚合树中的子节点，树由online中的参与方按顺序构成，CRS流程中为全部参与方。
+// online已经由key检查过，调用方持有锁
+func (n *node) children(online []int32) ([]child, error) {
+	if n.arity < 2 {
+		return nil, status.Error(codes.FailedPrecondition, "no aggregation tree in this session")
+	}
+	members := online
+	if len(members) == 0 {
+		members = make([]int32, n.N)
+		for j := range members {
+			members[j] = int32(j)
+		}
+	}
+	pos := 0
+	for k, j := range members {
+		if int(j) == n.i {
+			pos = k
+		}
+	}
+	var children []child
+	for _, c := range examples.TreeChildren(len(members), n.arity, pos) {
+		j := int(members[c])
+		children = append(children, child{i: j, client: n.peers[j]})
+	}
+	return children, nil
+}
+
+// childError 子节点的错误，保留其状态码
+func childError(i int, err error) error {
+	s := status.Convert(err)
+	return status.Errorf(s.Code(), "party %d: %s", i, s.Message())
+}
+
+func (n *node) DealShamirShares(ctx context.Context, _ *DealShamirSharesRequest) (*DealShamirSharesResponse, error) {
+	i, shares, peers, err := n.dealShamirShares(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 与GenPublicKeyShare相同，发送份额时不持有锁
+	for j, data := range shares {
+		if j == i {
+			continue
+		}
+		if _, err := peers[j].PutShamirShare(forward(ctx), &PutShamirShareRequest{From: int32(i), Share: data}); err != nil {
+			return nil, childError(j, err)
+		}
+	}
+	return &DealShamirSharesResponse{}, nil
+}
+
+// dealShamirShares 生成发给每个参与方的Shamir份额并记录已经发出，自己的份额直接聚合。
+// 返回的份额下标即参与方编号，自己的为nil
+func (n *node) dealShamirShares(ctx context.Context) (i int, shares [][]byte, peers []PartyClient, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err = n.ready(ctx); err != nil {
+		return
+	}
+	if n.t == n.N {
+		err = status.Error(codes.FailedPrecondition, "no threshold, the secret key is not shared")
+		return
+	}
+	if n.dealt {
+		err = status.Error(codes.AlreadyExists, "the Shamir shares were already dealt in this session")
+		return
+	}
+	shares = make([][]byte, n.N)
+	for j := range shares {
+		share := n.thresholdizer.AllocateThresholdSecretShare()
+		n.thresholdizer.GenShamirSecretShare(shamirPublicPoint(j), n.shamirPoly, &share)
+		if j == n.i {
+			if err = n.aggregateShamirShare(j, share); err != nil {
+				return
+			}
+			continue
+		}
+		if shares[j], err = share.MarshalBinary(); err != nil {
+			err = status.Errorf(codes.Internal, "shamir share: %v", err)
+			return
+		}
+	}
+	n.dealt = true
+	return n.i, shares, n.peers, nil
+}
+
+func (n *node) PutShamirShare(ctx context.Context, in *PutShamirShareRequest) (*PutShamirShareResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.ready(ctx); err != nil {
+		return nil, err
+	}
+	if n.t == n.N {
+		return nil, status.Error(codes.FailedPrecondition, "no threshold, the secret key is not shared")
+	}
+	from := int(in.From)
+	if from < 0 || from >= n.N || from == n.i {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid party %d", from)
+	}
+	share, err := examples.DecodeShamirShare(n.params, in.Share)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := n.aggregateShamirShare(from, share); err != nil {
+		return nil, err
+	}
+	return &PutShamirShareResponse{Received: int32(len(n.received))}, nil
+}
+
+// aggregateShamirShare 聚合参与方from的Shamir份额，每个参与方一次，调用方持有锁
+func (n *node) aggregateShamirShare(from int, share mhe.ShamirSecretShare) error {
+	if n.received[from] {
+		return status.Errorf(codes.AlreadyExists, "already received the Shamir share of party %d", from)
+	}
+	if err := n.thresholdizer.AggregateShares(n.share, share, &n.share); err != nil {
+		return status.Errorf(codes.Internal, "aggregating the Shamir share: %v", err)
+	}
+	n.received[from] = true
+	n.phase = phaseShamir
+	return nil
+}
+
+func (n *node) GenPublicKeyShare(ctx context.Context, in *PublicKeyShareRequest) (*ShareResponse, error) {
+	params, share, children, err := n.genPublicKeyShare(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	// 转发请求时不持有锁，慢的子节点不阻塞本节点的其他请求
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	for _, c := range children {
+		resp, err := c.client.GenPublicKeyShare(forward(ctx), in)
+		if err != nil {
+			return nil, childError(c.i, err)
+		}
+		childShare, err := examples.DecodePublicKeyGenShare(params, resp.Share)
+		if err != nil {
+			return nil, status.Errorf(codes.DataLoss, "party %d: %v", c.i, err)
+		}
+		ckg.AggregateShares(childShare, share, &share)
+	}
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "public-key share: %v", err)
+	}
+	return &ShareResponse{Share: data}, nil
+}
+
+// genPublicKeyShare 生成参与方自己的公钥份额，in.Aggregate时还返回聚合树中的子节点
+func (n *node) genPublicKeyShare(ctx context.Context, in *PublicKeyShareRequest) (params heint.Parameters, share mhe.PublicKeyGenShare, children []child, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err = n.ready(ctx); err != nil {
+		return
+	}
+	sk, err := n.key(in.Online)
+	if err != nil {
+		return
+	}
+	if in.Aggregate {
+		if children, err = n.children(in.Online); err != nil {
+			return
+		}
+	}
+	crs, err := sampling.NewKeyedPRNG(in.CrsSeed)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "CRS seed: %v", err)
+		return
+	}
+	ckg := mhe.NewPublicKeyGenProtocol(n.params)
+	crp := ckg.SampleCRP(crs)
+	share = ckg.AllocateShare()
+	ckg.GenShare(sk, crp, &share)
+	n.phase = phaseKeyGen
+	return n.params, share, children, nil
+}
+
+func (n *node) Encrypt(ctx context.Context, in *EncryptRequest) (*CiphertextResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.ready(ctx); err != nil {
+		return nil, err
+	}
+	pk, err := examples.DecodePublicKey(n.params, in.PublicKey)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	pt := heint.NewPlaintext(n.params, n.params.MaxLevel())
+	if err := heint.NewEncoder(n.params).Encode(n.input, pt); err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding: %v", err)
+	}
+	ct := heint.NewCiphertext(n.params, 1, n.params.MaxLevel())
+	if err := rlwe.NewEncryptor(n.params, pk).Encrypt(pt, ct); err != nil {
+		return nil, status.Errorf(codes.Internal, "encrypting: %v", err)
+	}
+	data, err := ct.MarshalBinary()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "ciphertext: %v", err)
+	}
+	n.phase = phaseEncrypt
+	return &CiphertextResponse{Ciphertext: data}, nil
+}
+
+func (n *node) RegisterCiphertext(ctx context.Context, in *RegisterCiphertextRequest) (*RegisterCiphertextResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.ready(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := examples.DecodeCiphertext(n.params, in.Ciphertext); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	digest := sha256.Sum256(in.Ciphertext)
+	// 重复登记不改变已经给出的份额
+	if _, ok := n.registered[digest]; !ok {
+		n.registered[digest] = nil
+	}
+	return &RegisterCiphertextResponse{Digest: digest[:]}, nil
+}
+
+func (n *node) PartialDecrypt(ctx context.Context, in *PartialDecryptRequest) (*ShareResponse, error) {
+	params, level, share, children, err := n.partialDecrypt(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	// 与GenPublicKeyShare相同，转发请求时不持有锁
+	ks, err := mhe.NewKeySwitchProtocol(params, smudging)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "key switch: %v", err)
+	}
+	for _, c := range children {
+		resp, err := c.client.PartialDecrypt(forward(ctx), in)
+		if err != nil {
+			return nil, childError(c.i, err)
+		}
+		childShare, err := examples.DecodeKeySwitchShare(params, level, resp.Share)
+		if err != nil {
+			return nil, status.Errorf(codes.DataLoss, "party %d: %v", c.i, err)
+		}
+		if err := ks.AggregateShares(childShare, share, &share); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "party %d: %v", c.i, err)
+		}
+	}
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "partial decryption: %v", err)
+	}
+	return &ShareResponse{Share: data}, nil
+}
+
+// answer 参与方对一个密文给出的部分解密
+type answer struct {
+	online []int32 // 作答时的在线参与方
+	share  []byte  // 参与方自己的密钥切换份额，不含子节点的份额
+}
+
+// partialDecrypt 生成参与方自己的密钥切换份额并记下它，已经作答时返回记下的份额，in.Aggregate时还返回聚合树中的子节点
+func (n *node) partialDecrypt(ctx context.Context, in *PartialDecryptRequest) (params heint.Parameters, level int, share mhe.KeySwitchShare, children []child, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err = n.ready(ctx); err != nil {
+		return
+	}
+	digest := sha256.Sum256(in.Ciphertext)
+	answered, ok := n.registered[digest]
+	if !ok {
+		err = status.Error(codes.FailedPrecondition, "the ciphertext is not registered in this session")
+		return
+	}
+	ct, err := examples.DecodeCiphertext(n.params, in.Ciphertext)
+	if err != nil {
+		err = status.Error(codes.InvalidArgument, err.Error())
+		return
+	}
+	// 对同一密文生成新的份额会让噪声淹没被平均掉，换了在线参与方的份额还会泄露另一个私钥份额；
+	// 同一轮的重试返回同一份额，不泄露更多信息
+	if answered == nil {
+		if share, err = n.genKeySwitchShare(ct, in.Online); err != nil {
+			return
+		}
+		var data []byte
+		if data, err = share.MarshalBinary(); err != nil {
+			err = status.Errorf(codes.Internal, "partial decryption: %v", err)
+			return
+		}
+		n.registered[digest] = &answer{online: append([]int32{}, in.Online...), share: data}
+	} else {
+		if !slices.Equal(answered.online, in.Online) {
+			err = status.Errorf(codes.AlreadyExists, "the ciphertext was already partially decrypted with the online parties %v", answered.online)
+			return
+		}
+		if share, err = examples.DecodeKeySwitchShare(n.params, ct.Level(), answered.share); err != nil {
+			err = status.Errorf(codes.Internal, "partial decryption: %v", err)
+			return
+		}
+	}
+	if in.Aggregate {
+		if children, err = n.children(in.Online); err != nil {
+			return
+		}
+	}
+	n.phase = phaseDecrypt
+	return n.params, ct.Level(), share, children, nil
+}
+
+// genKeySwitchShare 生成参与方对ct的密钥切换份额，切换到零私钥，即c1·sk加上噪声淹没，调用方持有锁
+func (n *node) genKeySwitchShare(ct *rlwe.Ciphertext, online []int32) (share mhe.KeySwitchShare, err error) {
+	sk, err := n.key(online)
+	if err != nil {
+		return
+	}
+	ks, err := mhe.NewKeySwitchProtocol(n.params, smudging)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "key switch: %v", err)
+		return
+	}
+	share = ks.AllocateShare(ct.Level())
+	ks.GenShare(sk, rlwe.NewSecretKey(n.params), ct, &share)
+	return share, nil
+}
+
+func (n *node) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return &StatusResponse{
+		Session:              n.session,
+		Party:                int32(n.i),
+		Phase:                n.phase,
+		Fingerprint:          n.fingerprint,
+		ShamirSharesReceived: int32(len(n.received)),
+	}, nil
+}
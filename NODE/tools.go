@@ -0,0 +1,23 @@
+//go:build tools
+
+package main
+
+// 生成代码所用的protoc插件，与运行时依赖一起由lattigo模块根目录的go.mod固定版本：
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go google.golang.org/grpc/cmd/protoc-gen-go-grpc
+//
+// examples目录没有自己的go.mod，NODE和-params的YAML配置需要在模块根目录的go.mod中加入下列依赖
+// （grpc v1.82要求go 1.25），再运行go mod tidy更新go.sum：
+//
+//	require (
+//		google.golang.org/grpc v1.82.1
+//		google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.5.1
+//		google.golang.org/protobuf v1.36.11
+//		gopkg.in/yaml.v3 v3.0.1
+//	)
+//
+// 生成的party.pb.go和party_grpc.pb.go用上述版本生成并通过go vet检查。
+import (
+	_ "google.golang.org/grpc/cmd/protoc-gen-go-grpc"
+	_ "google.golang.org/protobuf/cmd/protoc-gen-go"
+)
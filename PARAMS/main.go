@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+// 参数配置工具：导出params.go中的参数集，或读取配置文件并打印参数指纹，
+// 各参与方据此在带外约定参数
+
+var flagList = flag.Bool("list", false, "list the named parameter sets of params.go")
+var flagExport = flag.String("export", "", "the name of the parameter set of params.go to export")
+var flagOut = flag.String("out", "", "the JSON or YAML file the exported parameter set is written to")
+var flagLoad = flag.String("load", "", "the JSON or YAML configuration file to print the fingerprint of")
+
+func main() {
+	flag.Parse()
+
+	switch {
+	case *flagList:
+		for _, name := range examples.ParametersNames() {
+			fmt.Println(name)
+		}
+	case *flagExport != "":
+		config, ok := examples.NamedParametersConfig(*flagExport)
+		if !ok {
+			fmt.Println("Error: unknown parameter set", *flagExport)
+			os.Exit(1)
+		}
+		if *flagOut == "" {
+			fmt.Println("Error: -out is required with -export")
+			os.Exit(1)
+		}
+		if err := config.Save(*flagOut); err != nil {
+			fmt.Println("Error saving parameters:", err)
+			os.Exit(1)
+		}
+		printFingerprint(config)
+	case *flagLoad != "":
+		config, err := examples.LoadParametersConfig(*flagLoad)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			os.Exit(1)
+		}
+		printFingerprint(config)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// printFingerprint 打印参数集的名称、方案和指纹
+func printFingerprint(config examples.ParametersConfig) {
+	fingerprint, err := config.Fingerprint()
+	if err != nil {
+		fmt.Println("Error creating parameters:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s (%s): %s\n", config.Name, config.Scheme, fingerprint)
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// 参与方
+type party struct {
+	i   int //表示第i个参与方
+	sk  *rlwe.SecretKey
+	set []uint64         // 参与方的集合，元素是槽位编号
+	ct  *rlwe.Ciphertext // 加密的集合指示向量
+
+	Thresholdizer     mhe.Thresholdizer
+	share             mhe.ShamirSecretShare
+	ShamirPoly        mhe.ShamirPolynomial
+	ShamirPublicPoint mhe.ShamirPublicPoint
+	mhe.Combiner
+}
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
+}
+
+var flagSets = flag.String("sets", "", "comma-separated files, one per party, each holding one element in [0, slots) per line; random sets if empty")
+var flagN = flag.Int("n", 5, "the number of parties with random sets, when -sets is empty")
+var flagT = flag.Int("t", 0, "the threshold of the decryption, 0 for all the parties")
+var flagMode = flag.String("mode", examples.PSISum, "how to combine the sets: \"sum\" (masked sum-equals-N test) or \"product\" (needs ceil(log2 N) levels)")
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
+func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEIntLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	// -sets时从文件读取各参与方的集合，否则随机生成
+	cfg := config{N: *flagN, t: *flagT, mode: *flagMode, literal: literal}
+	if *flagSets != "" {
+		for _, path := range strings.Split(*flagSets, ",") {
+			set, err := readSet(path)
+			if err != nil {
+				fmt.Println("Error reading set:", err)
+				return
+			}
+			cfg.sets = append(cfg.sets, set)
+		}
+		cfg.N = len(cfg.sets)
+	}
+
+	if _, err := run(cfg); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N       int                     // 参与方数量
+	t       int                     // 门限，0表示全部参与方
+	mode    string                  // 集合的组合方式，examples.PSISum或examples.PSIProduct
+	sets    [][]uint64              // 各参与方的集合，为nil时随机生成
+	literal heint.ParametersLiteral // 参数字面量
+}
+
+// result 一次运行的输入与解密出的交集
+type result struct {
+	params       heint.Parameters
+	sets         [][]uint64 // 各参与方的集合
+	intersection []uint64   // 解密出的交集，按元素升序
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、秘密共享、(乘积方式下)重线性化密钥生成、集合指示向量的加密、
+// 同态组合和门限解密。只有组合的结果被解密，求和方式下由每个参与方乘以自己的随机掩码，交集之外的槽位解密为均匀随机值，
+// 不泄露持有该元素的参与方数量。
+func run(cfg config) (*result, error) {
+	N, t := cfg.N, cfg.t
+	if cfg.sets != nil {
+		N = len(cfg.sets)
+	}
+	if N < 2 {
+		return nil, fmt.Errorf("%d parties, expected at least 2", N)
+	}
+	if t <= 0 {
+		t = N
+	}
+	if t > N {
+		return nil, fmt.Errorf("threshold %d is larger than the %d parties", t, N)
+	}
+	if cfg.mode != examples.PSISum && cfg.mode != examples.PSIProduct {
+		return nil, fmt.Errorf("invalid mode %q, expected %q or %q", cfg.mode, examples.PSISum, examples.PSIProduct)
+	}
+
+	var start, end time.Time
+	var duration time.Duration
+	var durationall time.Duration
+
+	//*****初始化参数*****
+	fmt.Println("> Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
+	start = time.Now()
+	params, err := heint.NewParametersFromLiteral(cfg.literal)
+	if err != nil {
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEIntFingerprint(params))
+	// 乘积方式每层乘法消耗一个模数层级
+	if cfg.mode == examples.PSIProduct {
+		if depth := examples.PSIProductDepth(N); depth > params.MaxLevel() {
+			return nil, fmt.Errorf("the product of %d sets needs %d levels, the parameters have %d", N, depth, params.MaxLevel())
+		}
+	}
+	fmt.Printf("elements in [0, %d)\n", params.MaxSlots())
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Parameter initialization time: %s\n", duration)
+
+	//*****私钥生成*****
+	fmt.Println("> Private key generation Phase")
+	audit.Phase("Private key generation Phase")
+	start = time.Now()
+	parties := make([]*party, N)
+	for i := 0; i < N; i++ {
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
+		parties[i] = &party{i: i, sk: sk}
+		audit.PartyJoined(i)
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Private key generation time: %s\n", duration)
+
+	//*****秘密共享*****
+	fmt.Println("> Shamir Secret Share Phase")
+	audit.Phase("Shamir Secret Share Phase")
+	start = time.Now()
+	if t != N {
+		shamirPublicPoints := make([]mhe.ShamirPublicPoint, N)
+		for i, p := range parties {
+			seeded.With(i, "thresholdizer", func() { p.Thresholdizer = mhe.NewThresholdizer(params) })
+			p.share = p.Thresholdizer.AllocateThresholdSecretShare()
+			if p.ShamirPoly, err = p.Thresholdizer.GenShamirPolynomial(t, p.sk); err != nil {
+				return nil, err
+			}
+			p.ShamirPublicPoint = mhe.ShamirPublicPoint(i + 1)
+			shamirPublicPoints[i] = p.ShamirPublicPoint
+		}
+		for _, p := range parties {
+			p.Combiner = mhe.NewCombiner(*params.GetRLWEParameters(), p.ShamirPublicPoint, shamirPublicPoints, t)
+		}
+		// pi发给pj的份额写入同一个缓冲区后立即被pj聚合
+		share := parties[0].Thresholdizer.AllocateThresholdSecretShare()
+		for _, pi := range parties {
+			for _, pj := range parties {
+				pi.Thresholdizer.GenShamirSecretShare(pj.ShamirPublicPoint, pi.ShamirPoly, &share)
+				audit.ShareSent("shamir", examples.AuditShamirShare, pi.i, pj.i)
+				audit.ShareReceived("shamir", examples.AuditShamirShare, pj.i, pi.i)
+				if err := pj.Thresholdizer.AggregateShares(pj.share, share, &pj.share); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("share time: %s\n", duration)
+
+	//*****公钥生成*****
+	// 前t个参与方在线，用Shamir份额重构加性份额，其和为全部参与方私钥之和
+	fmt.Println("> Public key generation Phase")
+	audit.Phase("Public key generation Phase")
+	start = time.Now()
+	online := parties[:t]
+	if t != N {
+		points := make([]mhe.ShamirPublicPoint, t)
+		for i, p := range online {
+			points[i] = p.ShamirPublicPoint
+		}
+		for _, p := range online {
+			sk := rlwe.NewSecretKey(params)
+			if err := p.Combiner.GenAdditiveShare(points, p.ShamirPublicPoint, p.share, sk); err != nil {
+				return nil, err
+			}
+			p.sk = sk
+		}
+	}
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
+	crsSeed := seeded.Seed(-1, "crs")
+	audit.CRPDerived("pk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("creating CRS: %w", err)
+	}
+	crp := ckg.SampleCRP(crs)
+	pkShare := ckg.AllocateShare()
+	roundShare := ckg.AllocateShare()
+	for _, p := range online {
+		ckg.GenShare(p.sk, crp, &pkShare)
+		ckg.AggregateShares(pkShare, roundShare, &roundShare)
+		audit.ShareSent("pk", examples.TranscriptPublicKeyShare, p.i, -1)
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(roundShare, crp, pk)
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Public key generation time: %s\n", duration)
+
+	//*****重线性化密钥生成*****
+	// 只有乘积方式需要密文乘法
+	var rlk *rlwe.RelinearizationKey
+	if cfg.mode == examples.PSIProduct {
+		fmt.Println("> Relinearization key generation Phase")
+		audit.Phase("Relinearization key generation Phase")
+		start = time.Now()
+		if rlk, err = examples.GenRelinearizationKey(params, secretKeys(online), indices(online), seeded, audit); err != nil {
+			return nil, err
+		}
+		end = time.Now()
+		duration = end.Sub(start)
+		durationall += duration
+		fmt.Printf("Relinearization key generation time: %s\n", duration)
+	}
+
+	//*****加密*****
+	fmt.Println("> Encrypt Phase")
+	audit.Phase("Encrypt Phase")
+	start = time.Now()
+	out := &result{params: params, sets: make([][]uint64, N)}
+	for _, p := range parties {
+		if cfg.sets != nil {
+			p.set = cfg.sets[p.i]
+		} else {
+			prng, err := seeded.PRNG(p.i, "input")
+			if err != nil {
+				return nil, err
+			}
+			p.set = randomSet(prng, params.MaxSlots())
+		}
+		out.sets[p.i] = p.set
+
+		// 集合编码为指示向量：元素所在的槽位为1，其余为0
+		indicator, err := examples.EncodeSet(params.MaxSlots(), p.set)
+		if err != nil {
+			return nil, fmt.Errorf("party %d: %w", p.i, err)
+		}
+		pt := heint.NewPlaintext(params, params.MaxLevel())
+		if err := heint.NewEncoder(params).Encode(indicator, pt); err != nil {
+			return nil, err
+		}
+		var encryptor *rlwe.Encryptor
+		seeded.With(p.i, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, pk) })
+		if p.ct, err = encryptor.EncryptNew(pt); err != nil {
+			return nil, err
+		}
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Encrypt time: %s\n", duration)
+
+	//*****同态组合*****
+	fmt.Println("> Computation Phase")
+	audit.Phase("Computation Phase")
+	start = time.Now()
+	evaluator := examples.NewPSIEvaluator(params, rlk)
+	cts := make([]*rlwe.Ciphertext, N)
+	for i, p := range parties {
+		cts[i] = p.ct
+	}
+	var combined *rlwe.Ciphertext
+	switch cfg.mode {
+	case examples.PSISum:
+		if combined, err = evaluator.SumEqualsN(cts); err != nil {
+			return nil, err
+		}
+		// 每个参与方依次乘以自己的随机掩码，掩码之积只有全部参与方合谋才能得知
+		for _, p := range parties {
+			prng, err := seeded.PRNG(p.i, "mask")
+			if err != nil {
+				return nil, err
+			}
+			if err := evaluator.Mask(combined, prng); err != nil {
+				return nil, fmt.Errorf("party %d: %w", p.i, err)
+			}
+		}
+	case examples.PSIProduct:
+		if combined, err = evaluator.Product(cts); err != nil {
+			return nil, err
+		}
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("计算time: %s\n", duration)
+
+	//*****解密*****
+	// 只有组合结果经过在线参与方的门限解密
+	fmt.Println("> Decrypt Phase")
+	audit.Phase("Decrypt Phase")
+	start = time.Now()
+	values, err := decrypt(params, online, combined, "decrypt/intersection")
+	if err != nil {
+		return nil, err
+	}
+	audit.DecryptionReleased("decrypt/intersection", -1, indices(online))
+	if out.intersection, err = examples.Intersection(cfg.mode, values); err != nil {
+		return nil, err
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("解密time: %s\n", duration)
+
+	for _, p := range parties {
+		fmt.Printf("party %d: %d elements\n", p.i, len(p.set))
+	}
+	fmt.Printf("intersection: %d elements", len(out.intersection))
+	if len(out.intersection) <= 16 {
+		fmt.Printf(" %v", out.intersection)
+	}
+	fmt.Println()
+	fmt.Printf("All time: %s\n", durationall)
+	return out, nil
+}
+
+// decrypt 每个在线参与方带淹没噪声地部分解密ct，聚合后完成解密并解码
+func decrypt(params heint.Parameters, parties []*party, ct *rlwe.Ciphertext, round string) ([]uint64, error) {
+	pt, err := examples.SmudgedDecrypt(params, ct, secretKeys(parties), indices(parties), seeded, audit, round)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]uint64, params.MaxSlots())
+	if err := heint.NewEncoder(params).Decode(pt, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// readSet 读取集合文件：每行一个十进制元素，忽略空行和以#开头的注释行
+func readSet(path string) ([]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var set []uint64
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid element %q", path, line, text)
+		}
+		set = append(set, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// randomSet 参与方的随机集合，每个槽位以7/8的概率属于集合
+func randomSet(prng sampling.PRNG, slots int) []uint64 {
+	buf := make([]byte, slots)
+	if _, err := prng.Read(buf); err != nil {
+		panic(err)
+	}
+	var set []uint64
+	for k, b := range buf {
+		if b%8 != 0 {
+			set = append(set, uint64(k))
+		}
+	}
+	return set
+}
+
+// indices 参与方的编号
+func indices(parties []*party) []int {
+	idx := make([]int, len(parties))
+	for k, p := range parties {
+		idx[k] = p.i
+	}
+	return idx
+}
+
+// secretKeys 参与方的私钥
+func secretKeys(parties []*party) []*rlwe.SecretKey {
+	sks := make([]*rlwe.SecretKey, len(parties))
+	for k, p := range parties {
+		sks[k] = p.sk
+	}
+	return sks
+}
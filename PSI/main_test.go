@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+func TestRun(t *testing.T) {
+	type runCase struct {
+		N, t int
+		mode string
+	}
+	cases := []runCase{
+		{N: 2, mode: examples.PSISum},
+		{N: 5, t: 3, mode: examples.PSISum},
+		{N: 3, mode: examples.PSIProduct},
+		{N: 5, t: 3, mode: examples.PSIProduct},
+	}
+	examples.RunTable(t, cases, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, t: tc.t, mode: tc.mode, literal: examples.HEIntParamsN13QP218})
+	}, func(t *testing.T, _ runCase, out *result) { checkResult(t, out) })
+}
+
+func TestRunSets(t *testing.T) {
+	sets := [][]uint64{{1, 3, 5, 7, 9}, {3, 4, 5, 9}, {9, 5, 3, 2}}
+	out, err := run(config{sets: sets, mode: examples.PSISum, literal: examples.HEIntParamsN12QP109})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{3, 5, 9}; !reflect.DeepEqual(out.intersection, want) {
+		t.Errorf("intersection %v, want %v", out.intersection, want)
+	}
+
+	// 乘积方式的层级不足，元素超出槽位范围
+	if _, err := run(config{N: 8, mode: examples.PSIProduct, literal: examples.HEIntParamsN12QP109}); err == nil {
+		t.Error("product of 8 sets with a single level: no error")
+	}
+	if _, err := run(config{sets: [][]uint64{{1}, {1 << 20}}, mode: examples.PSISum, literal: examples.HEIntParamsN12QP109}); err == nil {
+		t.Error("element outside the slots: no error")
+	}
+}
+
+func TestReadSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "set.txt")
+	if err := os.WriteFile(path, []byte("# party 0\n3\n\n 17 \n42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	set, err := readSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{3, 17, 42}; !reflect.DeepEqual(set, want) {
+		t.Errorf("read %v, want %v", set, want)
+	}
+
+	if err := os.WriteFile(path, []byte("3\nfoo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSet(path); err == nil {
+		t.Error("invalid element: no error")
+	}
+}
+
+// checkResult 用明文重新计算各参与方集合的交集，与解密结果比较
+func checkResult(t *testing.T, out *result) {
+	t.Helper()
+	count := make([]int, out.params.MaxSlots())
+	for _, set := range out.sets {
+		for _, e := range set {
+			count[e]++
+		}
+	}
+	var want []uint64
+	for k, c := range count {
+		if c == len(out.sets) {
+			want = append(want, uint64(k))
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("the random sets have an empty intersection")
+	}
+	if !reflect.DeepEqual(out.intersection, want) {
+		t.Errorf("decrypted intersection of %d elements, want %d", len(out.intersection), len(want))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+// 回放工具：读取演示程序用-transcript记录的协议消息，重新执行聚合与解密，
+// 找出第一个与预期不符的份额
+
+var flagTranscript = flag.String("transcript", "", "the transcript file recorded by a demo with -transcript")
+var flagVerbose = flag.Bool("v", false, "print every entry of the transcript")
+
+func main() {
+	flag.Parse()
+
+	if *flagTranscript == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	entries, err := examples.ReadTranscript(*flagTranscript)
+	if err != nil {
+		fmt.Println("Error reading transcript:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("transcript: %d entries\n", len(entries))
+	if *flagVerbose {
+		for _, e := range entries {
+			fmt.Println(e)
+		}
+	}
+
+	report, err := examples.ReplayTranscript(entries)
+	if err != nil {
+		fmt.Println("Error replaying transcript:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("replayed %d rounds, %d checks, %d deviations\n", report.Rounds, report.Checks, len(report.Deviations))
+
+	first, ok := report.First()
+	if !ok {
+		fmt.Println("no deviation")
+		return
+	}
+	fmt.Println("first deviation:", first)
+	for _, d := range report.Deviations {
+		if d.Entry.Seq != first.Entry.Seq {
+			fmt.Println("deviation:", d)
+		}
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// 参与方
+type party struct {
+	i       int //表示第i个参与方
+	sk      *rlwe.SecretKey
+	values  []uint64 // 每个槽位的取值
+	present []bool   // 参与方是否为该槽位提供取值
+
+	input   examples.StatisticsInput // 加密的取值和计数
+	buckets []*rlwe.Ciphertext       // 加密的各桶指示向量
+}
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
+}
+
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagBuckets = flag.Int("buckets", 8, "the number of buckets of the histogram")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
+func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEIntLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	//假设有N个参与方
+	if _, err := run(config{N: 40, literal: literal, buckets: *flagBuckets}); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N       int                     // 参与方数量
+	literal heint.ParametersLiteral // 参数字面量
+	buckets int                     // 直方图的桶数
+}
+
+// result 一次运行的输入与解密出的统计量
+type result struct {
+	params    heint.Parameters
+	values    [][]uint64 // 各参与方的取值
+	present   [][]bool   // 各参与方提供取值的槽位
+	edges     []uint64   // 直方图的桶边界
+	stats     examples.Statistics
+	histogram [][]uint64 // 每个桶在各槽位的计数
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、重线性化密钥生成、加密、统计量的同态计算和分布式解密。
+// 只有最终的聚合结果(和、计数、平方和、直方图)被解密，单个参与方的密文从不解密。
+func run(cfg config) (*result, error) {
+	N := cfg.N
+
+	var start, end time.Time
+	var duration time.Duration
+	var durationall time.Duration
+
+	//*****初始化参数*****
+	fmt.Println("> Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
+	start = time.Now()
+	params, err := heint.NewParametersFromLiteral(cfg.literal)
+	if err != nil {
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEIntFingerprint(params))
+	// 平方和不能超过明文模数，参与方的取值上限由参与方数量决定
+	maxValue := examples.StatisticsMaxValue(params, N)
+	if maxValue == 0 {
+		return nil, fmt.Errorf("the plaintext modulus %d is too small for the sum of squares of %d parties", params.PlaintextModulus(), N)
+	}
+	fmt.Printf("values in [0, %d]\n", maxValue)
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Parameter initialization time: %s\n", duration)
+
+	//*****私钥生成*****
+	fmt.Println("> Private key generation Phase")
+	audit.Phase("Private key generation Phase")
+	start = time.Now()
+	parties := make([]*party, N)
+	for i := 0; i < N; i++ {
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
+		parties[i] = &party{i: i, sk: sk}
+		audit.PartyJoined(i)
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Private key generation time: %s\n", duration)
+
+	//*****公钥生成*****
+	fmt.Println("> Public key generation Phase")
+	audit.Phase("Public key generation Phase")
+	start = time.Now()
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
+	crsSeed := seeded.Seed(-1, "crs")
+	audit.CRPDerived("pk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("creating CRS: %w", err)
+	}
+	crp := ckg.SampleCRP(crs)
+	pkShare := ckg.AllocateShare()
+	roundShare := ckg.AllocateShare()
+	for _, p := range parties {
+		ckg.GenShare(p.sk, crp, &pkShare)
+		ckg.AggregateShares(pkShare, roundShare, &roundShare)
+		audit.ShareSent("pk", examples.TranscriptPublicKeyShare, p.i, -1)
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(roundShare, crp, pk)
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Public key generation time: %s\n", duration)
+
+	//*****重线性化密钥生成*****
+	// 平方和需要密文乘法，乘法后用集体重线性化密钥把密文降回一次
+	fmt.Println("> Relinearization key generation Phase")
+	audit.Phase("Relinearization key generation Phase")
+	start = time.Now()
+	rlk, err := examples.GenRelinearizationKey(params, secretKeys(parties), indices(parties), seeded, audit)
+	if err != nil {
+		return nil, err
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Relinearization key generation time: %s\n", duration)
+
+	//*****加密*****
+	fmt.Println("> Encrypt Phase")
+	audit.Phase("Encrypt Phase")
+	start = time.Now()
+	out := &result{params: params, values: make([][]uint64, N), present: make([][]bool, N), edges: bucketEdges(maxValue, cfg.buckets)}
+	for _, p := range parties {
+		prng, err := seeded.PRNG(p.i, "input")
+		if err != nil {
+			return nil, err
+		}
+		p.values, p.present = randomInput(prng, params.MaxSlots(), maxValue)
+		out.values[p.i], out.present[p.i] = p.values, p.present
+
+		var encryptor *rlwe.Encryptor
+		seeded.With(p.i, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, pk) })
+		if p.input, err = examples.EncryptStatisticsInput(params, encryptor, p.values, p.present); err != nil {
+			return nil, err
+		}
+		// 每个桶一个指示向量，取值落在该桶的槽位为1，加密后只参与求和
+		if p.buckets, err = examples.EncryptHistogramInput(params, encryptor, p.values, p.present, out.edges); err != nil {
+			return nil, err
+		}
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("Encrypt time: %s\n", duration)
+
+	//*****统计量计算*****
+	fmt.Println("> Computation Phase")
+	audit.Phase("Computation Phase")
+	start = time.Now()
+	evaluator := examples.NewStatisticsEvaluator(params, rlk)
+	inputs := make([]examples.StatisticsInput, N)
+	buckets := make([][]*rlwe.Ciphertext, N)
+	for i, p := range parties {
+		inputs[i], buckets[i] = p.input, p.buckets
+	}
+	agg, err := evaluator.Aggregate(inputs)
+	if err != nil {
+		return nil, err
+	}
+	hist, err := evaluator.Histogram(buckets)
+	if err != nil {
+		return nil, err
+	}
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("计算time: %s\n", duration)
+
+	//*****解密*****
+	// 只有聚合结果经过分布式解密
+	fmt.Println("> Decrypt Phase")
+	audit.Phase("Decrypt Phase")
+	start = time.Now()
+	type round struct {
+		name string
+		ct   *rlwe.Ciphertext
+	}
+	rounds := []round{{"sum", agg.Sum}, {"count", agg.Count}, {"sum-of-squares", agg.SumOfSquares}}
+	for k, ct := range hist {
+		rounds = append(rounds, round{fmt.Sprintf("histogram/%d", k), ct})
+	}
+	decoded := make([][]uint64, len(rounds))
+	for r, round := range rounds {
+		if decoded[r], err = decrypt(params, parties, round.ct, "decrypt/"+round.name); err != nil {
+			return nil, err
+		}
+		audit.DecryptionReleased("decrypt/"+round.name, -1, indices(parties))
+	}
+	out.stats = examples.NewStatistics(decoded[0], decoded[1], decoded[2])
+	out.histogram = decoded[3:]
+	end = time.Now()
+	duration = end.Sub(start)
+	durationall += duration
+	fmt.Printf("解密time: %s\n", duration)
+
+	for k := 0; k < 4; k++ {
+		fmt.Printf("slot %d: count %d, sum %d, mean %.3f, variance %.3f\n", k, out.stats.Count[k], out.stats.Sum[k], out.stats.Mean[k], out.stats.Variance[k])
+	}
+	fmt.Println("slot 0 histogram:")
+	for k, counts := range out.histogram {
+		fmt.Printf("\t[%d, %d)\t%d\n", out.edges[k], out.edges[k+1], counts[0])
+	}
+	fmt.Printf("All time: %s\n", durationall)
+	return out, nil
+}
+
+// decrypt 各参与方带淹没噪声地部分解密ct，聚合后完成解密并解码
+func decrypt(params heint.Parameters, parties []*party, ct *rlwe.Ciphertext, round string) ([]uint64, error) {
+	pt, err := examples.SmudgedDecrypt(params, ct, secretKeys(parties), indices(parties), seeded, audit, round)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]uint64, params.MaxSlots())
+	if err := heint.NewEncoder(params).Decode(pt, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// randomInput 参与方的随机取值，每个槽位以3/4的概率提供[0, maxValue]中的取值
+func randomInput(prng sampling.PRNG, slots int, maxValue uint64) ([]uint64, []bool) {
+	values := make([]uint64, slots)
+	present := make([]bool, slots)
+	buf := make([]byte, 3*slots)
+	if _, err := prng.Read(buf); err != nil {
+		panic(err)
+	}
+	for k := range values {
+		present[k] = buf[3*k]%4 != 0
+		if present[k] {
+			values[k] = (uint64(buf[3*k+1])<<8 | uint64(buf[3*k+2])) % (maxValue + 1)
+		}
+	}
+	return values, present
+}
+
+// bucketEdges 把[0, maxValue]等分为n个桶的边界
+func bucketEdges(maxValue uint64, n int) []uint64 {
+	if n < 1 {
+		n = 1
+	}
+	edges := make([]uint64, n+1)
+	for k := range edges {
+		edges[k] = uint64(k) * (maxValue + 1) / uint64(n)
+	}
+	edges[n] = maxValue + 1
+	return edges
+}
+
+// indices 参与方的编号
+func indices(parties []*party) []int {
+	idx := make([]int, len(parties))
+	for k, p := range parties {
+		idx[k] = p.i
+	}
+	return idx
+}
+
+// secretKeys 参与方的私钥
+func secretKeys(parties []*party) []*rlwe.SecretKey {
+	sks := make([]*rlwe.SecretKey, len(parties))
+	for k, p := range parties {
+		sks[k] = p.sk
+	}
+	return sks
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+func TestRun(t *testing.T) {
+	type runCase struct{ N, buckets int }
+	cases := []runCase{
+		{N: 2, buckets: 1},
+		{N: 5, buckets: 4},
+		{N: 20, buckets: 8},
+	}
+	examples.RunTable(t, cases, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, literal: examples.HEIntParamsN13QP218, buckets: tc.buckets})
+	}, func(t *testing.T, _ runCase, out *result) { checkResult(t, out) })
+}
+
+// checkResult 用明文重新计算各槽位的统计量和直方图，与解密结果比较
+func checkResult(t *testing.T, out *result) {
+	t.Helper()
+	slots := out.params.MaxSlots()
+	sum, count, squares := make([]uint64, slots), make([]uint64, slots), make([]uint64, slots)
+	hist := make([][]uint64, len(out.edges)-1)
+	for b := range hist {
+		hist[b] = make([]uint64, slots)
+	}
+	for i, values := range out.values {
+		for k, v := range values {
+			if !out.present[i][k] {
+				continue
+			}
+			sum[k] += v
+			count[k]++
+			squares[k] += v * v
+			for b := range hist {
+				if out.edges[b] <= v && v < out.edges[b+1] {
+					hist[b][k]++
+				}
+			}
+		}
+	}
+	want := examples.NewStatistics(sum, count, squares)
+	for name, got := range map[string][2][]uint64{
+		"sum":            {out.stats.Sum, want.Sum},
+		"count":          {out.stats.Count, want.Count},
+		"sum of squares": {out.stats.SumOfSquares, want.SumOfSquares},
+	} {
+		if !reflect.DeepEqual(got[0], got[1]) {
+			t.Errorf("%s: decrypted %v..., want %v...", name, got[0][:4], got[1][:4])
+		}
+	}
+	for k := range want.Mean {
+		if !sameFloat(out.stats.Mean[k], want.Mean[k]) || !sameFloat(out.stats.Variance[k], want.Variance[k]) {
+			t.Fatalf("slot %d: mean %v, variance %v, want %v and %v", k, out.stats.Mean[k], out.stats.Variance[k], want.Mean[k], want.Variance[k])
+		}
+	}
+	if !reflect.DeepEqual(out.histogram, hist) {
+		t.Errorf("histogram of slot 0: decrypted %v, want %v", column(out.histogram, 0), column(hist, 0))
+	}
+}
+
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func column(m [][]uint64, k int) []uint64 {
+	c := make([]uint64, len(m))
+	for b := range m {
+		c[b] = m[b][k]
+	}
+	return c
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
 	"github.com/tuneinsight/lattigo/v5/he/heint"
 	"github.com/tuneinsight/lattigo/v5/mhe"
 	"github.com/tuneinsight/lattigo/v5/ring"
@@ -27,31 +29,169 @@ type party struct {
 	ShamirPoly        mhe.ShamirPolynomial
 	ShamirPublicPoint mhe.ShamirPublicPoint
 	mhe.Combiner
+
+	decryptor *rlwe.Decryptor // 复用的解密器
 }
 
 type Computer struct {
-	ringQ *ring.Ring
+	params heint.Parameters
+	ringQ  *ring.Ring
 }
 
 var flagO = flag.Int("o", 0, "the number of online parties")
+var flagSeed = flag.String("seed", "", "INSECURE: derive all the randomness from this master seed, for reproducible runs")
+var flagTranscript = flag.String("transcript", "", "record the transcript of the run to the given file, for the REPLAY tool")
+var flagParams = flag.String("params", "", "load the parameter literal from a JSON or YAML configuration file")
+var flagRecommend = flag.Bool("recommend", false, "use parameters recommended for the number of parties instead of the fixed literal")
+var flagK = flag.Int("k", 0, "the arity of the aggregation tree, 0 for linear aggregation at a single aggregator")
+var flagMalicious = flag.String("malicious", "", "simulate misbehaving parties, e.g. \"3:pk-share,5:shamir+partial-decryption\" (pk-share, shamir, partial-decryption, wrong-key)")
+var flagAudit = flag.String("audit", "", "write the protocol events as JSON lines to the given file, for compliance audits")
+
+// 参数字面量
+var paramsLiteral = heint.ParametersLiteral{
+	LogN:             15,
+	LogQ:             []int{54, 54, 54},
+	LogP:             []int{55},
+	PlaintextModulus: 65537,
+}
+
+// seeded 由主种子派生随机性，未开启时为nil，使用系统随机性
+var seeded *examples.SeededRandomness
+
+// transcript 记录协议消息，未开启时为nil，记录操作为空操作
+var transcript *examples.Transcript
+
+// audit 协议事件的审计日志，未开启时为nil，记录操作为空操作
+var audit *examples.AuditLog
+
+// adversary 指定的恶意参与方及其行为，未开启时为nil，所有参与方诚实
+var adversary *examples.Adversary
 
 func main() {
+	flag.Parse()
+
+	// -seed时所有随机性由主种子和参与方编号派生，两次运行的输出逐字节相同，不安全，仅用于测试
+	if *flagSeed != "" {
+		seeded = examples.NewSeededRandomness([]byte(*flagSeed))
+		fmt.Println("Warning: -seed is INSECURE, all the randomness derives from the master seed")
+	}
+
+	// -transcript时把协议的全部消息记录到文件，供回放工具检查
+	if *flagTranscript != "" {
+		var err error
+		if transcript, err = examples.CreateTranscript(*flagTranscript); err != nil {
+			fmt.Println("Error creating transcript:", err)
+			return
+		}
+		defer func() {
+			if err := transcript.Close(); err != nil {
+				fmt.Println("Error recording transcript:", err)
+			}
+		}()
+	}
+
+	// -malicious时指定的参与方偏离协议，运行结束后报告各检查是否发现
+	if *flagMalicious != "" {
+		var err error
+		if adversary, err = examples.ParseAdversary(*flagMalicious); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
+	// -audit时把协议事件以JSON行写入审计日志，-seed时会话ID可复现
+	if *flagAudit != "" {
+		var err error
+		if audit, err = examples.CreateAuditLog(*flagAudit, examples.NewSessionID(seeded.Seed(-1, "session"))); err != nil {
+			fmt.Println("Error creating audit log:", err)
+			return
+		}
+		defer func() {
+			if err := audit.Close(); err != nil {
+				fmt.Println("Error closing audit log:", err)
+			}
+		}()
+	}
+
+	// 创建参数字面量，-params时从配置文件读取
+	literal := paramsLiteral
+	if *flagParams != "" {
+		config, err := examples.LoadParametersConfig(*flagParams)
+		if err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+		if literal, err = config.HEIntLiteral(); err != nil {
+			fmt.Println("Error loading parameters:", err)
+			return
+		}
+	}
+
+	//假设有N个参与方，阈值为95
+	if _, err := run(config{N: 100, t: 95, o: *flagO, literal: literal, recommend: *flagRecommend, k: *flagK}); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// config 一次运行的配置
+type config struct {
+	N          int                     // 参与方数量
+	t          int                     // 阈值
+	o          int                     // 在线参与方数量，0表示全部参与方
+	literal    heint.ParametersLiteral // 参数字面量
+	recommend  bool                    // 按参与方数量推荐参数，忽略literal
+	k          int                     // 聚合树的叉数
+	decryptors int                     // 参与解密的在线参与方数量，0表示t个
+}
+
+// result 一次运行的输入与解密结果
+type result struct {
+	params    heint.Parameters
+	inputs    [][]uint64 // 各参与方的输入
+	decrypted [][]uint64 // 各参与方密文的解密结果
+	summands  [2]int     // 同态加法的两个参与方
+	sum       []uint64   // ctadd的解密结果
+}
+
+// run 运行一次完整的流程：参数初始化、密钥生成、秘密共享、加密、同态加法和门限解密
+func run(cfg config) (*result, error) {
+	N, t := cfg.N, cfg.t
+	decryptors := t
+	if cfg.decryptors > 0 && cfg.decryptors < t {
+		decryptors = cfg.decryptors
+	}
+
 	var start, end time.Time
 	var duration time.Duration
 	var durationall time.Duration
 	//*****初始化参数*****
 	fmt.Println("> Parameter initialization Phase")
+	transcript.Phase("Parameter initialization Phase")
+	audit.Phase("Parameter initialization Phase")
 	start = time.Now()
-	// 创建参数字面量
-	params, err := heint.NewParametersFromLiteral(heint.ParametersLiteral{
-		LogN:             15,
-		LogQ:             []int{54, 54, 54},
-		LogP:             []int{55},
-		PlaintextModulus: 65537,
-	})
+	// -recommend时按参与方数量推荐参数
+	literal := cfg.literal
+	if cfg.recommend {
+		rec, err := examples.RecommendHEIntParameters(examples.MultipartyRequirements{Parties: N, Threshold: t, Inputs: 2, PlaintextModulus: literal.PlaintextModulus, Security: 128})
+		if err != nil {
+			return nil, fmt.Errorf("recommending parameters: %w", err)
+		}
+		fmt.Print(rec)
+		literal = rec.Literal
+	}
+	params, err := heint.NewParametersFromLiteral(literal)
 	if err != nil {
-		fmt.Println("Error creating parameters:", err)
-		return
+		return nil, fmt.Errorf("creating parameters: %w", err)
+	}
+	out := &result{params: params, inputs: make([][]uint64, N), decrypted: make([][]uint64, N)}
+	transcript.Params(examples.HEIntConfig("", literal))
+	// 参数指纹，各参与方比对以确认使用相同的参数
+	fmt.Println("fingerprint:", examples.HEIntFingerprint(params))
+	// 估计参数的安全性，低于128比特时给出警告
+	security := examples.EstimateSecurity(params)
+	fmt.Println("security:", security)
+	if !security.Meets(128) {
+		fmt.Println("Warning: the parameters are below 128-bit security")
 	}
 	end = time.Now()
 	duration = end.Sub(start)
@@ -62,29 +202,30 @@ func main() {
 
 	//*****私钥生成*****
 	fmt.Println("> Private key generation Phase")
+	transcript.Phase("Private key generation Phase")
+	audit.Phase("Private key generation Phase")
 	start = time.Now()
-	// 创建密钥生成器
-	kgen := rlwe.NewKeyGenerator(params)
 
 	//假设有N个参与方，自定义输入
 	// fmt.Println("输入参与方数量：")
-	N := 100
 	//_, err = fmt.Scanln(&N)
 	// if err != nil {
 	// 	fmt.Println("Error reading input:", err)
 	// 	return
 	// }
 	var o int
-	if *flagO <= 0 {
+	if cfg.o <= 0 {
 		o = N
 	} else {
-		o = *flagO
+		o = cfg.o
 	}
 
 	parties := make([]*party, N)
 	for i := 0; i < N; i++ {
-		sk := kgen.GenSecretKeyNew()
+		var sk *rlwe.SecretKey
+		seeded.With(i, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
 		parties[i] = &party{i: i, sk: sk}
+		audit.PartyJoined(i)
 		// fmt.Printf("Party %d: Secret key generated successfully\n", i)
 	}
 	end = time.Now()
@@ -94,17 +235,18 @@ func main() {
 
 	//*****秘密共享*****
 	fmt.Println("> Shamir Secret Share Phase")
+	transcript.Phase("Shamir Secret Share Phase")
+	audit.Phase("Shamir Secret Share Phase")
 	start = time.Now()
 	//秘密共享的初始化操作
 	shamirPublicPoints := make([]mhe.ShamirPublicPoint, 0)
-	t := 95
 	//_, err = fmt.Scanln(&t)
 	// if err != nil {
 	// 	fmt.Println("Error reading input:", err)
 	// 	return
 	// }
 	for i := 0; i < N; i++ {
-		parties[i].Thresholdizer = mhe.NewThresholdizer(params)
+		seeded.With(i, "thresholdizer", func() { parties[i].Thresholdizer = mhe.NewThresholdizer(params) })
 		parties[i].share = parties[i].Thresholdizer.AllocateThresholdSecretShare()
 		var err error
 		parties[i].ShamirPoly, err = parties[i].Thresholdizer.GenShamirPolynomial(t, parties[i].sk)
@@ -121,24 +263,19 @@ func main() {
 			pi.Combiner = mhe.NewCombiner(*params_rlwe.GetRLWEParameters(), pi.ShamirPublicPoint, shamirPublicPoints, t)
 		}
 
-		shares := make(map[*party]map[*party]mhe.ShamirSecretShare, len(parties))
-
+		// pi发给pj的份额写入同一个缓冲区后立即被pj聚合，无需保存N*N个份额
+		share := parties[0].Thresholdizer.AllocateThresholdSecretShare()
 		for _, pi := range parties {
-
-			shares[pi] = make(map[*party]mhe.ShamirSecretShare)
-
 			for _, pj := range parties {
-				share := pi.Thresholdizer.AllocateThresholdSecretShare()
 				pi.Thresholdizer.GenShamirSecretShare(pj.ShamirPublicPoint, pi.ShamirPoly, &share)
-				shares[pi][pj] = share
-			}
-
-		}
-
-		for _, pi := range parties {
-			for _, pj := range parties {
-				share := shares[pj][pi]
-				if err := pi.Thresholdizer.AggregateShares(pi.share, share, &pi.share); err != nil {
+				// 恶意参与方发给其他参与方的份额不是同一个多项式的取值
+				if pj != pi && adversary.Misbehaves(pi.i, examples.MisbehaviourShamirShares) {
+					adversary.Corrupt(params, share.Q)
+					adversary.Inject("Shamir Secret Share Phase", pi.i, examples.MisbehaviourShamirShares)
+				}
+				audit.ShareSent("shamir", examples.AuditShamirShare, pi.i, pj.i)
+				audit.ShareReceived("shamir", examples.AuditShamirShare, pj.i, pi.i)
+				if err := pj.Thresholdizer.AggregateShares(pj.share, share, &pj.share); err != nil {
 					panic(err)
 				}
 			}
@@ -152,6 +289,8 @@ func main() {
 
 	//*****公钥生成*****
 	fmt.Println("> Public key generation Phase")
+	transcript.Phase("Public key generation Phase")
+	audit.Phase("Public key generation Phase")
 	// 创建公钥生成协议实例
 	start = time.Now()
 
@@ -159,16 +298,33 @@ func main() {
 	parties_oline := parties[:o]
 
 	// 重构
+	sks := make([]*rlwe.SecretKey, t)
 	for i := 0; i < t; i++ {
+		sks[i] = parties[i].sk
 		parties[i].sk = parties[i].combine(t, N, parties_oline, params)
+		transcript.Record("keys", examples.TranscriptSecretKey, i, parties[i].sk)
 	}
-	ckg := mhe.NewPublicKeyGenProtocol(params)
-
-	// 生成CRS
-	crs, err := sampling.NewPRNG()
+	// 在线参与方的加性份额之和是集体私钥
+	skAgg := examples.SumSecretKeys(params, sksOf(parties_oline)...)
+	// 模拟中已知所有私钥：集体私钥应等于全部参与方私钥之和，否则有参与方发送了不一致的Shamir份额
+	if adversary != nil && t != N {
+		for i := t; i < N; i++ {
+			sks = append(sks, parties[i].sk)
+		}
+		if want := examples.SumSecretKeys(params, sks...); !skAgg.Value.Q.Equal(&want.Value.Q) {
+			adversary.Detect("Public key generation Phase", "the additive shares of the online parties do not sum to the secret keys of all the parties", true, -1, examples.MisbehaviourShamirShares)
+		}
+	}
+	var ckg mhe.PublicKeyGenProtocol
+	seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
+
+	// 生成CRS，种子记录在transcript中以便回放
+	crsSeed := seeded.Seed(-1, "crs")
+	transcript.RecordBytes("pk", examples.TranscriptCRSSeed, -1, crsSeed)
+	audit.CRPDerived("pk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
 	if err != nil {
-		fmt.Println("Error creating CRS:", err)
-		return
+		return nil, fmt.Errorf("creating CRS: %w", err)
 	}
 	//从CRS中抽样记作CRP
 	crp := ckg.SampleCRP(crs)
@@ -176,16 +332,34 @@ func main() {
 	// fmt.Println("CRS generated successfully!")
 	// 生成公钥
 	pk := rlwe.NewPublicKey(params)
-	// 假设这是从参与方处接收到的最终聚合的共享
-	roundShare := ckg.AllocateShare()
-
+	// 每个在线参与方生成份额
+	pkShares := make([]mhe.PublicKeyGenShare, t)
 	for i := 0; i < t; i++ {
 		parties[i].shareOut = ckg.AllocateShare()
 		ckg.GenShare(parties[i].sk, crp, &parties[i].shareOut)
-		ckg.AggregateShares(parties[i].shareOut, roundShare, &roundShare)
+		// 恶意参与方发送错误的公钥份额
+		if adversary.Misbehaves(i, examples.MisbehaviourPublicKeyShare) {
+			adversary.Corrupt(params, parties[i].shareOut.Q)
+			adversary.Inject("Public key generation Phase", i, examples.MisbehaviourPublicKeyShare)
+		}
+		// 模拟中用参与方的私钥检查份额的误差
+		if adversary != nil {
+			if err := examples.CheckPublicKeyGenShare(params, crp, parties[i].sk, parties[i].shareOut); err != nil {
+				adversary.Detect("Public key generation Phase", "a public-key share has an error above the bound for the party's key", true, i, examples.MisbehaviourPublicKeyShare)
+			}
+		}
+		pkShares[i] = parties[i].shareOut
+		transcript.Record("pk", examples.TranscriptPublicKeyShare, i, &parties[i].shareOut)
 	}
 
+	// 按k叉树聚合份额，根节点得到最终聚合的共享
+	roundShare := examples.AggregateTree(pkShares, cfg.k, ckg.AllocateShare, func(share mhe.PublicKeyGenShare, acc *mhe.PublicKeyGenShare) {
+		ckg.AggregateShares(share, *acc, acc)
+	})
+	examples.AuditTree(audit, "pk", examples.TranscriptPublicKeyShare, indices(parties_oline), cfg.k)
+
 	ckg.GenPublicKey(roundShare, crp, pk)
+	transcript.Record("pk", examples.TranscriptPublicKey, -1, pk)
 	//公钥生成成功
 	// fmt.Println("Public key generated successfully!")
 	end = time.Now()
@@ -195,9 +369,10 @@ func main() {
 
 	//*****加密*****
 	fmt.Println("> Encrypt Phase")
+	transcript.Phase("Encrypt Phase")
+	audit.Phase("Encrypt Phase")
 	start = time.Now()
-	//初始化加密生成器和编码生成器
-	encryptor := rlwe.NewEncryptor(params, pk)
+	//初始化编码生成器
 	encoder := heint.NewEncoder(params)
 	//生成明文
 	for i := 0; i < N; i++ {
@@ -211,6 +386,7 @@ func main() {
 		for j := range parties[i].input {
 			parties[i].input[j] = uint64(i)
 		}
+		out.inputs[i] = parties[i].input
 		//编码
 		if err := encoder.Encode(parties[i].input, parties[i].pt); err != nil {
 			panic(err)
@@ -222,12 +398,30 @@ func main() {
 		fmt.Printf("\t%v...%v\n", res0[:8], res0[params.N()-8:]) //打印前八个元素和后八个元素
 
 		// fmt.Printf("Party %d: Plaintext encoded successfully!\n", i)
-		//加密
+		//加密，每个参与方使用自己的加密器
+		var encryptor *rlwe.Encryptor
+		seeded.With(i, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, pk) })
+		// 恶意参与方用自己生成的公钥而不是集体公钥加密
+		if adversary.Misbehaves(i, examples.MisbehaviourWrongKey) {
+			kgen := rlwe.NewKeyGenerator(params)
+			encryptor = rlwe.NewEncryptor(params, kgen.GenPublicKeyNew(kgen.GenSecretKeyNew()))
+			adversary.Inject("Encrypt Phase", i, examples.MisbehaviourWrongKey)
+		}
 		if err := encryptor.Encrypt(parties[i].pt, parties[i].ct); err != nil {
 			panic(err)
 		}
+		// 模拟中用集体私钥检查密文，噪声预算为负说明密文不是在集体公钥下加密的
+		if adversary != nil {
+			noise, err := examples.CiphertextNoise(params, parties[i].ct, parties[i].pt, skAgg)
+			if err != nil {
+				return nil, err
+			}
+			if examples.NoiseBudget(params, parties[i].ct.Level(), noise) < 0 {
+				adversary.Detect("Encrypt Phase", "the ciphertext does not decrypt under the collective secret key", true, i, examples.MisbehaviourWrongKey)
+			}
+		}
 		// fmt.Printf("Party %d: Ciphertext generated successfully!\n", i)
-		//parties[i].ct = extendCiphertext(parties[i].ct, N, params, i)
+		//parties[i].ct = examples.ExtendCiphertext(parties[i].ct, N, params, i)
 	}
 	end = time.Now()
 	duration = end.Sub(start)
@@ -236,10 +430,21 @@ func main() {
 
 	//*****同态加法*****
 	fmt.Println("> Computation Phase")
+	transcript.Phase("Computation Phase")
+	audit.Phase("Computation Phase")
+	// 计算前预测解密后剩余的噪声预算，预算不足时告警
+	noiseModel := examples.NoiseModel{Params: params, Parties: t, Decryptors: decryptors}
+	prediction := noiseModel.Predict(2, params.MaxLevel())
+	if err := prediction.Err(); err != nil {
+		fmt.Println("Warning:", err)
+	}
 	start = time.Now()
 	ctadd := heint.NewCiphertext(params, 1, params.MaxLevel())
 	computer := NewComputer(params)
-	computer.Add(parties[1].ct, parties[2].ct, ctadd, N)
+	// 参与同态加法的两个参与方，N<3时回绕
+	a, b := 1%N, 2%N
+	out.summands = [2]int{a, b}
+	computer.Add(parties[a].ct, parties[b].ct, ctadd, N)
 	// fmt.Printf("The sum of parties[1].ct and parties[2].ct generated successfully!\n")
 
 	end = time.Now()
@@ -249,6 +454,8 @@ func main() {
 
 	//*****解密*****
 	fmt.Println("> Decrypt Phase")
+	transcript.Phase("Decrypt Phase")
+	audit.Phase("Decrypt Phase")
 	start = time.Now()
 	//重构
 	for i := 0; i < t; i++ {
@@ -259,48 +466,38 @@ func main() {
 	// 	parties[i].ct = reCiphertext(parties[i].ct, N, params, i)
 	// }
 
+	fmt.Printf("aggregation tree: arity %d, depth %d, root inbound %d\n", examples.TreeArity(decryptors, cfg.k), examples.TreeDepth(decryptors, cfg.k), examples.TreeRootInbound(decryptors, cfg.k))
+	ptparts := allocateDecryptionBuffers(params, parties)[:decryptors]
+	// 只有前decryptors个在线参与方参与解密，少于t时解密结果错误
+	parties_decrypt := parties_oline[:decryptors]
+	res := make([]uint64, params.MaxSlots())
 	for j := 0; j < N; j++ {
-		//解密分额的和
-		hisigema := heint.NewPlaintext(params, params.MaxLevel())
-
-		for i := 0; i < t; i++ {
-			parties[i].ptpart = heint.NewPlaintext(params, params.MaxLevel())
-			decryptor := rlwe.NewDecryptor(params, parties[i].sk)
-			decryptor.Decryptpart(parties[j].ct, parties[i].ptpart) //部分解密
+		hisigema := decrypt(params, parties_decrypt, ptparts, parties[j].ct, parties[j].decryptor, cfg.k, fmt.Sprintf("decrypt/%d", j))
 
-			decryptor.Decryptadd(parties[i].ptpart, hisigema) //求和
-		}
-		decryptor := rlwe.NewDecryptor(params, parties[j].sk)
-		decryptor.Decryptall(parties[j].ct, hisigema) //全部解密
-
-		res := make([]uint64, params.MaxSlots())
 		if err := encoder.Decode(hisigema, res); err != nil {
 			panic(err)
 		}
 		fmt.Printf("\t%v...%v\n", res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+		out.decrypted[j] = append([]uint64(nil), res...)
+		audit.DecryptionReleased(fmt.Sprintf("decrypt/%d", j), j, indices(parties_decrypt))
+		// 诚实参与方知道自己的输入，可以检查自己密文的解密结果
+		if adversary != nil && adversary.Honest(j) && !slices.Equal(out.decrypted[j], parties[j].input) {
+			adversary.Detect("Decrypt Phase", "an honest party's ciphertext does not decrypt to its input", false, -1,
+				examples.MisbehaviourPublicKeyShare, examples.MisbehaviourShamirShares, examples.MisbehaviourPartialDecryption)
+		}
 
 		// fmt.Printf("Party %d: NewPlaintext generated successfully!\n", j)
 	}
 
 	//*****同态加法解密*****
-	//解密份额的和
-	hisigema := heint.NewPlaintext(params, params.MaxLevel())
-
-	for i := 0; i < t; i++ {
-		ptaddpart := heint.NewPlaintext(params, params.MaxLevel())
-		decryptor := rlwe.NewDecryptor(params, parties[i].sk)
-		decryptor.Decryptpart(ctadd, ptaddpart) //部分解密
-
-		decryptor.Decryptadd(ptaddpart, hisigema) //求和
-	}
-	decryptor := rlwe.NewDecryptor(params, parties[1].sk)
-	decryptor.Decryptall(ctadd, hisigema) //全部解密
+	hisigema := decrypt(params, parties_decrypt, ptparts, ctadd, parties[a].decryptor, cfg.k, "decrypt/ctadd")
+	audit.DecryptionReleased("decrypt/ctadd", a, indices(parties_decrypt))
 
-	res := make([]uint64, params.MaxSlots())
 	if err := encoder.Decode(hisigema, res); err != nil {
 		panic(err)
 	}
 	fmt.Printf("ctadd 解密得%v...%v\n", res[:8], res[params.N()-8:]) //打印前八个元素和后八个元素
+	out.sum = append([]uint64(nil), res...)
 
 	// fmt.Printf("Party %d: NewPlaintext generated successfully!\n", j)
 	end = time.Now()
@@ -309,9 +506,52 @@ func main() {
 	fmt.Printf("解密time: %s\n", duration)
 
 	fmt.Printf("all time: %s\n", durationall)
+
+	//*****噪声分析*****
+	// 模拟中已知所有私钥，测量实际噪声并与理论估计比较
+	fmt.Println("> Noise analysis")
+	transcript.Phase("Noise analysis")
+	audit.Phase("Noise analysis")
+	noiseFresh, err := examples.CiphertextNoise(params, parties[a].ct, parties[a].pt, skAgg)
+	if err != nil {
+		return nil, err
+	}
+	printNoise(params, "fresh", parties[a].ct.Level(), noiseFresh, prediction.Fresh)
+	// ctadd的期望明文
+	sum := make([]uint64, params.N())
+	for k := range sum {
+		sum[k] = (parties[a].input[k] + parties[b].input[k]) % params.PlaintextModulus()
+	}
+	ptadd := heint.NewPlaintext(params, ctadd.Level())
+	ptadd.Scale = ctadd.Scale
+	if err := encoder.Encode(sum, ptadd); err != nil {
+		panic(err)
+	}
+	noiseAdd, err := examples.CiphertextNoise(params, ctadd, ptadd, skAgg)
+	if err != nil {
+		return nil, err
+	}
+	printNoise(params, "ctadd", ctadd.Level(), noiseAdd, prediction.Computed)
+	printNoise(params, "decrypted ctadd", ctadd.Level(), examples.PlaintextNoise(params, hisigema, ptadd), prediction.Decrypted)
+
+	//*****恶意参与方报告*****
+	if adversary != nil {
+		fmt.Println("> Adversary report")
+		fmt.Print(adversary.Report())
+	}
+	return out, nil
 }
 
-// func extendCiphertext(ct *rlwe.Ciphertext, N int, params heint.Parameters, i int) *rlwe.Ciphertext {
+// sksOf 参与方当前的私钥
+func sksOf(parties []*party) []*rlwe.SecretKey {
+	sks := make([]*rlwe.SecretKey, len(parties))
+	for i, p := range parties {
+		sks[i] = p.sk
+	}
+	return sks
+}
+
+// func examples.ExtendCiphertext(ct *rlwe.Ciphertext, N int, params heint.Parameters, i int) *rlwe.Ciphertext {
 // 	ctext := heint.NewCiphertext(params, N, ct.Level())
 // 	ctext.Value[0] = ct.Value[0]
 // 	ctext.Value[i+1] = ct.Value[1]
@@ -345,7 +585,8 @@ func (p *party) combine(t int, N int, parties_oline []*party, params heint.Param
 }
 func NewComputer(params heint.Parameters) *Computer {
 	return &Computer{
-		ringQ: params.RingQ(),
+		params: params,
+		ringQ:  params.RingQ(),
 	}
 }
 
@@ -356,3 +597,147 @@ func (c Computer) Add(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctadd *rlwe.Ci
 		ringQ.Add(ct1.Value[i], ct2.Value[i], ctadd.Value[i])
 	}
 }
+
+// Sub 密文相减，ctsub = ct1 - ct2
+func (c Computer) Sub(ct1 *rlwe.Ciphertext, ct2 *rlwe.Ciphertext, ctsub *rlwe.Ciphertext) {
+	level := ct1.Level()
+	ringQ := c.ringQ.AtLevel(level)
+	for i := 0; i < len(ct1.Value); i++ {
+		ringQ.Sub(ct1.Value[i], ct2.Value[i], ctsub.Value[i])
+	}
+	*ctsub.MetaData = *ct1.MetaData
+}
+
+// Neg 密文取负，ctneg = -ct
+func (c Computer) Neg(ct *rlwe.Ciphertext, ctneg *rlwe.Ciphertext) {
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.Neg(ct.Value[i], ctneg.Value[i])
+	}
+	*ctneg.MetaData = *ct.MetaData
+}
+
+// MulScalar 密文的每个槽位乘以同一个公开的整数，scalar按明文模数约简，尺度不变
+func (c Computer) MulScalar(ct *rlwe.Ciphertext, scalar uint64, ctmul *rlwe.Ciphertext) {
+	scalar %= c.params.PlaintextModulus()
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.MulScalar(ct.Value[i], scalar, ctmul.Value[i])
+	}
+	*ctmul.MetaData = *ct.MetaData
+}
+
+// AddPlain 密文加明文，明文只加到c0上。明文的尺度须与密文相同(编码前设置pt.Scale = ct.Scale)
+func (c Computer) AddPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctadd *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot AddPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ringQ.Add(ct.Value[0], pt.Value, ctadd.Value[0])
+	for i := 1; i < len(ct.Value); i++ {
+		ctadd.Value[i].Copy(ct.Value[i])
+	}
+	*ctadd.MetaData = *ct.MetaData
+	return nil
+}
+
+// SubPlain 密文减明文，明文只从c0中减去，尺度要求与AddPlain相同
+func (c Computer) SubPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctsub *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, true); err != nil {
+		return fmt.Errorf("cannot SubPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ringQ.Sub(ct.Value[0], pt.Value, ctsub.Value[0])
+	for i := 1; i < len(ct.Value); i++ {
+		ctsub.Value[i].Copy(ct.Value[i])
+	}
+	*ctsub.MetaData = *ct.MetaData
+	return nil
+}
+
+// MulPlain 密文逐槽位乘以明文，结果写入ctmul，尺度为两者尺度之积。
+// 密文和明文都在NTT域，明文先转为Montgomery形式再逐系数相乘
+func (c Computer) MulPlain(ct *rlwe.Ciphertext, pt *rlwe.Plaintext, ctmul *rlwe.Ciphertext) error {
+	if err := checkPlaintext(ct.MetaData, ct.Level(), pt, false); err != nil {
+		return fmt.Errorf("cannot MulPlain: %w", err)
+	}
+	ringQ := c.ringQ.AtLevel(ct.Level())
+	ptm := ringQ.NewPoly()
+	ringQ.MForm(pt.Value, ptm)
+	for i := 0; i < len(ct.Value); i++ {
+		ringQ.MulCoeffsMontgomery(ct.Value[i], ptm, ctmul.Value[i])
+	}
+	*ctmul.MetaData = *ct.MetaData
+	ctmul.Scale = ct.Scale.Mul(pt.Scale)
+	return nil
+}
+
+// checkPlaintext 明文须与密文同在NTT域且层级不低于密文；加减时尺度还须与密文相同
+func checkPlaintext(md *rlwe.MetaData, level int, pt *rlwe.Plaintext, sameScale bool) error {
+	if pt.Level() < level {
+		return fmt.Errorf("plaintext at level %d, below the ciphertext at level %d", pt.Level(), level)
+	}
+	if pt.IsNTT != md.IsNTT {
+		return fmt.Errorf("plaintext in NTT domain: %v, ciphertext: %v", pt.IsNTT, md.IsNTT)
+	}
+	if sameScale && !pt.Scale.Equal(md.Scale) {
+		return fmt.Errorf("plaintext scale %v, ciphertext scale %v: encode the plaintext with pt.Scale = ct.Scale", pt.Scale.Uint64(), md.Scale.Uint64())
+	}
+	return nil
+}
+
+// printNoise 打印实测噪声、理论估计和剩余的噪声预算
+func printNoise(params heint.Parameters, label string, level int, measured, predicted float64) {
+	fmt.Printf("%s noise: measured 2^%.2f, predicted 2^%.2f, budget %.2f bits\n", label, measured, predicted, examples.NoiseBudget(params, level, measured))
+}
+
+// allocateDecryptionBuffers 为每个参与方创建一次解密器和部分解密的缓冲区，
+// 之后所有密文的解密都复用它们
+func allocateDecryptionBuffers(params heint.Parameters, parties []*party) []*rlwe.Plaintext {
+	ptparts := make([]*rlwe.Plaintext, len(parties))
+	for i, p := range parties {
+		p.decryptor = rlwe.NewDecryptor(params, p.sk)
+		p.ptpart = heint.NewPlaintext(params, params.MaxLevel())
+		ptparts[i] = p.ptpart
+	}
+	return ptparts
+}
+
+// decrypt 在线参与方对ct部分解密并写入自己的缓冲区，份额按k叉树原地求和，
+// 最后由dec完成解密。返回的明文是参与方0的缓冲区，下一次调用会覆盖它。
+func decrypt(params heint.Parameters, parties []*party, ptparts []*rlwe.Plaintext, ct *rlwe.Ciphertext, dec *rlwe.Decryptor, k int, round string) *rlwe.Plaintext {
+	transcript.Record(round, examples.TranscriptCiphertext, -1, ct)
+	for i, p := range parties {
+		p.decryptor.Decryptpart(ct, ptparts[i]) //部分解密
+		// 恶意参与方发送错误的部分解密
+		if adversary.Misbehaves(p.i, examples.MisbehaviourPartialDecryption) {
+			adversary.Corrupt(params, ptparts[i].Value)
+			adversary.Inject("Decrypt Phase", p.i, examples.MisbehaviourPartialDecryption)
+		}
+		// 模拟中用参与方的私钥重新计算部分解密并比较
+		if adversary != nil {
+			if err := examples.CheckPartialDecryption(params, p.sk, ct, ptparts[i]); err != nil {
+				adversary.Detect("Decrypt Phase", "a partial decryption differs from the one recomputed with the party's key", true, p.i, examples.MisbehaviourPartialDecryption)
+			}
+		}
+		transcript.Record(round, examples.TranscriptPartialDecryption, p.i, ptparts[i])
+		audit.PartialDecryption(round, p.i)
+	}
+	hisigema := examples.AggregateTree(ptparts, k, nil, func(share *rlwe.Plaintext, acc **rlwe.Plaintext) {
+		dec.Decryptadd(share, *acc) //求和
+	})
+	examples.AuditTree(audit, round, examples.TranscriptPartialDecryption, indices(parties), k)
+	transcript.Record(round, examples.TranscriptDecryptionSum, -1, hisigema)
+	dec.Decryptall(ct, hisigema) //全部解密
+	transcript.Record(round, examples.TranscriptDecryption, -1, hisigema)
+	return hisigema
+}
+
+// indices 参与方的编号
+func indices(parties []*party) []int {
+	idx := make([]int, len(parties))
+	for k, p := range parties {
+		idx[k] = p.i
+	}
+	return idx
+}
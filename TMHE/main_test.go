@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+// TestRun ok为false时参与解密的参与方不足，都不应解密出正确的明文
+func TestRun(t *testing.T) {
+	type runCase struct {
+		N, t, decryptors int
+		ok               bool
+	}
+	cases := []runCase{
+		{N: 2, t: 2, ok: true},
+		{N: 3, t: 2, ok: true},
+		{N: 3, t: 3, ok: true},
+		{N: 10, t: 2, ok: true},
+		{N: 10, t: 5, ok: true},
+		{N: 10, t: 10, ok: true},
+		{N: 3, t: 2, decryptors: 1},
+		{N: 10, t: 5, decryptors: 4},
+		{N: 10, t: 10, decryptors: 9},
+	}
+	examples.RunTable(t, cases, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, t: tc.t, literal: examples.HEIntParamsN12QP109, decryptors: tc.decryptors})
+	}, func(t *testing.T, tc runCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, tc.ok, tc.ok)
+	})
+}
+
+func TestMalicious(t *testing.T) {
+	for _, tc := range []struct {
+		m          examples.Misbehaviour
+		detected   bool // 协议本身能发现
+		pinpointed bool // 模拟中能确定恶意参与方
+	}{
+		{m: examples.MisbehaviourPublicKeyShare, detected: true, pinpointed: true},
+		// 公钥生成和解密使用同一组在线参与方，不一致的Shamir份额不影响解密结果
+		{m: examples.MisbehaviourShamirShares},
+		{m: examples.MisbehaviourPartialDecryption, detected: true, pinpointed: true},
+		// 只有恶意参与方自己的密文解密错误，诚实参与方无从发现
+		{m: examples.MisbehaviourWrongKey, pinpointed: true},
+	} {
+		t.Run(string(tc.m), func(t *testing.T) {
+			var err error
+			if adversary, err = examples.ParseAdversary(fmt.Sprintf("1:%s", tc.m)); err != nil {
+				t.Fatal(err)
+			}
+			defer func() { adversary = nil }()
+
+			if _, err := run(config{N: 4, t: 3, literal: examples.HEIntParamsN12QP109}); err != nil {
+				t.Fatal(err)
+			}
+			events := adversary.Events()
+			if len(events) != 1 || events[0].Phase == "" {
+				t.Fatalf("misbehaviour not injected: %v", events)
+			}
+			e := events[0]
+			if e.Detected() != tc.detected {
+				t.Errorf("detected by the protocol: %v, want %v\n%s", e.Detected(), tc.detected, e)
+			}
+			var oracle, pinpointed bool
+			for _, d := range e.Detections {
+				oracle = oracle || d.Oracle
+				pinpointed = pinpointed || d.Pinpointed
+			}
+			if !oracle {
+				t.Errorf("not detected by the simulation oracle\n%s", e)
+			}
+			if pinpointed != tc.pinpointed {
+				t.Errorf("party identified: %v, want %v\n%s", pinpointed, tc.pinpointed, e)
+			}
+		})
+	}
+}
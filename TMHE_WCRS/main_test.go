@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/examples"
+)
+
+// TestRun 每个参与方独立解密自己的密文，总是正确，ok为false时参与ctadd解密的参与方不足，ctadd不应解密出正确的明文
+func TestRun(t *testing.T) {
+	type runCase struct {
+		N, t, decryptors int
+		compress         bool
+		ok               bool
+	}
+	cases := []runCase{
+		{N: 2, t: 2, ok: true},
+		{N: 3, t: 2, ok: true},
+		{N: 3, t: 3, ok: true},
+		{N: 10, t: 5, ok: true},
+		{N: 10, t: 10, ok: true},
+		{N: 10, t: 5, compress: true, ok: true},
+		{N: 3, t: 2, decryptors: 1},
+		{N: 10, t: 10, decryptors: 1},
+	}
+	examples.RunTable(t, cases, func(tc runCase) (*result, error) {
+		return run(config{N: tc.N, t: tc.t, literal: examples.HEIntParamsN12QP109, compress: tc.compress, decryptors: tc.decryptors})
+	}, func(t *testing.T, tc runCase, out *result) {
+		examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, true, tc.ok)
+	})
+}
+
+// TestThreshold 在线参与方由Shamir份额重构的加性份额都非零，t<N时其和等于全部参与方的私钥之和；
+// 在线参与方少于t时重构失败
+func TestThreshold(t *testing.T) {
+	for _, tc := range []struct {
+		N, t, online int
+		ok           bool
+	}{
+		{N: 3, t: 2, ok: true},
+		{N: 10, t: 5, ok: true},
+		{N: 10, t: 10, ok: true},
+		{N: 3, t: 2, online: 1},
+		{N: 10, t: 5, online: 4},
+	} {
+		t.Run(fmt.Sprintf("N=%d/t=%d/online=%d", tc.N, tc.t, tc.online), func(t *testing.T) {
+			out, err := run(config{N: tc.N, t: tc.t, literal: examples.HEIntParamsN12QP109, online: tc.online})
+			if !tc.ok {
+				if err == nil {
+					t.Fatal("fewer online parties than the threshold: no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			examples.CheckDecryption(t, out.params.PlaintextModulus(), out.inputs, out.decrypted, out.summands, out.sum, true, true)
+
+			if len(out.combined) != tc.t {
+				t.Fatalf("%d combined keys, want %d", len(out.combined), tc.t)
+			}
+			zero := rlwe.NewSecretKey(out.params)
+			for i, sk := range out.combined {
+				if sk.Value.Q.Equal(&zero.Value.Q) {
+					t.Errorf("party %d: the combined key is zero", i)
+				}
+			}
+			if tc.t < tc.N {
+				got := examples.SumSecretKeys(out.params, out.combined...)
+				want := examples.SumSecretKeys(out.params, out.keys...)
+				if !got.Value.Q.Equal(&want.Value.Q) {
+					t.Error("the combined keys do not sum to the secret keys of all the parties")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package examples
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// Misbehaviour is a way a simulated party deviates from the protocol.
+type Misbehaviour string
+
+// The misbehaviours of an Adversary. Each produces well-formed messages, which pass the Decode functions.
+const (
+	MisbehaviourPublicKeyShare    Misbehaviour = "pk-share"           // adds a uniform polynomial to its public-key share
+	MisbehaviourShamirShares      Misbehaviour = "shamir"             // sends Shamir shares of another polynomial to every other party
+	MisbehaviourPartialDecryption Misbehaviour = "partial-decryption" // adds a uniform polynomial to its partial decryptions
+	MisbehaviourWrongKey          Misbehaviour = "wrong-key"          // encrypts its input under a public key of its own instead of the collective one
+)
+
+var misbehaviours = []Misbehaviour{MisbehaviourPublicKeyShare, MisbehaviourShamirShares, MisbehaviourPartialDecryption, MisbehaviourWrongKey}
+
+// Detection is a failed check attributed to a misbehaviour.
+type Detection struct {
+	Phase string
+	Check string
+
+	// Oracle is true if the check uses the secret keys of the parties, which only a simulation knows;
+	// otherwise the honest parties can run it in the protocol.
+	Oracle bool
+
+	// Pinpointed is true if the check identifies the misbehaving party.
+	Pinpointed bool
+}
+
+func (d Detection) String() string {
+	by := "the protocol"
+	if d.Oracle {
+		by = "the simulation oracle"
+	}
+	s := fmt.Sprintf("detected by %s in %s: %s", by, d.Phase, d.Check)
+	if d.Pinpointed {
+		s += " (party identified)"
+	}
+	return s
+}
+
+// AdversaryEvent is a misbehaviour of a party and the checks that detected it.
+type AdversaryEvent struct {
+	Party        int
+	Misbehaviour Misbehaviour
+	Phase        string // phase the misbehaviour was injected in, empty if the party never had the opportunity
+	Detections   []Detection
+}
+
+// Detected returns true if a check the honest parties can run detected the event.
+func (e AdversaryEvent) Detected() bool {
+	for _, d := range e.Detections {
+		if !d.Oracle {
+			return true
+		}
+	}
+	return false
+}
+
+func (e AdversaryEvent) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "party %d %s: ", e.Party, e.Misbehaviour)
+	switch {
+	case e.Phase == "":
+		b.WriteString("not injected, the party did not take part in the corresponding phase")
+		return b.String()
+	case len(e.Detections) == 0:
+		fmt.Fprintf(&b, "injected in %s, undetected", e.Phase)
+		return b.String()
+	case !e.Detected():
+		fmt.Fprintf(&b, "injected in %s, undetected by the protocol", e.Phase)
+	default:
+		fmt.Fprintf(&b, "injected in %s", e.Phase)
+	}
+	for _, d := range e.Detections {
+		fmt.Fprintf(&b, "\n\t%s", d)
+	}
+	return b.String()
+}
+
+// Adversary makes chosen parties of a simulated run misbehave, and records which checks of the run detect it.
+//
+// The run asks Misbehaves at each opportunity to deviate, calls Inject when a party does, and Detect when one of its
+// checks fails. All methods are no-ops on a nil *Adversary, where every party is honest.
+type Adversary struct {
+	events []*AdversaryEvent
+	prng   sampling.PRNG
+}
+
+// ParseAdversary parses a list of misbehaving parties such as "3:pk-share,5:shamir+partial-decryption".
+func ParseAdversary(spec string) (*Adversary, error) {
+	prng, err := sampling.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+	a := &Adversary{prng: prng}
+	for _, entry := range strings.Split(spec, ",") {
+		party, list, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("cannot ParseAdversary: %q is not of the form party:misbehaviour", entry)
+		}
+		i, err := strconv.Atoi(party)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("cannot ParseAdversary: invalid party %q", party)
+		}
+		for _, name := range strings.Split(list, "+") {
+			m := Misbehaviour(name)
+			if !validMisbehaviour(m) {
+				return nil, fmt.Errorf("cannot ParseAdversary: unknown misbehaviour %q, expected one of %v", name, misbehaviours)
+			}
+			if !a.Misbehaves(i, m) {
+				a.events = append(a.events, &AdversaryEvent{Party: i, Misbehaviour: m})
+			}
+		}
+	}
+	sort.SliceStable(a.events, func(i, j int) bool { return a.events[i].Party < a.events[j].Party })
+	return a, nil
+}
+
+func validMisbehaviour(m Misbehaviour) bool {
+	for _, mi := range misbehaviours {
+		if m == mi {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Adversary) event(party int, m Misbehaviour) *AdversaryEvent {
+	if a == nil {
+		return nil
+	}
+	for _, e := range a.events {
+		if e.Party == party && e.Misbehaviour == m {
+			return e
+		}
+	}
+	return nil
+}
+
+// Misbehaves returns true if the party is chosen for the misbehaviour.
+func (a *Adversary) Misbehaves(party int, m Misbehaviour) bool {
+	return a.event(party, m) != nil
+}
+
+// Honest returns true if the party is not chosen for any misbehaviour.
+func (a *Adversary) Honest(party int) bool {
+	if a == nil {
+		return true
+	}
+	for _, e := range a.events {
+		if e.Party == party {
+			return false
+		}
+	}
+	return true
+}
+
+// Inject records that the party misbehaved in the given phase.
+func (a *Adversary) Inject(phase string, party int, m Misbehaviour) {
+	if e := a.event(party, m); e != nil && e.Phase == "" {
+		e.Phase = phase
+	}
+}
+
+// Corrupt adds a uniform polynomial to poly, which remains a well-formed element of the ring at its level.
+func (a *Adversary) Corrupt(params rlwe.ParameterProvider, poly ring.Poly) {
+	if a == nil {
+		return
+	}
+	ringQ := params.GetRLWEParameters().RingQ().AtLevel(poly.Level())
+	noise := ringQ.NewPoly()
+	ring.NewUniformSampler(a.prng, ringQ).Read(noise)
+	ringQ.Add(poly, noise, poly)
+}
+
+// Detect records that a check of the given phase failed. The failure is attributed to the injected misbehaviours of
+// the given kinds, or of every kind if none is given, of the given party, or of every party if party is -1.
+func (a *Adversary) Detect(phase, check string, oracle bool, party int, kinds ...Misbehaviour) {
+	if a == nil {
+		return
+	}
+	d := Detection{Phase: phase, Check: check, Oracle: oracle, Pinpointed: party >= 0}
+	for _, e := range a.events {
+		if e.Phase == "" || (party >= 0 && e.Party != party) || (len(kinds) > 0 && !containsMisbehaviour(kinds, e.Misbehaviour)) {
+			continue
+		}
+		if !containsDetection(e.Detections, d) {
+			e.Detections = append(e.Detections, d)
+		}
+	}
+}
+
+func containsMisbehaviour(kinds []Misbehaviour, m Misbehaviour) bool {
+	for _, k := range kinds {
+		if k == m {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDetection(ds []Detection, d Detection) bool {
+	for _, di := range ds {
+		if di == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Events returns the misbehaviours of the adversary, in the order of the parties.
+func (a *Adversary) Events() []AdversaryEvent {
+	if a == nil {
+		return nil
+	}
+	events := make([]AdversaryEvent, len(a.events))
+	for i, e := range a.events {
+		events[i] = *e
+	}
+	return events
+}
+
+// Report returns one paragraph per misbehaviour, telling which phases detected it.
+func (a *Adversary) Report() string {
+	var b strings.Builder
+	for _, e := range a.Events() {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// CheckPublicKeyGenShare returns an error if share is not -sk*crp + e with e below the bound of NoiseModel.
+// It needs the secret key of the party, so it is only available in simulation.
+func CheckPublicKeyGenShare(params rlwe.ParameterProvider, crp mhe.PublicKeyGenCRP, sk *rlwe.SecretKey, share mhe.PublicKeyGenShare) error {
+	p := params.GetRLWEParameters()
+	bound := noiseBound(p.LogN(), sigma(p)*sigma(p))
+	ringQ := p.RingQ()
+	buff := ringQ.NewPoly()
+	buff.Copy(share.Q)
+	ringQ.MulCoeffsMontgomeryThenAdd(crp.Q, sk.Value.Q, buff)
+	ringQ.INTT(buff, buff)
+	if noise := infNormLog2(ringQ, buff); noise > bound {
+		return fmt.Errorf("the error of the share is 2^%.2f, above the bound 2^%.2f", noise, bound)
+	}
+	return nil
+}
+
+// CheckPartialDecryption returns an error if ptpart is not the partial decryption of ct with sk.
+// It needs the secret key of the party, so it is only available in simulation.
+func CheckPartialDecryption(params heint.Parameters, sk *rlwe.SecretKey, ct *rlwe.Ciphertext, ptpart *rlwe.Plaintext) error {
+	want := heint.NewPlaintext(params, ct.Level())
+	rlwe.NewDecryptor(params, sk).Decryptpart(ct, want)
+	if !want.Value.Equal(&ptpart.Value) {
+		return fmt.Errorf("differs from the partial decryption recomputed with the party's key")
+	}
+	return nil
+}
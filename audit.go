@@ -0,0 +1,135 @@
+package examples
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Events of an audit log.
+const (
+	AuditPartyJoined        = "party-joined"        // a party generated its secret key and joined the session
+	AuditShareSent          = "share-sent"          // a party sent a share to another party
+	AuditShareReceived      = "share-received"      // a party received a share from another party
+	AuditCRPDerived         = "crp-derived"         // the common reference polynomial of a round was derived from its seed
+	AuditPartialDecryption  = "partial-decryption"  // a party contributed a partial decryption
+	AuditDecryptionReleased = "decryption-released" // a plaintext was released to a party
+	AuditPhase              = "phase"               // a phase boundary, logged with party -1
+)
+
+// AuditShamirShare is the kind of a Shamir share, which transcripts do not record; the other shares are logged with
+// the kinds of the transcript, e.g. TranscriptPublicKeyShare.
+const AuditShamirShare = "shamir-share"
+
+// the number of bytes of the SHA-256 digests used as session IDs and seed fingerprints
+const auditDigestBytes = 8
+
+// AuditLog writes every protocol event of a multiparty session as a JSON line, with the session ID, the phase and
+// the index of the acting party, so that it can be established afterwards who contributed to which decryption.
+//
+// All methods are no-ops on a nil *AuditLog, so that the logging can be left in place when it is disabled.
+// The log never contains key material: a CRS seed is identified by a fingerprint.
+type AuditLog struct {
+	logger *slog.Logger
+	closer io.Closer
+	phase  string
+}
+
+// NewAuditLog returns an AuditLog writing to w for the session identified by session.
+func NewAuditLog(w io.Writer, session string) *AuditLog {
+	return &AuditLog{logger: slog.New(slog.NewJSONHandler(w, nil)).With(slog.String("session", session))}
+}
+
+// CreateAuditLog creates the file at path and returns an AuditLog writing to it.
+func CreateAuditLog(path, session string) (*AuditLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a := NewAuditLog(f, session)
+	a.closer = f
+	return a, nil
+}
+
+// NewSessionID returns a session ID derived from seed, e.g. a seed of SeededRandomness.
+func NewSessionID(seed []byte) string {
+	h := sha256.Sum256(seed)
+	return hex.EncodeToString(h[:auditDigestBytes])
+}
+
+func (a *AuditLog) log(event string, party int, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("phase", a.phase), slog.Int("party", party)}, attrs...)
+	a.logger.LogAttrs(context.Background(), slog.LevelInfo, event, attrs...)
+}
+
+// Phase records a phase boundary; the following events belong to the phase.
+func (a *AuditLog) Phase(name string) {
+	if a == nil {
+		return
+	}
+	a.phase = name
+	a.log(AuditPhase, -1)
+}
+
+// PartyJoined records that the party joined the session.
+func (a *AuditLog) PartyJoined(party int) {
+	if a == nil {
+		return
+	}
+	a.log(AuditPartyJoined, party)
+}
+
+// ShareSent records that the party sent a share of the given kind to another party, or published it to an
+// aggregator outside the parties or to everyone if to is -1.
+func (a *AuditLog) ShareSent(round, kind string, party, to int) {
+	if a == nil {
+		return
+	}
+	a.log(AuditShareSent, party, slog.String("round", round), slog.String("kind", kind), slog.Int("to", to))
+}
+
+// ShareReceived records that the party, or an aggregator outside the parties if party is -1, received a share of the
+// given kind from another party.
+func (a *AuditLog) ShareReceived(round, kind string, party, from int) {
+	if a == nil {
+		return
+	}
+	a.log(AuditShareReceived, party, slog.String("round", round), slog.String("kind", kind), slog.Int("from", from))
+}
+
+// CRPDerived records that the CRP of the round was derived from seed by the party, or publicly if party is -1.
+func (a *AuditLog) CRPDerived(round string, party int, seed []byte) {
+	if a == nil {
+		return
+	}
+	h := sha256.Sum256(seed)
+	a.log(AuditCRPDerived, party, slog.String("round", round), slog.String("seed", hex.EncodeToString(h[:auditDigestBytes])))
+}
+
+// PartialDecryption records that the party contributed a partial decryption to the round.
+func (a *AuditLog) PartialDecryption(round string, party int) {
+	if a == nil {
+		return
+	}
+	a.log(AuditPartialDecryption, party, slog.String("round", round))
+}
+
+// DecryptionReleased records that the plaintext of the round was released to the party, or to every party if party is
+// -1, from the partial decryptions of the contributors.
+func (a *AuditLog) DecryptionReleased(round string, party int, contributors []int) {
+	if a == nil {
+		return
+	}
+	a.log(AuditDecryptionReleased, party, slog.String("round", round), slog.Any("contributors", contributors))
+}
+
+// Close closes the file of an AuditLog created by CreateAuditLog.
+func (a *AuditLog) Close() error {
+	if a == nil || a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
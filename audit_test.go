@@ -0,0 +1,42 @@
+package examples
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf, NewSessionID([]byte("audit")))
+	audit.Phase("Decrypt Phase")
+	audit.PartialDecryption("decrypt/ctadd", 1)
+	audit.ShareSent("decrypt/ctadd", TranscriptPartialDecryption, 1, 0)
+	audit.ShareReceived("decrypt/ctadd", TranscriptPartialDecryption, 0, 1)
+	audit.DecryptionReleased("decrypt/ctadd", 0, []int{0, 1})
+	(*AuditLog)(nil).PartyJoined(0)
+
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("%q is not a JSON line: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+
+	want := []string{AuditPhase, AuditPartialDecryption, AuditShareSent, AuditShareReceived, AuditDecryptionReleased}
+	if len(events) != len(want) {
+		t.Fatalf("%d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e["msg"] != want[i] || e["session"] != NewSessionID([]byte("audit")) || e["phase"] != "Decrypt Phase" {
+			t.Errorf("event %d: %v, want %s in session %s and phase Decrypt Phase", i, e, want[i], NewSessionID([]byte("audit")))
+		}
+	}
+	if released := events[4]; released["party"] != 0.0 || len(released["contributors"].([]interface{})) != 2 {
+		t.Errorf("release: %v, want party 0 with 2 contributors", released)
+	}
+}
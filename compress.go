@@ -0,0 +1,102 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// Seed compression replaces the uniformly random component of a message by the seed of the PRNG that sampled it, and
+// the receiver expands the seed back into the component. It roughly halves the size of the public keys and of the
+// secret-key encryptions that the parties send each other without a common reference string.
+
+// CompressedPublicKey is a public key (b, a) with a = SampleCRP(Seed), sent as the share b and the seed.
+type CompressedPublicKey struct {
+	Share mhe.PublicKeyGenShare
+	Seed  []byte
+}
+
+// BinarySize returns the number of bytes sent for cpk.
+func (cpk CompressedPublicKey) BinarySize() int {
+	return cpk.Share.BinarySize() + len(cpk.Seed)
+}
+
+// Expand samples a again from the seed and writes the full public key to pk.
+func (cpk CompressedPublicKey) Expand(ckg mhe.PublicKeyGenProtocol, pk *rlwe.PublicKey) error {
+	prng, err := sampling.NewKeyedPRNG(cpk.Seed)
+	if err != nil {
+		return fmt.Errorf("cannot Expand: %w", err)
+	}
+	ckg.GenPublicKey(cpk.Share, ckg.SampleCRP(prng), pk)
+	return nil
+}
+
+// CompressedCiphertext is a secret-key encryption (c0, c1) with c1 sampled uniformly from Seed, sent as c0 and the
+// seed.
+type CompressedCiphertext struct {
+	*rlwe.MetaData
+	C0   ring.Poly
+	Seed []byte
+}
+
+// BinarySize returns the number of bytes sent for cct.
+func (cct CompressedCiphertext) BinarySize() int {
+	return cct.C0.BinarySize() + len(cct.Seed)
+}
+
+// EncryptSeeded encrypts pt under sk with c1 sampled from the seed, and returns the compressed ciphertext. It checks
+// that the seed reproduces c1 before returning, since only the seed is sent.
+func EncryptSeeded(params heint.Parameters, sk *rlwe.SecretKey, pt *rlwe.Plaintext, seed []byte) (cct CompressedCiphertext, err error) {
+	prng, err := sampling.NewKeyedPRNG(seed)
+	if err != nil {
+		return cct, fmt.Errorf("cannot EncryptSeeded: %w", err)
+	}
+	ct := heint.NewCiphertext(params, 1, pt.Level())
+	if err = rlwe.NewEncryptor(params, sk).WithPRNG(prng).Encrypt(pt, ct); err != nil {
+		return cct, fmt.Errorf("cannot EncryptSeeded: %w", err)
+	}
+	cct = CompressedCiphertext{MetaData: ct.MetaData, C0: ct.Value[0], Seed: seed}
+
+	ctexp := heint.NewCiphertext(params, 1, ct.Level())
+	if err = cct.Expand(params, ctexp); err != nil {
+		return cct, fmt.Errorf("cannot EncryptSeeded: %w", err)
+	}
+	if !ctexp.Value[1].Equal(&ct.Value[1]) {
+		return cct, fmt.Errorf("cannot EncryptSeeded: the seed does not reproduce c1")
+	}
+	return cct, nil
+}
+
+// Expand samples c1 again from the seed and writes the full ciphertext to ct, which shares c0 with cct.
+func (cct CompressedCiphertext) Expand(params heint.Parameters, ct *rlwe.Ciphertext) error {
+	prng, err := sampling.NewKeyedPRNG(cct.Seed)
+	if err != nil {
+		return fmt.Errorf("cannot Expand: %w", err)
+	}
+	level := cct.C0.Level()
+	*ct.MetaData = *cct.MetaData
+	ct.Value[0] = cct.C0
+	ringQ := params.RingQ().AtLevel(level)
+	ring.NewUniformSampler(prng, ringQ).Read(ct.Value[1])
+	if !ct.IsNTT {
+		ringQ.INTT(ct.Value[1], ct.Value[1])
+	}
+	return nil
+}
+
+// Traffic counts the bytes sent in a phase, in full and seed-compressed representation.
+type Traffic struct {
+	Full       int
+	Compressed int
+}
+
+func (t Traffic) String() string {
+	if t.Full == 0 {
+		return "0 B"
+	}
+	return fmt.Sprintf("%d B, compressed %d B (saved %.1f%%)", t.Full, t.Compressed, 100*float64(t.Full-t.Compressed)/float64(t.Full))
+}
@@ -0,0 +1,77 @@
+package examples
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+func TestCompress(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk := rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	seed := make([]byte, 32)
+
+	// the public key expanded from the share and the seed is the public key generated from the same CRP
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	prng, err := sampling.NewKeyedPRNG(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crp := ckg.SampleCRP(prng)
+	share := ckg.AllocateShare()
+	ckg.GenShare(sk, crp, &share)
+	want := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(share, crp, want)
+	cpk := CompressedPublicKey{Share: share, Seed: seed}
+	pk := rlwe.NewPublicKey(params)
+	if err := cpk.Expand(ckg, pk); err != nil {
+		t.Fatal(err)
+	}
+	got, err := pk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := want.MarshalBinary(); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("expanded public key differs from the generated one: %v", err)
+	}
+	if cpk.BinarySize() >= pk.BinarySize() {
+		t.Errorf("compressed public key of %d bytes, full of %d bytes", cpk.BinarySize(), pk.BinarySize())
+	}
+
+	// the expanded ciphertext decrypts to the plaintext
+	encoder := heint.NewEncoder(params)
+	values := make([]uint64, params.MaxSlots())
+	for j := range values {
+		values[j] = uint64(j) % params.PlaintextModulus()
+	}
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(values, pt); err != nil {
+		t.Fatal(err)
+	}
+	cct, err := EncryptSeeded(params, sk, pt, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+	if err := cct.Expand(params, ct); err != nil {
+		t.Fatal(err)
+	}
+	if cct.BinarySize() >= ct.BinarySize() {
+		t.Errorf("compressed ciphertext of %d bytes, full of %d bytes", cct.BinarySize(), ct.BinarySize())
+	}
+	res := make([]uint64, params.MaxSlots())
+	if err := encoder.Decode(rlwe.NewDecryptor(params, sk).DecryptNew(ct), res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, values) {
+		t.Errorf("decrypted %v..., want %v...", res[:8], values[:8])
+	}
+}
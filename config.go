@@ -0,0 +1,261 @@
+package examples
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/ring"
+)
+
+const (
+	// SchemeHEInt is the scheme of a ParametersConfig holding a `heint` literal.
+	SchemeHEInt = "heint"
+	// SchemeHEFloat is the scheme of a ParametersConfig holding a `hefloat` literal.
+	SchemeHEFloat = "hefloat"
+)
+
+// ParametersConfig is the serializable form of a `heint` or `hefloat` parameter literal, read from and written to
+// JSON or YAML files so that parties can agree on parameters out of band.
+type ParametersConfig struct {
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Scheme string `json:"scheme" yaml:"scheme"`
+
+	LogN int   `json:"logN" yaml:"logN"`
+	LogQ []int `json:"logQ" yaml:"logQ,flow"`
+	LogP []int `json:"logP" yaml:"logP,flow"`
+
+	// PlaintextModulus is only used by `heint`.
+	PlaintextModulus uint64 `json:"plaintextModulus,omitempty" yaml:"plaintextModulus,omitempty"`
+
+	// LogDefaultScale and RingType are only used by `hefloat`.
+	LogDefaultScale int    `json:"logDefaultScale,omitempty" yaml:"logDefaultScale,omitempty"`
+	RingType        string `json:"ringType,omitempty" yaml:"ringType,omitempty"`
+}
+
+// HEIntConfig returns the configuration of a `heint` literal.
+func HEIntConfig(name string, pl heint.ParametersLiteral) ParametersConfig {
+	return ParametersConfig{
+		Name:             name,
+		Scheme:           SchemeHEInt,
+		LogN:             pl.LogN,
+		LogQ:             pl.LogQ,
+		LogP:             pl.LogP,
+		PlaintextModulus: pl.PlaintextModulus,
+	}
+}
+
+// HEFloatConfig returns the configuration of a `hefloat` literal.
+func HEFloatConfig(name string, pl hefloat.ParametersLiteral) ParametersConfig {
+	return ParametersConfig{
+		Name:            name,
+		Scheme:          SchemeHEFloat,
+		LogN:            pl.LogN,
+		LogQ:            pl.LogQ,
+		LogP:            pl.LogP,
+		LogDefaultScale: pl.LogDefaultScale,
+		RingType:        pl.RingType.String(),
+	}
+}
+
+// HEIntLiteral returns the `heint` literal of the configuration.
+func (c ParametersConfig) HEIntLiteral() (pl heint.ParametersLiteral, err error) {
+	if c.Scheme != SchemeHEInt {
+		return pl, fmt.Errorf("cannot HEIntLiteral: scheme is %q", c.Scheme)
+	}
+	if c.LogDefaultScale != 0 || (c.RingType != "" && c.RingType != ring.Standard.String()) {
+		return pl, fmt.Errorf("cannot HEIntLiteral: logDefaultScale and ringType are not supported by %s", SchemeHEInt)
+	}
+	return heint.ParametersLiteral{
+		LogN:             c.LogN,
+		LogQ:             c.LogQ,
+		LogP:             c.LogP,
+		PlaintextModulus: c.PlaintextModulus,
+	}, nil
+}
+
+// HEFloatLiteral returns the `hefloat` literal of the configuration.
+func (c ParametersConfig) HEFloatLiteral() (pl hefloat.ParametersLiteral, err error) {
+	if c.Scheme != SchemeHEFloat {
+		return pl, fmt.Errorf("cannot HEFloatLiteral: scheme is %q", c.Scheme)
+	}
+	if c.PlaintextModulus != 0 {
+		return pl, fmt.Errorf("cannot HEFloatLiteral: plaintextModulus is not supported by %s", SchemeHEFloat)
+	}
+	ringType, err := parseRingType(c.RingType)
+	if err != nil {
+		return pl, fmt.Errorf("cannot HEFloatLiteral: %w", err)
+	}
+	return hefloat.ParametersLiteral{
+		LogN:            c.LogN,
+		LogQ:            c.LogQ,
+		LogP:            c.LogP,
+		LogDefaultScale: c.LogDefaultScale,
+		RingType:        ringType,
+	}, nil
+}
+
+func parseRingType(s string) (ring.Type, error) {
+	switch s {
+	case "", ring.Standard.String():
+		return ring.Standard, nil
+	case ring.ConjugateInvariant.String():
+		return ring.ConjugateInvariant, nil
+	}
+	return ring.Standard, fmt.Errorf("invalid ring type %q", s)
+}
+
+// Fingerprint instantiates the parameters of the configuration and returns their fingerprint, see HEIntFingerprint
+// and HEFloatFingerprint.
+func (c ParametersConfig) Fingerprint() (string, error) {
+	switch c.Scheme {
+	case SchemeHEInt:
+		pl, err := c.HEIntLiteral()
+		if err != nil {
+			return "", err
+		}
+		params, err := heint.NewParametersFromLiteral(pl)
+		if err != nil {
+			return "", err
+		}
+		return HEIntFingerprint(params), nil
+	case SchemeHEFloat:
+		pl, err := c.HEFloatLiteral()
+		if err != nil {
+			return "", err
+		}
+		params, err := hefloat.NewParametersFromLiteral(pl)
+		if err != nil {
+			return "", err
+		}
+		return HEFloatFingerprint(params), nil
+	}
+	return "", fmt.Errorf("cannot Fingerprint: invalid scheme %q", c.Scheme)
+}
+
+// HEIntFingerprint returns the hex-encoded SHA-256 of a canonical description of params: the scheme, the ring,
+// the primes of Q and P, the plaintext modulus and the secret and error distributions. Two parties that obtain the
+// same fingerprint use the same parameters, whether they were compiled in or loaded from a configuration.
+func HEIntFingerprint(params heint.Parameters) string {
+	return fingerprint(SchemeHEInt, *params.GetRLWEParameters(), fmt.Sprintf("t=%d", params.PlaintextModulus()))
+}
+
+// HEFloatFingerprint returns the fingerprint of `hefloat` parameters, see HEIntFingerprint. It covers the default
+// scale instead of the plaintext modulus.
+func HEFloatFingerprint(params hefloat.Parameters) string {
+	return fingerprint(SchemeHEFloat, *params.GetRLWEParameters(), fmt.Sprintf("logDefaultScale=%d", params.LogDefaultScale()))
+}
+
+func fingerprint(scheme string, params rlwe.Parameters, plaintext string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "scheme=%s;logN=%d;ring=%s;q=%v;p=%v;%s;xs=%v;xe=%v",
+		scheme, params.LogN(), params.RingType(), params.Q(), params.P(), plaintext, params.Xs(), params.Xe())
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadParametersConfig reads a configuration from a JSON (.json) or YAML (.yaml, .yml) file. Unknown fields are rejected.
+func LoadParametersConfig(path string) (c ParametersConfig, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&c)
+	default:
+		return c, fmt.Errorf("cannot LoadParametersConfig: unknown format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return c, fmt.Errorf("cannot LoadParametersConfig: %w", err)
+	}
+	return
+}
+
+// Save writes the configuration to a JSON (.json) or YAML (.yaml, .yml) file.
+func (c ParametersConfig) Save(path string) (err error) {
+	var data []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if data, err = json.MarshalIndent(c, "", "\t"); err != nil {
+			return
+		}
+		data = append(data, '\n')
+	case ".yaml", ".yml":
+		if data, err = yaml.Marshal(c); err != nil {
+			return
+		}
+	default:
+		return fmt.Errorf("cannot Save: unknown format %q", filepath.Ext(path))
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// NamedParametersConfig returns the configuration of the set of params.go with the given name,
+// e.g. "HEIntParamsN14QP438".
+func NamedParametersConfig(name string) (ParametersConfig, bool) {
+	if pl, ok := namedHEIntParams[name]; ok {
+		return HEIntConfig(name, pl), true
+	}
+	if pl, ok := namedHEFloatParams[name]; ok {
+		return HEFloatConfig(name, pl), true
+	}
+	return ParametersConfig{}, false
+}
+
+// ParametersNames returns the names of the sets of params.go, in lexicographic order.
+func ParametersNames() (names []string) {
+	for name := range namedHEIntParams {
+		names = append(names, name)
+	}
+	for name := range namedHEFloatParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+var namedHEIntParams = map[string]heint.ParametersLiteral{
+	"HEIntParamsN12QP109":               HEIntParamsN12QP109,
+	"HEIntParamsN13QP218":               HEIntParamsN13QP218,
+	"HEIntParamsN14QP438":               HEIntParamsN14QP438,
+	"HEIntParamsN15QP880":               HEIntParamsN15QP880,
+	"HEIntScaleInvariantParamsN12QP109": HEIntScaleInvariantParamsN12QP109,
+	"HEIntScaleInvariantParamsN13QP218": HEIntScaleInvariantParamsN13QP218,
+	"HEIntScaleInvariantParamsN14QP438": HEIntScaleInvariantParamsN14QP438,
+	"HEIntScaleInvariantParamsN15QP880": HEIntScaleInvariantParamsN15QP880,
+	"HEIntMultiparty10ParamsN14QP151":   HEIntMultiparty10ParamsN14QP151,
+	"HEIntMultiparty100ParamsN14QP153":  HEIntMultiparty100ParamsN14QP153,
+	"HEIntMultiparty1000ParamsN14QP159": HEIntMultiparty1000ParamsN14QP159,
+}
+
+var namedHEFloatParams = map[string]hefloat.ParametersLiteral{
+	"HEFloatComplexParamsN12QP109":   HEFloatComplexParamsN12QP109,
+	"HEFloatComplexParamsN13QP218":   HEFloatComplexParamsN13QP218,
+	"HEFloatComplexParamsN14QP438":   HEFloatComplexParamsN14QP438,
+	"HEFloatComplexParamsN15QP881":   HEFloatComplexParamsN15QP881,
+	"HEFloatComplexParamsPN16QP1761": HEFloatComplexParamsPN16QP1761,
+	"HEFloatRealParamsN12QP109":      HEFloatRealParamsN12QP109,
+	"HEFloatRealParamsN13QP218":      HEFloatRealParamsN13QP218,
+	"HEFloatRealParamsN14QP438":      HEFloatRealParamsN14QP438,
+	"HEFloatRealParamsN15QP881":      HEFloatRealParamsN15QP881,
+	"HEFloatRealParamsPN16QP1761":    HEFloatRealParamsPN16QP1761,
+}
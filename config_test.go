@@ -0,0 +1,36 @@
+package examples
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParametersConfig(t *testing.T) {
+	for _, name := range ParametersNames() {
+		config, ok := NamedParametersConfig(name)
+		if !ok {
+			t.Fatalf("%s: not found", name)
+		}
+		want, err := config.Fingerprint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ext := range []string{".json", ".yaml"} {
+			path := filepath.Join(t.TempDir(), name+ext)
+			if err := config.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadParametersConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			have, err := loaded.Fingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != want {
+				t.Errorf("%s%s: fingerprint %s after loading, %s before saving", name, ext, have, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package examples
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/ring/ringqp"
+)
+
+// The Decode functions decode the protocol messages a party receives from the other parties.
+//
+// The UnmarshalBinary methods of Lattigo restore whatever shape the data describes: a share with a wrong number of
+// moduli or of coefficients, or with coefficients that are not reduced, decodes without error and only makes the
+// aggregation or the decryption panic later, or silently corrupt its result. The Decode functions check every
+// decoded polynomial against the parameters, reject data longer than the largest valid message, and turn a panic of
+// the decoding into an error, so that a malformed message never crashes the receiving party.
+
+// DecodeShamirShare decodes a Shamir secret share sent by another party.
+func DecodeShamirShare(params rlwe.ParameterProvider, data []byte) (share mhe.ShamirSecretShare, err error) {
+	p := params.GetRLWEParameters()
+	if err = decode(data, p.RingQP().NewPoly().BinarySize(), &share); err != nil {
+		return share, fmt.Errorf("cannot DecodeShamirShare: %w", err)
+	}
+	if err = checkPolyQP(p, share.Poly); err != nil {
+		return share, fmt.Errorf("cannot DecodeShamirShare: %w", err)
+	}
+	return
+}
+
+// DecodePublicKeyGenShare decodes a party's share of a public-key round.
+func DecodePublicKeyGenShare(params rlwe.ParameterProvider, data []byte) (share mhe.PublicKeyGenShare, err error) {
+	p := params.GetRLWEParameters()
+	if err = decode(data, p.RingQP().NewPoly().BinarySize(), &share); err != nil {
+		return share, fmt.Errorf("cannot DecodePublicKeyGenShare: %w", err)
+	}
+	if err = checkPolyQP(p, share.Poly); err != nil {
+		return share, fmt.Errorf("cannot DecodePublicKeyGenShare: %w", err)
+	}
+	return
+}
+
+// DecodePublicKey decodes a collective public key.
+func DecodePublicKey(params rlwe.ParameterProvider, data []byte) (pk *rlwe.PublicKey, err error) {
+	p := params.GetRLWEParameters()
+	pk = new(rlwe.PublicKey)
+	if err = decode(data, rlwe.NewPublicKey(p).BinarySize(), pk); err != nil {
+		return nil, fmt.Errorf("cannot DecodePublicKey: %w", err)
+	}
+	if len(pk.Value) != 2 {
+		return nil, fmt.Errorf("cannot DecodePublicKey: %d components, expected 2", len(pk.Value))
+	}
+	for i := range pk.Value {
+		if err = checkPolyQP(p, pk.Value[i]); err != nil {
+			return nil, fmt.Errorf("cannot DecodePublicKey: %w", err)
+		}
+	}
+	return
+}
+
+// DecodeCiphertext decodes a ciphertext (c0, c1) at any level.
+func DecodeCiphertext(params heint.Parameters, data []byte) (ct *rlwe.Ciphertext, err error) {
+	ct = new(rlwe.Ciphertext)
+	if err = decode(data, heint.NewCiphertext(params, 1, params.MaxLevel()).BinarySize(), ct); err != nil {
+		return nil, fmt.Errorf("cannot DecodeCiphertext: %w", err)
+	}
+	if ct.MetaData == nil || ct.Degree() != 1 {
+		return nil, fmt.Errorf("cannot DecodeCiphertext: expected a ciphertext of degree 1")
+	}
+	if ct.IsNTT != params.NTTFlag() {
+		return nil, fmt.Errorf("cannot DecodeCiphertext: IsNTT is %v, the parameters expect %v", ct.IsNTT, params.NTTFlag())
+	}
+	level := ct.Value[0].Level()
+	if level < 0 || level > params.MaxLevel() {
+		return nil, fmt.Errorf("cannot DecodeCiphertext: %d moduli, the parameters have %d", level+1, params.MaxLevel()+1)
+	}
+	for i := range ct.Value {
+		if err = checkPoly(params.RingQ(), level, ct.Value[i], fmt.Sprintf("c%d", i)); err != nil {
+			return nil, fmt.Errorf("cannot DecodeCiphertext: %w", err)
+		}
+	}
+	return
+}
+
+// DecodePartialDecryption decodes a party's partial decryption of a ciphertext at the given level, or an aggregate of
+// partial decryptions, which must be at the same level to be summed with the others.
+func DecodePartialDecryption(params heint.Parameters, level int, data []byte) (pt *rlwe.Plaintext, err error) {
+	if level < 0 || level > params.MaxLevel() {
+		return nil, fmt.Errorf("cannot DecodePartialDecryption: invalid level %d", level)
+	}
+	pt = new(rlwe.Plaintext)
+	if err = decode(data, heint.NewPlaintext(params, params.MaxLevel()).BinarySize(), pt); err != nil {
+		return nil, fmt.Errorf("cannot DecodePartialDecryption: %w", err)
+	}
+	if pt.MetaData == nil {
+		return nil, fmt.Errorf("cannot DecodePartialDecryption: missing metadata")
+	}
+	if err = checkPoly(params.RingQ(), level, pt.Value, "plaintext"); err != nil {
+		return nil, fmt.Errorf("cannot DecodePartialDecryption: %w", err)
+	}
+	return
+}
+
+// DecodeKeySwitchShare decodes a party's share of a key switch of a ciphertext at the given level, such as its smudged
+// share of a threshold decryption, which switches to the zero key.
+func DecodeKeySwitchShare(params heint.Parameters, level int, data []byte) (share mhe.KeySwitchShare, err error) {
+	if level < 0 || level > params.MaxLevel() {
+		return share, fmt.Errorf("cannot DecodeKeySwitchShare: invalid level %d", level)
+	}
+	if err = decode(data, params.RingQ().NewPoly().BinarySize(), &share); err != nil {
+		return share, fmt.Errorf("cannot DecodeKeySwitchShare: %w", err)
+	}
+	if err = checkPoly(params.RingQ(), level, share.Value, "share"); err != nil {
+		return share, fmt.Errorf("cannot DecodeKeySwitchShare: %w", err)
+	}
+	return
+}
+
+// MultiKeyCiphertext is the transmitted form of an extended multi-key ciphertext (c0, c_1, ..., c_N), sparse in the
+// parties: C maps the index i of a party to its mask component c_i, and the missing components are zero.
+//
+// It is encoded as the number of components and their party indices in increasing order, followed by the metadata,
+// c0 and the components, each prefixed with its length.
+type MultiKeyCiphertext struct {
+	*rlwe.MetaData
+	C0 ring.Poly
+	C  map[int]ring.Poly
+}
+
+// MarshalBinary encodes ct, which must have metadata.
+func (ct MultiKeyCiphertext) MarshalBinary() ([]byte, error) {
+	if ct.MetaData == nil {
+		return nil, fmt.Errorf("cannot MarshalBinary: missing metadata")
+	}
+	idx := make([]int, 0, len(ct.C))
+	for i := range ct.C {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(idx)))
+	for _, i := range idx {
+		data = binary.BigEndian.AppendUint32(data, uint32(i))
+	}
+
+	frames := make([]encoding.BinaryMarshaler, 0, len(idx)+2)
+	frames = append(frames, ct.MetaData, &ct.C0)
+	for _, i := range idx {
+		ci := ct.C[i]
+		frames = append(frames, &ci)
+	}
+	for _, f := range frames {
+		frame, err := f.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = binary.BigEndian.AppendUint64(data, uint64(len(frame)))
+		data = append(data, frame...)
+	}
+	return data, nil
+}
+
+// DecodeMultiKeyCiphertext decodes an extended multi-key ciphertext of a run with the given number of parties.
+// The party indices must be distinct and below parties, and all the components must be at the level of c0.
+func DecodeMultiKeyCiphertext(params heint.Parameters, parties int, data []byte) (ct MultiKeyCiphertext, err error) {
+	if ct, err = decodeMultiKeyCiphertext(params, parties, data); err != nil {
+		return ct, fmt.Errorf("cannot DecodeMultiKeyCiphertext: %w", err)
+	}
+	return
+}
+
+func decodeMultiKeyCiphertext(params heint.Parameters, parties int, data []byte) (ct MultiKeyCiphertext, err error) {
+	r := frameReader{data: data}
+
+	n, err := r.uint32()
+	if err != nil {
+		return ct, err
+	}
+	if int64(n) > int64(parties) {
+		return ct, fmt.Errorf("%d components, the run has %d parties", n, parties)
+	}
+	idx := make([]int, n)
+	for k := range idx {
+		i, err := r.uint32()
+		if err != nil {
+			return ct, err
+		}
+		idx[k] = int(i)
+		if idx[k] >= parties {
+			return ct, fmt.Errorf("component of party %d, the run has %d parties", idx[k], parties)
+		}
+		if k > 0 && idx[k] <= idx[k-1] {
+			return ct, fmt.Errorf("party indices are not strictly increasing")
+		}
+	}
+
+	ringQ := params.RingQ()
+	maxSize := ringQ.NewPoly().BinarySize()
+
+	ct.MetaData = new(rlwe.MetaData)
+	if err = r.frame(ct.MetaData, maxMetaDataSize); err != nil {
+		return ct, fmt.Errorf("metadata: %w", err)
+	}
+	if ct.IsNTT != params.NTTFlag() {
+		return ct, fmt.Errorf("IsNTT is %v, the parameters expect %v", ct.IsNTT, params.NTTFlag())
+	}
+
+	if err = r.frame(&ct.C0, maxSize); err != nil {
+		return ct, fmt.Errorf("c0: %w", err)
+	}
+	level := ct.C0.Level()
+	if level < 0 || level > params.MaxLevel() {
+		return ct, fmt.Errorf("c0 has %d moduli, the parameters have %d", level+1, params.MaxLevel()+1)
+	}
+	if err = checkPoly(ringQ, level, ct.C0, "c0"); err != nil {
+		return ct, err
+	}
+
+	ct.C = make(map[int]ring.Poly, n)
+	for _, i := range idx {
+		var ci ring.Poly
+		if err = r.frame(&ci, maxSize); err != nil {
+			return ct, fmt.Errorf("c_%d: %w", i, err)
+		}
+		if err = checkPoly(ringQ, level, ci, fmt.Sprintf("c_%d", i)); err != nil {
+			return ct, err
+		}
+		ct.C[i] = ci
+	}
+
+	if len(r.data) != 0 {
+		return ct, fmt.Errorf("%d trailing bytes", len(r.data))
+	}
+	return
+}
+
+// maxMetaDataSize bounds the size of the encoded metadata of a MultiKeyCiphertext, which is a few dozen bytes.
+const maxMetaDataSize = 1 << 10
+
+// frameReader reads the length-prefixed frames of a MultiKeyCiphertext.
+type frameReader struct {
+	data []byte
+}
+
+func (r *frameReader) uint32() (uint32, error) {
+	if len(r.data) < 4 {
+		return 0, fmt.Errorf("unexpected end of data")
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v, nil
+}
+
+// frame decodes the next frame into v; frames longer than maxSize are rejected before decoding.
+func (r *frameReader) frame(v encoding.BinaryUnmarshaler, maxSize int) error {
+	if len(r.data) < 8 {
+		return fmt.Errorf("unexpected end of data")
+	}
+	size := binary.BigEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	if size > uint64(len(r.data)) {
+		return fmt.Errorf("frame of %d bytes, %d left", size, len(r.data))
+	}
+	frame := r.data[:size]
+	r.data = r.data[size:]
+	return decode(frame, maxSize, v)
+}
+
+// decode unmarshals data into v, rejecting data longer than maxSize and recovering from a panic of the decoding.
+func decode(data []byte, maxSize int, v encoding.BinaryUnmarshaler) (err error) {
+	if len(data) > maxSize {
+		return fmt.Errorf("%d bytes, expected at most %d", len(data), maxSize)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed data: %v", r)
+		}
+	}()
+	return v.UnmarshalBinary(data)
+}
+
+// checkPolyQP checks that poly has the shape of a polynomial of RingQP at the maximum levels, with reduced coefficients.
+func checkPolyQP(params *rlwe.Parameters, poly ringqp.Poly) error {
+	if err := checkPoly(params.RingQ(), params.MaxLevelQ(), poly.Q, "Q part"); err != nil {
+		return err
+	}
+	if params.RingP() == nil {
+		if poly.P.Level() >= 0 {
+			return fmt.Errorf("P part given but the parameters have no P")
+		}
+		return nil
+	}
+	return checkPoly(params.RingP(), params.MaxLevelP(), poly.P, "P part")
+}
+
+// checkPoly checks that poly has level+1 moduli of ringQ, N coefficients per modulus, and that every coefficient
+// is reduced modulo its modulus.
+func checkPoly(ringQ *ring.Ring, level int, poly ring.Poly, what string) error {
+	if poly.Level() != level {
+		return fmt.Errorf("%s has %d moduli, expected %d", what, poly.Level()+1, level+1)
+	}
+	moduli := ringQ.ModuliChain()
+	for i, coeffs := range poly.Coeffs {
+		if len(coeffs) != ringQ.N() {
+			return fmt.Errorf("%s has %d coefficients modulo q_%d, expected %d", what, len(coeffs), i, ringQ.N())
+		}
+		for _, c := range coeffs {
+			if c >= moduli[i] {
+				return fmt.Errorf("%s has a coefficient %d out of range modulo q_%d = %d", what, c, i, moduli[i])
+			}
+		}
+	}
+	return nil
+}
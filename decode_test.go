@@ -0,0 +1,227 @@
+package examples
+
+import (
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+func TestDecodeMalformed(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q0 := params.Q()[0]
+
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	marshal := func(v interface{ MarshalBinary() ([]byte, error) }) []byte {
+		data, err := v.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	share := func(corrupt func(*mhe.PublicKeyGenShare)) []byte {
+		s := ckg.AllocateShare()
+		corrupt(&s)
+		return marshal(&s)
+	}
+	ciphertext := func(corrupt func(*rlwe.Ciphertext)) []byte {
+		ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+		corrupt(ct)
+		return marshal(ct)
+	}
+	valid := share(func(*mhe.PublicKeyGenShare) {})
+
+	for _, tc := range []struct {
+		name   string
+		decode func() error
+	}{
+		{"share/empty", func() error { _, err := DecodePublicKeyGenShare(params, nil); return err }},
+		{"share/truncated", func() error { _, err := DecodePublicKeyGenShare(params, valid[:len(valid)/2]); return err }},
+		{"share/too-long", func() error { _, err := DecodePublicKeyGenShare(params, append(valid, 0)); return err }},
+		{"share/moduli", func() error {
+			_, err := DecodePublicKeyGenShare(params, share(func(s *mhe.PublicKeyGenShare) { s.Q.Resize(0) }))
+			return err
+		}},
+		{"share/coefficient", func() error {
+			_, err := DecodePublicKeyGenShare(params, share(func(s *mhe.PublicKeyGenShare) { s.Q.Coeffs[0][0] = q0 }))
+			return err
+		}},
+		{"shamir/coefficient", func() error {
+			_, err := DecodeShamirShare(params, share(func(s *mhe.PublicKeyGenShare) { s.Q.Coeffs[1][7] = ^uint64(0) }))
+			return err
+		}},
+		{"ciphertext/degree", func() error {
+			_, err := DecodeCiphertext(params, marshal(heint.NewCiphertext(params, 2, params.MaxLevel())))
+			return err
+		}},
+		{"ciphertext/coefficient", func() error {
+			_, err := DecodeCiphertext(params, ciphertext(func(ct *rlwe.Ciphertext) { ct.Value[1].Coeffs[0][0] = q0 }))
+			return err
+		}},
+		{"partial-decryption/level", func() error {
+			_, err := DecodePartialDecryption(params, params.MaxLevel(), marshal(heint.NewPlaintext(params, 0)))
+			return err
+		}},
+		{"key-switch/level", func() error {
+			share := mhe.KeySwitchShare{Value: params.RingQ().AtLevel(0).NewPoly()}
+			_, err := DecodeKeySwitchShare(params, params.MaxLevel(), marshal(&share))
+			return err
+		}},
+		{"multi-key/party", func() error {
+			ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+			data := marshal(MultiKeyCiphertext{MetaData: ct.MetaData, C0: ct.Value[0], C: map[int]ring.Poly{4: ct.Value[1]}})
+			_, err := DecodeMultiKeyCiphertext(params, 4, data)
+			return err
+		}},
+		{"multi-key/level", func() error {
+			ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+			c1 := ct.Value[1].CopyNew()
+			c1.Resize(0)
+			data := marshal(MultiKeyCiphertext{MetaData: ct.MetaData, C0: ct.Value[0], C: map[int]ring.Poly{0: *c1}})
+			_, err := DecodeMultiKeyCiphertext(params, 4, data)
+			return err
+		}},
+		{"multi-key/metadata", func() error {
+			ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+			_, err := MultiKeyCiphertext{C0: ct.Value[0], C: map[int]ring.Poly{0: ct.Value[1]}}.MarshalBinary()
+			return err
+		}},
+	} {
+		if err := tc.decode(); err == nil {
+			t.Errorf("%s: decoded without error", tc.name)
+		}
+	}
+}
+
+// fuzzParams are the parameters of the fuzz targets, small so that a decoding is fast.
+func fuzzParams(f *testing.F) heint.Parameters {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		f.Fatal(err)
+	}
+	return params
+}
+
+// fuzzSeeds adds data to the corpus together with a truncated and a bit-flipped copy.
+func fuzzSeeds(f *testing.F, v interface{ MarshalBinary() ([]byte, error) }) {
+	data, err := v.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add(data[:len(data)/3])
+	flipped := append([]byte{}, data...)
+	flipped[len(flipped)/2] ^= 0x80
+	f.Add(flipped)
+}
+
+func FuzzDecodeShamirShare(f *testing.F) {
+	params := fuzzParams(f)
+	thresholdizer := mhe.NewThresholdizer(params)
+	poly, err := thresholdizer.GenShamirPolynomial(2, rlwe.NewKeyGenerator(params).GenSecretKeyNew())
+	if err != nil {
+		f.Fatal(err)
+	}
+	share := thresholdizer.AllocateThresholdSecretShare()
+	thresholdizer.GenShamirSecretShare(mhe.ShamirPublicPoint(1), poly, &share)
+	fuzzSeeds(f, &share)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		received, err := DecodeShamirShare(params, data)
+		if err != nil {
+			return
+		}
+		// a decoded share must aggregate without panicking
+		agg := thresholdizer.AllocateThresholdSecretShare()
+		if err := thresholdizer.AggregateShares(share, received, &agg); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzDecodePublicKeyGenShare(f *testing.F) {
+	params := fuzzParams(f)
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	crs, err := sampling.NewKeyedPRNG(make([]byte, 32))
+	if err != nil {
+		f.Fatal(err)
+	}
+	crp := ckg.SampleCRP(crs)
+	share := ckg.AllocateShare()
+	ckg.GenShare(rlwe.NewKeyGenerator(params).GenSecretKeyNew(), crp, &share)
+	fuzzSeeds(f, &share)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		received, err := DecodePublicKeyGenShare(params, data)
+		if err != nil {
+			return
+		}
+		// a decoded share must aggregate into a public key without panicking
+		agg := ckg.AllocateShare()
+		ckg.AggregateShares(share, received, &agg)
+		ckg.GenPublicKey(agg, crp, rlwe.NewPublicKey(params))
+	})
+}
+
+func FuzzDecodeMultiKeyCiphertext(f *testing.F) {
+	params := fuzzParams(f)
+	const parties = 4
+	sk := rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+	if err := rlwe.NewEncryptor(params, sk).EncryptZero(ct); err != nil {
+		f.Fatal(err)
+	}
+	fuzzSeeds(f, MultiKeyCiphertext{MetaData: ct.MetaData, C0: ct.Value[0], C: map[int]ring.Poly{1: ct.Value[1], 3: ct.Value[1]}})
+
+	dec := rlwe.NewDecryptor(params, sk)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		received, err := DecodeMultiKeyCiphertext(params, parties, data)
+		if err != nil {
+			return
+		}
+		// every projection (c0, c_i) of a decoded ciphertext must decrypt without panicking
+		for i, ci := range received.C {
+			if i < 0 || i >= parties {
+				t.Fatalf("component of party %d", i)
+			}
+			cti := heint.NewCiphertext(params, 1, received.C0.Level())
+			*cti.MetaData = *received.MetaData
+			cti.Value[0], cti.Value[1] = received.C0, ci
+			pt := heint.NewPlaintext(params, cti.Level())
+			dec.Decryptpart(cti, pt)
+			dec.Decryptall(cti, pt)
+		}
+	})
+}
+
+func FuzzDecodePartialDecryption(f *testing.F) {
+	params := fuzzParams(f)
+	sk := rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+	if err := rlwe.NewEncryptor(params, sk).EncryptZero(ct); err != nil {
+		f.Fatal(err)
+	}
+	dec := rlwe.NewDecryptor(params, sk)
+	ptpart := heint.NewPlaintext(params, ct.Level())
+	dec.Decryptpart(ct, ptpart)
+	fuzzSeeds(f, ptpart)
+	fuzzSeeds(f, heint.NewPlaintext(params, 0))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		received, err := DecodePartialDecryption(params, ct.Level(), data)
+		if err != nil {
+			return
+		}
+		// a decoded partial decryption must aggregate and finish the decryption without panicking
+		sum := heint.NewPlaintext(params, ct.Level())
+		dec.Decryptpart(ct, sum)
+		dec.Decryptadd(received, sum)
+		dec.Decryptall(ct, sum)
+	})
+}
@@ -0,0 +1,85 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/ring"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// SmudgedDecrypt decrypts ct under the sum of the secret keys sks, the key of the party parties[k] being sks[k]: every
+// party switches ct to the zero key with mhe.KeySwitchProtocol and smudging noise of standard deviation
+// MultipartySmudgingSigma, the shares are aggregated and the switched ciphertext is decrypted with the zero key. The
+// smudging noise hides c1·sk_i in the shares, which the Decryptpart decryptions reveal to the aggregator up to the
+// noise of ct. The randomness of party parties[k] is drawn from s and the shares are recorded in log under round.
+func SmudgedDecrypt(params rlwe.ParameterProvider, ct *rlwe.Ciphertext, sks []*rlwe.SecretKey, parties []int, s *SeededRandomness, log *AuditLog, round string) (*rlwe.Plaintext, error) {
+	if len(sks) == 0 || len(sks) != len(parties) {
+		return nil, fmt.Errorf("cannot SmudgedDecrypt: %d secret keys for %d parties", len(sks), len(parties))
+	}
+	smudging := ring.DiscreteGaussian{Sigma: MultipartySmudgingSigma, Bound: 6 * MultipartySmudgingSigma}
+	zero := rlwe.NewSecretKey(params)
+	var ks mhe.KeySwitchProtocol
+	var share, agg mhe.KeySwitchShare
+	var err error
+	for k, sk := range sks {
+		s.With(parties[k], "decrypt", func() { ks, err = mhe.NewKeySwitchProtocol(params, smudging) })
+		if err != nil {
+			return nil, fmt.Errorf("cannot SmudgedDecrypt: %w", err)
+		}
+		if k == 0 {
+			share, agg = ks.AllocateShare(ct.Level()), ks.AllocateShare(ct.Level())
+		}
+		ks.GenShare(sk, zero, ct, &share)
+		if err = ks.AggregateShares(share, agg, &agg); err != nil {
+			return nil, fmt.Errorf("cannot SmudgedDecrypt: %w", err)
+		}
+		log.PartialDecryption(round, parties[k])
+	}
+	ctOut := rlwe.NewCiphertext(params, 1, ct.Level())
+	ks.KeySwitch(ct, agg, ctOut)
+	pt := rlwe.NewDecryptor(params, zero).DecryptNew(ctOut)
+	pt.Scale = ct.Scale
+	return pt, nil
+}
+
+// GenRelinearizationKey generates the relinearization key of the sum of the secret keys sks with the two rounds of
+// mhe.RelinearizationKeyGenProtocol, the key of the party parties[k] being sks[k]: in the first round every party
+// generates a share with an ephemeral secret key, and in the second round a share from the aggregate of the first. The
+// CRS and the randomness of the parties are drawn from s and the shares are recorded in log.
+func GenRelinearizationKey(params rlwe.ParameterProvider, sks []*rlwe.SecretKey, parties []int, s *SeededRandomness, log *AuditLog) (*rlwe.RelinearizationKey, error) {
+	if len(sks) == 0 || len(sks) != len(parties) {
+		return nil, fmt.Errorf("cannot GenRelinearizationKey: %d secret keys for %d parties", len(sks), len(parties))
+	}
+	var rkg mhe.RelinearizationKeyGenProtocol
+	s.With(-1, "rkg", func() { rkg = mhe.NewRelinearizationKeyGenProtocol(params) })
+	crsSeed := s.Seed(-1, "rlk-crs")
+	log.CRPDerived("rlk", -1, crsSeed)
+	crs, err := sampling.NewKeyedPRNG(crsSeed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenRelinearizationKey: %w", err)
+	}
+	crp := rkg.SampleCRP(crs)
+
+	ephSks := make([]*rlwe.SecretKey, len(sks))
+	round1 := make([]mhe.RelinearizationKeyGenShare, len(sks))
+	round2 := make([]mhe.RelinearizationKeyGenShare, len(sks))
+	for k := range sks {
+		ephSks[k], round1[k], round2[k] = rkg.AllocateShare()
+	}
+	_, agg1, agg2 := rkg.AllocateShare()
+	for k, sk := range sks {
+		rkg.GenShareRoundOne(sk, crp, ephSks[k], &round1[k])
+		rkg.AggregateShares(round1[k], agg1, &agg1)
+		log.ShareSent("rlk/1", "rlk-share", parties[k], -1)
+	}
+	for k, sk := range sks {
+		rkg.GenShareRoundTwo(ephSks[k], sk, agg1, &round2[k])
+		rkg.AggregateShares(round2[k], agg2, &agg2)
+		log.ShareSent("rlk/2", "rlk-share", parties[k], -1)
+	}
+	rlk := rlwe.NewRelinearizationKey(params)
+	rkg.GenRelinearizationKey(agg1, agg2, rlk)
+	return rlk, nil
+}
@@ -0,0 +1,59 @@
+package examples
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+func TestSmudgedDecrypt(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntMultiparty10ParamsN14QP151)
+	if err != nil {
+		t.Fatal(err)
+	}
+	N := 3
+	sks := make([]*rlwe.SecretKey, N)
+	for i := range sks {
+		sks[i] = rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	}
+	parties := []int{0, 1, 2}
+	skAgg := SumSecretKeys(params, sks...)
+
+	encoder := heint.NewEncoder(params)
+	values := make([]uint64, params.MaxSlots())
+	for j := range values {
+		values[j] = uint64(j) % params.PlaintextModulus()
+	}
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(values, pt); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := rlwe.NewEncryptor(params, skAgg).EncryptNew(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the smudged shares decrypt ct with both fresh and seeded randomness
+	for _, s := range []*SeededRandomness{nil, NewSeededRandomness([]byte("seed"))} {
+		dec, err := SmudgedDecrypt(params, ct, sks, parties, s, nil, "decrypt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := make([]uint64, params.MaxSlots())
+		if err := encoder.Decode(dec, res); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, values) {
+			t.Errorf("decrypted %v..., want %v...", res[:8], values[:8])
+		}
+	}
+
+	if _, err := SmudgedDecrypt(params, ct, sks, parties[:2], nil, nil, "decrypt"); err == nil {
+		t.Error("more secret keys than parties: no error")
+	}
+	if _, err := GenRelinearizationKey(params, nil, nil, nil, nil); err == nil {
+		t.Error("no secret keys: no error")
+	}
+}
@@ -1,12 +1,19 @@
 package examples
 
 import (
+	"flag"
+	"fmt"
 	"testing"
 
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
 	"github.com/tuneinsight/lattigo/v5/he/hefloat"
 	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
 )
 
+var flagSecurityFloor = flag.Int("security-floor", 128, "the minimum security, in bits, of the example parameter sets")
+
 func TestExampleParams(t *testing.T) {
 	for _, pl := range HEIntParams {
 		p, err := heint.NewParametersFromLiteral(pl)
@@ -43,4 +50,202 @@ func TestExampleParams(t *testing.T) {
 		p.RingQ()
 		t.Logf("HEFloatReal: LogN: %d - LogQP: %12.7f - LogSlots: %d", p.LogN(), p.LogQP(), p.LogMaxSlots())
 	}
+
+	for i, pl := range HEIntMultipartyParams {
+		p, err := heint.NewParametersFromLiteral(pl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		N := HEIntMultipartyParties[i]
+		t.Logf("HEIntMultipartyParams: LogN: %d - LogQP: %12.7f - LogSlots: %d - Parties: %d", p.LogN(), p.LogQP(), p.LogMaxSlots(), N)
+		t.Run(fmt.Sprintf("N=%d", N), func(t *testing.T) {
+			if testing.Short() && N > 100 {
+				t.Skip("skipping the multiparty cycle of 1000 parties in short mode")
+			}
+			testMultipartyCycle(t, p, N)
+		})
+	}
+}
+
+// testMultipartyCycle checks that params decrypt correctly the computation it is validated for with N parties:
+// the noise predicted for an N-out-of-N decryption must fit the first prime, and a quick cycle is run where the
+// N parties generate a collective key, encrypt their inputs and aggregate the ciphertexts, and a majority threshold
+// of them decrypts the sum both with Decryptpart, Decryptadd and Decryptall and with SmudgedDecrypt.
+func testMultipartyCycle(t *testing.T, params heint.Parameters, N int) {
+	prediction := NoiseModel{Params: params, Parties: N, Decryptors: N, SmudgingSigma: MultipartySmudgingSigma}.Predict(N, 0)
+	if err := prediction.Err(); err != nil {
+		t.Fatalf("N=%d: %v", N, err)
+	}
+	threshold := N/2 + 1
+
+	kgen := rlwe.NewKeyGenerator(params)
+	sks := make([]*rlwe.SecretKey, N)
+	for i := range sks {
+		sks[i] = kgen.GenSecretKeyNew()
+	}
+
+	// collective public key
+	crs, err := sampling.NewPRNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckg := mhe.NewPublicKeyGenProtocol(params)
+	crp := ckg.SampleCRP(crs)
+	pkShare := ckg.AllocateShare()
+	pkAgg := ckg.AllocateShare()
+	for _, sk := range sks {
+		ckg.GenShare(sk, crp, &pkShare)
+		ckg.AggregateShares(pkShare, pkAgg, &pkAgg)
+	}
+	pk := rlwe.NewPublicKey(params)
+	ckg.GenPublicKey(pkAgg, crp, pk)
+
+	// every party encrypts its index, the ciphertexts are summed
+	encoder := heint.NewEncoder(params)
+	encryptor := rlwe.NewEncryptor(params, pk)
+	eval := heint.NewEvaluator(params, nil)
+	values := make([]uint64, params.MaxSlots())
+	want := make([]uint64, params.MaxSlots())
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+	ctAgg := heint.NewCiphertext(params, 1, params.MaxLevel())
+	for i := 0; i < N; i++ {
+		for j := range values {
+			values[j] = uint64(i+j) % params.PlaintextModulus()
+			want[j] = (want[j] + values[j]) % params.PlaintextModulus()
+		}
+		if err := encoder.Encode(values, pt); err != nil {
+			t.Fatal(err)
+		}
+		if err := encryptor.Encrypt(pt, ct); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			ctAgg.Copy(ct)
+		} else if err := eval.Add(ctAgg, ct, ctAgg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// t-out-of-N sharing of the secret keys. The sum of the Shamir polynomials of the parties is a Shamir polynomial of
+	// the sum of their keys, so the online parties receive their aggregated shares from a single polynomial: N·t^2
+	// polynomial operations would not fit a test for 1000 parties.
+	points := make([]mhe.ShamirPublicPoint, N)
+	for i := range points {
+		points[i] = mhe.ShamirPublicPoint(i + 1)
+	}
+	online := points[:threshold]
+	thresholdizer := mhe.NewThresholdizer(params)
+	shamirPoly, err := thresholdizer.GenShamirPolynomial(threshold, SumSecretKeys(params, sks...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parties := make([]int, threshold)
+	shares := make([]*rlwe.SecretKey, threshold)
+	for k, point := range online {
+		tsk := thresholdizer.AllocateThresholdSecretShare()
+		thresholdizer.GenShamirSecretShare(point, shamirPoly, &tsk)
+		combiner := mhe.NewCombiner(*params.GetRLWEParameters(), point, points, threshold)
+		shares[k] = rlwe.NewSecretKey(params)
+		if err := combiner.GenAdditiveShare(online, point, tsk, shares[k]); err != nil {
+			t.Fatal(err)
+		}
+		parties[k] = int(point) - 1
+	}
+
+	// the online parties decrypt ctAgg with their additive shares through Decryptpart, Decryptadd and Decryptall
+	first := rlwe.NewDecryptor(params, shares[0])
+	ptpart := heint.NewPlaintext(params, ctAgg.Level())
+	sum := heint.NewPlaintext(params, ctAgg.Level())
+	for _, sk := range shares {
+		rlwe.NewDecryptor(params, sk).Decryptpart(ctAgg, ptpart)
+		first.Decryptadd(ptpart, sum)
+	}
+	first.Decryptall(ctAgg, sum)
+	have := make([]uint64, params.MaxSlots())
+	if err := encoder.Decode(sum, have); err != nil {
+		t.Fatal(err)
+	}
+	for j := range want {
+		if have[j] != want[j] {
+			t.Fatalf("N=%d: Decryptall: slot %d decrypted to %d instead of %d", N, j, have[j], want[j])
+		}
+	}
+
+	// and with SmudgedDecrypt, each share switching ctAgg to the zero key with smudging noise
+	ptOut, err := SmudgedDecrypt(params, ctAgg, shares, parties, nil, nil, "decrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Decode(ptOut, have); err != nil {
+		t.Fatal(err)
+	}
+	for j := range want {
+		if have[j] != want[j] {
+			t.Fatalf("N=%d: SmudgedDecrypt: slot %d decrypted to %d instead of %d", N, j, have[j], want[j])
+		}
+	}
+
+	// the measured noise stays below the prediction for this run
+	ptWant := heint.NewPlaintext(params, ptOut.Level())
+	ptWant.Scale = ptOut.Scale
+	if err := encoder.Encode(want, ptWant); err != nil {
+		t.Fatal(err)
+	}
+	measured := PlaintextNoise(params, ptOut, ptWant)
+	predicted := NoiseModel{Params: params, Parties: N, Decryptors: threshold, SmudgingSigma: MultipartySmudgingSigma}.Predict(N, ptOut.Level()).Decrypted
+	if measured > predicted {
+		t.Fatalf("N=%d: measured noise 2^%.2f exceeds the prediction 2^%.2f", N, measured, predicted)
+	}
+	t.Logf("N=%d, t=%d: measured noise 2^%.2f, predicted 2^%.2f", N, threshold, measured, predicted)
+}
+
+// TestParamsSecurity checks the example parameter sets against the security floor. The bound of the HE standard is
+// checked on the nominal bit count of the literal, which the table is written for: several sets sit exactly at the
+// bound, where the primes actually generated can exceed it by a fraction of a bit.
+func TestParamsSecurity(t *testing.T) {
+	check := func(name string, params rlwe.ParameterProvider, logQ, logP []int) {
+		security := EstimateSecurity(params)
+		t.Logf("%s: %s", name, security)
+		nominal := sum(logQ) + sum(logP)
+		if maxLogQP, ok := HEStandardMaxLogQP(*flagSecurityFloor, security.LogN); !ok || nominal > maxLogQP {
+			t.Errorf("%s: LogN=%d nominal logQP=%d above the HE standard bound for %d bits", name, security.LogN, nominal, *flagSecurityFloor)
+		}
+		if security.Bits < float64(*flagSecurityFloor)-securityEstimateSlack {
+			t.Errorf("%s: estimated %.2f bits, below the security floor of %d bits", name, security.Bits, *flagSecurityFloor)
+		}
+	}
+
+	for _, group := range []struct {
+		name     string
+		literals []heint.ParametersLiteral
+	}{
+		{"HEIntParams", HEIntParams},
+		{"HEIntScaleInvariantParams", HEIntScaleInvariantParams},
+		{"HEIntMultipartyParams", HEIntMultipartyParams},
+	} {
+		for _, pl := range group.literals {
+			p, err := heint.NewParametersFromLiteral(pl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(group.name, p, pl.LogQ, pl.LogP)
+		}
+	}
+
+	for _, group := range []struct {
+		name     string
+		literals []hefloat.ParametersLiteral
+	}{
+		{"HEFloatComplexParams", HEFloatComplexParams},
+		{"HEFloatRealParams", HEFloatRealParams},
+	} {
+		for _, pl := range group.literals {
+			p, err := hefloat.NewParametersFromLiteral(pl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(group.name, p, pl.LogQ, pl.LogP)
+		}
+	}
 }
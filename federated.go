@@ -0,0 +1,177 @@
+package examples
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// Dataset is a binary classification dataset. The first feature of every sample is the constant 1, so that the
+// first weight of a model is its bias.
+type Dataset struct {
+	X [][]float64 // the samples
+	Y []float64   // the labels, 0 or 1
+}
+
+// SyntheticDataset returns the synthetic logistic regression dataset bundled with the examples: samples with
+// features-1 features uniform in [-1, 1] after the constant feature, labelled by a fixed hidden model. The dataset
+// only depends on its dimensions, so that every party and every run see the same data.
+func SyntheticDataset(samples, features int) (Dataset, error) {
+	if samples < 1 || features < 2 {
+		return Dataset{}, fmt.Errorf("cannot SyntheticDataset: %d samples of %d features, expected at least 1 and 2", samples, features)
+	}
+	prng, err := sampling.NewKeyedPRNG([]byte("lattigo/examples: synthetic logistic regression"))
+	if err != nil {
+		return Dataset{}, fmt.Errorf("cannot SyntheticDataset: %w", err)
+	}
+	uniform := func() float64 {
+		buf := make([]byte, 8)
+		if _, err := prng.Read(buf); err != nil {
+			panic(err)
+		}
+		return float64(binary.LittleEndian.Uint64(buf)>>11) / (1 << 53)
+	}
+	hidden := make([]float64, features)
+	for j := range hidden {
+		hidden[j] = 8*uniform() - 4
+	}
+	d := Dataset{X: make([][]float64, samples), Y: make([]float64, samples)}
+	for k := range d.X {
+		d.X[k] = make([]float64, features)
+		d.X[k][0] = 1
+		for j := 1; j < features; j++ {
+			d.X[k][j] = 2*uniform() - 1
+		}
+		// labels drawn from the logistic model, so that even the hidden model misclassifies some samples
+		if uniform() < sigmoid(dot(hidden, d.X[k])) {
+			d.Y[k] = 1
+		}
+	}
+	return d, nil
+}
+
+// Split returns the n consecutive parts of d, of sizes differing by at most one sample.
+func (d Dataset) Split(n int) []Dataset {
+	parts := make([]Dataset, n)
+	for i := range parts {
+		lo, hi := i*len(d.X)/n, (i+1)*len(d.X)/n
+		parts[i] = Dataset{X: d.X[lo:hi], Y: d.Y[lo:hi]}
+	}
+	return parts
+}
+
+// LogisticGradient returns the gradient of the mean logistic loss of the model weights on d.
+func LogisticGradient(weights []float64, d Dataset) []float64 {
+	grad := make([]float64, len(weights))
+	for k, x := range d.X {
+		e := sigmoid(dot(weights, x)) - d.Y[k]
+		for j := range grad {
+			grad[j] += e * x[j]
+		}
+	}
+	for j := range grad {
+		grad[j] /= float64(max(len(d.X), 1))
+	}
+	return grad
+}
+
+// TrainLogistic returns the model obtained from weights by the given number of full-batch gradient descent steps
+// with the given learning rate on d. The input weights are not modified.
+func TrainLogistic(weights []float64, d Dataset, epochs int, rate float64) []float64 {
+	w := append([]float64(nil), weights...)
+	for e := 0; e < epochs; e++ {
+		for j, g := range LogisticGradient(w, d) {
+			w[j] -= rate * g
+		}
+	}
+	return w
+}
+
+// LogisticAccuracy returns the fraction of the samples of d the model weights classifies correctly.
+func LogisticAccuracy(weights []float64, d Dataset) float64 {
+	if len(d.X) == 0 {
+		return 0
+	}
+	var correct int
+	for k, x := range d.X {
+		if (dot(weights, x) >= 0) == (d.Y[k] == 1) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(d.X))
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func dot(a, b []float64) (s float64) {
+	for j := range a {
+		s += a[j] * b[j]
+	}
+	return
+}
+
+// FederatedEvaluator aggregates the parties' encrypted model updates in a round of federated averaging.
+type FederatedEvaluator struct {
+	params  hefloat.Parameters
+	eval    *hefloat.Evaluator
+	encoder *hefloat.Encoder
+}
+
+// NewFederatedEvaluator returns a FederatedEvaluator. The averaging needs no evaluation key.
+func NewFederatedEvaluator(params hefloat.Parameters) *FederatedEvaluator {
+	return &FederatedEvaluator{params: params, eval: hefloat.NewEvaluator(params, nil), encoder: hefloat.NewEncoder(params)}
+}
+
+// Average returns the encryption of the updated model: model plus the mean of the encrypted updates, each update
+// being the difference between a party's locally trained model and model. The sum is multiplied by 1/len(updates)
+// encoded with the scale of the last modulus and rescaled, so the result consumes one level and keeps the scale of
+// the updates.
+func (f *FederatedEvaluator) Average(updates []*rlwe.Ciphertext, model []float64) (*rlwe.Ciphertext, error) {
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("cannot Average: no updates")
+	}
+	if len(model) > f.params.MaxSlots() {
+		return nil, fmt.Errorf("cannot Average: %d weights do not fit in %d slots", len(model), f.params.MaxSlots())
+	}
+	sum := updates[0].CopyNew()
+	for i, ct := range updates[1:] {
+		if err := f.eval.Add(sum, ct, sum); err != nil {
+			return nil, fmt.Errorf("cannot Average: update %d: %w", i+1, err)
+		}
+	}
+	if sum.Level() == 0 {
+		return nil, fmt.Errorf("cannot Average: the updates have no level left to rescale")
+	}
+
+	inv := make([]float64, f.params.MaxSlots())
+	for k := range inv {
+		inv[k] = 1 / float64(len(updates))
+	}
+	pt := hefloat.NewPlaintext(f.params, sum.Level())
+	pt.Scale = rlwe.NewScale(f.params.Q()[sum.Level()])
+	if err := f.encoder.Encode(inv, pt); err != nil {
+		return nil, fmt.Errorf("cannot Average: %w", err)
+	}
+	if err := f.eval.Mul(sum, pt, sum); err != nil {
+		return nil, fmt.Errorf("cannot Average: %w", err)
+	}
+	if err := f.eval.Rescale(sum, sum); err != nil {
+		return nil, fmt.Errorf("cannot Average: %w", err)
+	}
+
+	pt = hefloat.NewPlaintext(f.params, sum.Level())
+	pt.Scale = sum.Scale
+	if err := f.encoder.Encode(model, pt); err != nil {
+		return nil, fmt.Errorf("cannot Average: %w", err)
+	}
+	if err := f.eval.Add(sum, pt, sum); err != nil {
+		return nil, fmt.Errorf("cannot Average: %w", err)
+	}
+	return sum, nil
+}
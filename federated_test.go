@@ -0,0 +1,81 @@
+package examples
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+)
+
+func TestFederated(t *testing.T) {
+	d, err := SyntheticDataset(400, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := SyntheticDataset(400, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d, again) {
+		t.Error("SyntheticDataset: two calls return different datasets")
+	}
+	if _, err := SyntheticDataset(10, 1); err == nil {
+		t.Error("SyntheticDataset: no feature besides the bias: no error")
+	}
+	var samples int
+	for _, part := range d.Split(3) {
+		samples += len(part.X)
+		if len(part.X) < 133 || len(part.X) > 134 {
+			t.Errorf("Split: part of %d samples, want 133 or 134", len(part.X))
+		}
+	}
+	if samples != len(d.X) {
+		t.Errorf("Split: %d samples in the parts, want %d", samples, len(d.X))
+	}
+	w := TrainLogistic(make([]float64, 5), d, 50, 1)
+	if acc := LogisticAccuracy(w, d); acc < 0.65 {
+		t.Errorf("accuracy %.4f after training, want at least 0.65", acc)
+	}
+
+	params, err := hefloat.NewParametersFromLiteral(HEFloatComplexParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kgen := rlwe.NewKeyGenerator(params)
+	sk := kgen.GenSecretKeyNew()
+	encoder := hefloat.NewEncoder(params)
+	encryptor := rlwe.NewEncryptor(params, sk)
+	model := []float64{0.5, -1, 2}
+	updates := [][]float64{{1, 2, 3}, {-1, 0.5, 0}, {0.25, 0.25, -3}}
+	cts := make([]*rlwe.Ciphertext, len(updates))
+	for i, update := range updates {
+		pt := hefloat.NewPlaintext(params, params.MaxLevel())
+		if err := encoder.Encode(update, pt); err != nil {
+			t.Fatal(err)
+		}
+		if cts[i], err = encryptor.EncryptNew(pt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eval := NewFederatedEvaluator(params)
+	ct, err := eval.Average(cts, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]float64, params.MaxSlots())
+	if err := encoder.Decode(rlwe.NewDecryptor(params, sk).DecryptNew(ct), got); err != nil {
+		t.Fatal(err)
+	}
+	for j, m := range model {
+		want := m + (updates[0][j]+updates[1][j]+updates[2][j])/3
+		if math.Abs(got[j]-want) > 1e-4 {
+			t.Errorf("weight %d: decrypted %f, want %f", j, got[j], want)
+		}
+	}
+	// the average consumed the only level
+	if _, err := eval.Average([]*rlwe.Ciphertext{ct}, model); err == nil {
+		t.Error("Average: no level left: no error")
+	}
+}
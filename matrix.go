@@ -0,0 +1,206 @@
+package examples
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+// MatrixVectorDimension returns the dimension n of the square matrix a rows×cols matrix is padded to: the smallest
+// power of two at least rows and cols. The vector is replicated with period n over the slots, so that the slot
+// rotations act on it as cyclic rotations of dimension n.
+func MatrixVectorDimension(rows, cols int) int {
+	n := max(rows, cols, 1)
+	return 1 << bits.Len(uint(n-1))
+}
+
+// MatrixVectorRotations returns the rotations of the baby-step giant-step diagonal method for a rows×cols matrix:
+// the baby steps 1, ..., n1-1 and the giant steps n1, 2*n1, ..., with n1 about the square root of the dimension.
+func MatrixVectorRotations(rows, cols int) []int {
+	n := MatrixVectorDimension(rows, cols)
+	n1 := matrixBabySteps(n)
+	rotations := make([]int, 0, n1+n/n1)
+	for b := 1; b < n1; b++ {
+		rotations = append(rotations, b)
+	}
+	for g := n1; g < n; g += n1 {
+		rotations = append(rotations, g)
+	}
+	return rotations
+}
+
+// matrixBabySteps returns n1 = 2^ceil(log2(n)/2), so that n1 baby steps and n/n1 giant steps cover the n diagonals.
+func matrixBabySteps(n int) int {
+	return 1 << ((bits.Len(uint(n-1)) + 1) / 2)
+}
+
+// MatrixVectorEvaluator multiplies plaintext matrices by encrypted vectors with the diagonal method of Halevi and
+// Shoup: the product is the sum over i of the i-th generalized diagonal times the vector rotated by i. The
+// rotations are split into baby steps and giant steps, so that a product by an n×n matrix needs about 2*sqrt(n)
+// rotations instead of n. The rotations use the collective Galois keys of the rotations of MatrixVectorRotations.
+//
+// The vector must be encrypted as returned by Replicate. The i-th entry of the product is in the i-th slot and
+// repeats, like the input, with period MatrixVectorDimension.
+type MatrixVectorEvaluator[T uint64 | float64] struct {
+	params rlwe.Parameters
+	cycle  int // the number of slots a rotation cycles over
+	slots  int
+
+	plaintext func(level int) *rlwe.Plaintext
+	encode    func(values []T, pt *rlwe.Plaintext) error
+	rotate    func(ct *rlwe.Ciphertext, k int) (*rlwe.Ciphertext, error)
+	mul       func(ct *rlwe.Ciphertext, pt *rlwe.Plaintext) (*rlwe.Ciphertext, error)
+	add       func(ct0, ct1, ctOut *rlwe.Ciphertext) error
+	rescale   func(ct *rlwe.Ciphertext) error
+}
+
+// NewHEIntMatrixVectorEvaluator returns a MatrixVectorEvaluator for heint ciphertexts. The slots are two rows of
+// N/2 slots rotated independently, so the dimension is at most N/2. The product keeps the level and the scale of the
+// vector.
+func NewHEIntMatrixVectorEvaluator(params heint.Parameters, evk rlwe.EvaluationKeySet) *MatrixVectorEvaluator[uint64] {
+	eval := heint.NewEvaluator(params, evk)
+	encoder := heint.NewEncoder(params)
+	return &MatrixVectorEvaluator[uint64]{
+		params:    *params.GetRLWEParameters(),
+		cycle:     params.MaxSlots() / 2,
+		slots:     params.MaxSlots(),
+		plaintext: func(level int) *rlwe.Plaintext { return heint.NewPlaintext(params, level) },
+		encode:    func(values []uint64, pt *rlwe.Plaintext) error { return encoder.Encode(values, pt) },
+		rotate:    eval.RotateColumnsNew,
+		mul:       func(ct *rlwe.Ciphertext, pt *rlwe.Plaintext) (*rlwe.Ciphertext, error) { return eval.MulNew(ct, pt) },
+		add:       func(ct0, ct1, ctOut *rlwe.Ciphertext) error { return eval.Add(ct0, ct1, ctOut) },
+		rescale:   func(*rlwe.Ciphertext) error { return nil },
+	}
+}
+
+// NewHEFloatMatrixVectorEvaluator returns a MatrixVectorEvaluator for hefloat ciphertexts. The diagonals are encoded
+// with the scale of the last modulus of the vector's level, so the product is rescaled once, consumes one level and
+// keeps the scale of the vector.
+func NewHEFloatMatrixVectorEvaluator(params hefloat.Parameters, evk rlwe.EvaluationKeySet) *MatrixVectorEvaluator[float64] {
+	eval := hefloat.NewEvaluator(params, evk)
+	encoder := hefloat.NewEncoder(params)
+	return &MatrixVectorEvaluator[float64]{
+		params: *params.GetRLWEParameters(),
+		cycle:  params.MaxSlots(),
+		slots:  params.MaxSlots(),
+		plaintext: func(level int) *rlwe.Plaintext {
+			pt := hefloat.NewPlaintext(params, level)
+			pt.Scale = rlwe.NewScale(params.Q()[level])
+			return pt
+		},
+		encode:  func(values []float64, pt *rlwe.Plaintext) error { return encoder.Encode(values, pt) },
+		rotate:  eval.RotateNew,
+		mul:     func(ct *rlwe.Ciphertext, pt *rlwe.Plaintext) (*rlwe.Ciphertext, error) { return eval.MulNew(ct, pt) },
+		add:     func(ct0, ct1, ctOut *rlwe.Ciphertext) error { return eval.Add(ct0, ct1, ctOut) },
+		rescale: func(ct *rlwe.Ciphertext) error { return eval.Rescale(ct, ct) },
+	}
+}
+
+// GaloisElements returns the Galois elements of the collective Galois keys a product by a rows×cols matrix needs.
+func (e *MatrixVectorEvaluator[T]) GaloisElements(rows, cols int) []uint64 {
+	return e.params.GaloisElements(MatrixVectorRotations(rows, cols))
+}
+
+// Replicate returns the slot vector encrypting x for a product by a matrix of dimension n, see
+// MatrixVectorDimension: x padded with zeros to n entries, repeated over all the slots.
+func (e *MatrixVectorEvaluator[T]) Replicate(x []T, n int) ([]T, error) {
+	if len(x) > n || e.cycle%n != 0 {
+		return nil, fmt.Errorf("cannot Replicate: %d entries with period %d over rotation cycles of %d slots", len(x), n, e.cycle)
+	}
+	values := make([]T, e.slots)
+	for k := 0; k < e.slots; k += n {
+		copy(values[k:], x)
+	}
+	return values, nil
+}
+
+// Mul returns the encryption of m times the vector encrypted in ct.
+func (e *MatrixVectorEvaluator[T]) Mul(m [][]T, ct *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	if len(m) == 0 || len(m[0]) == 0 {
+		return nil, fmt.Errorf("cannot Mul: empty matrix")
+	}
+	rows, cols := len(m), len(m[0])
+	for i, row := range m {
+		if len(row) != cols {
+			return nil, fmt.Errorf("cannot Mul: row %d has %d entries, expected %d", i, len(row), cols)
+		}
+	}
+	n := MatrixVectorDimension(rows, cols)
+	if n > e.cycle {
+		return nil, fmt.Errorf("cannot Mul: a %dx%d matrix needs rotation cycles of %d slots, the parameters have %d; use a larger LogN", rows, cols, n, e.cycle)
+	}
+	n1 := matrixBabySteps(n)
+
+	// baby steps: the vector rotated by 0, ..., n1-1
+	babies := make([]*rlwe.Ciphertext, n1)
+	babies[0] = ct
+	for b := 1; b < n1; b++ {
+		var err error
+		if babies[b], err = e.rotate(ct, b); err != nil {
+			return nil, fmt.Errorf("cannot Mul: rotation by %d: %w (generate the collective Galois keys of GaloisElements)", b, err)
+		}
+	}
+
+	var res *rlwe.Ciphertext
+	for g := 0; g < n; g += n1 {
+		// sum over b of the diagonal g+b rotated by -g, times the vector rotated by b
+		var inner *rlwe.Ciphertext
+		for b := 0; b < n1 && g+b < n; b++ {
+			diag := make([]T, n)
+			zero := true
+			for j := range diag {
+				// the diagonal g+b at the slot j-g
+				r := ((j-g)%n + n) % n
+				if c := (r + g + b) % n; r < rows && c < cols {
+					diag[j] = m[r][c]
+					zero = zero && diag[j] == 0
+				}
+			}
+			if zero {
+				continue
+			}
+			values, err := e.Replicate(diag, n)
+			if err != nil {
+				return nil, fmt.Errorf("cannot Mul: %w", err)
+			}
+			pt := e.plaintext(ct.Level())
+			if err := e.encode(values, pt); err != nil {
+				return nil, fmt.Errorf("cannot Mul: %w", err)
+			}
+			term, err := e.mul(babies[b], pt)
+			if err != nil {
+				return nil, fmt.Errorf("cannot Mul: %w", err)
+			}
+			if inner == nil {
+				inner = term
+			} else if err := e.add(inner, term, inner); err != nil {
+				return nil, fmt.Errorf("cannot Mul: %w", err)
+			}
+		}
+		if inner == nil {
+			continue
+		}
+		// giant step: rotate the partial sum by g
+		if g > 0 {
+			var err error
+			if inner, err = e.rotate(inner, g); err != nil {
+				return nil, fmt.Errorf("cannot Mul: rotation by %d: %w (generate the collective Galois keys of GaloisElements)", g, err)
+			}
+		}
+		if res == nil {
+			res = inner
+		} else if err := e.add(res, inner, res); err != nil {
+			return nil, fmt.Errorf("cannot Mul: %w", err)
+		}
+	}
+	if res == nil {
+		return nil, fmt.Errorf("cannot Mul: zero matrix")
+	}
+	if err := e.rescale(res); err != nil {
+		return nil, fmt.Errorf("cannot Mul: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,164 @@
+package examples
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+func TestMatrixVector(t *testing.T) {
+	for dims, want := range map[[2]int][]int{{1, 1}: {}, {4, 4}: {1, 2}, {3, 7}: {1, 2, 3, 4}, {16, 2}: {1, 2, 3, 4, 8, 12}} {
+		if got := MatrixVectorRotations(dims[0], dims[1]); !reflect.DeepEqual(got, want) {
+			t.Errorf("MatrixVectorRotations(%d, %d) = %v, want %v", dims[0], dims[1], got, want)
+		}
+	}
+
+	// a square matrix, a rectangular matrix with a zero diagonal, and a matrix wider than it is tall
+	shapes := [][2]int{{8, 8}, {5, 3}, {3, 12}}
+	matrix := func(rows, cols int, f func(r, c int) int) [][]int {
+		m := make([][]int, rows)
+		for r := range m {
+			m[r] = make([]int, cols)
+			for c := range m[r] {
+				if r != c {
+					m[r][c] = f(r, c)
+				}
+			}
+		}
+		return m
+	}
+
+	t.Run("heint", func(t *testing.T) {
+		params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+		if err != nil {
+			t.Fatal(err)
+		}
+		T := params.PlaintextModulus()
+		kgen := rlwe.NewKeyGenerator(params)
+		sk := kgen.GenSecretKeyNew()
+		rotations := MatrixVectorRotations(12, 12)
+		gks := kgen.GenGaloisKeysNew(params.GaloisElements(rotations), sk)
+		eval := NewHEIntMatrixVectorEvaluator(params, rlwe.NewMemEvaluationKeySet(nil, gks...))
+		encoder := heint.NewEncoder(params)
+		for _, shape := range shapes {
+			rows, cols := shape[0], shape[1]
+			m := make([][]uint64, rows)
+			for r, row := range matrix(rows, cols, func(r, c int) int { return 3*r + c + 1 }) {
+				m[r] = make([]uint64, cols)
+				for c, v := range row {
+					m[r][c] = uint64(v)
+				}
+			}
+			x := make([]uint64, cols)
+			for c := range x {
+				x[c] = uint64(c*c + 2)
+			}
+			values, err := eval.Replicate(x, MatrixVectorDimension(rows, cols))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt := heint.NewPlaintext(params, params.MaxLevel())
+			if err := encoder.Encode(values, pt); err != nil {
+				t.Fatal(err)
+			}
+			ct, err := rlwe.NewEncryptor(params, sk).EncryptNew(pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := eval.Mul(m, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]uint64, params.MaxSlots())
+			if err := encoder.Decode(rlwe.NewDecryptor(params, sk).DecryptNew(res), got); err != nil {
+				t.Fatal(err)
+			}
+			for r := range m {
+				var want uint64
+				for c := range x {
+					want = (want + m[r][c]*x[c]) % T
+				}
+				if got[r] != want {
+					t.Errorf("%dx%d: entry %d decrypted %d, want %d", rows, cols, r, got[r], want)
+				}
+			}
+		}
+
+		// the rotations of a 32x32 matrix have no Galois keys
+		m := make([][]uint64, 32)
+		for r := range m {
+			m[r] = make([]uint64, 32)
+			m[r][(r+17)%32] = 1
+		}
+		ct := rlwe.NewCiphertext(params, 1, params.MaxLevel())
+		if _, err := eval.Mul(m, ct); err == nil {
+			t.Error("Mul: missing Galois keys: no error")
+		}
+		if _, err := eval.Mul([][]uint64{{1, 2}, {3}}, ct); err == nil {
+			t.Error("Mul: ragged matrix: no error")
+		}
+	})
+
+	t.Run("hefloat", func(t *testing.T) {
+		params, err := hefloat.NewParametersFromLiteral(HEFloatComplexParamsN12QP109)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kgen := rlwe.NewKeyGenerator(params)
+		sk := kgen.GenSecretKeyNew()
+		eval := NewHEFloatMatrixVectorEvaluator(params, nil)
+		gks := kgen.GenGaloisKeysNew(eval.GaloisElements(12, 12), sk)
+		eval = NewHEFloatMatrixVectorEvaluator(params, rlwe.NewMemEvaluationKeySet(nil, gks...))
+		encoder := hefloat.NewEncoder(params)
+		for _, shape := range shapes {
+			rows, cols := shape[0], shape[1]
+			m := make([][]float64, rows)
+			for r, row := range matrix(rows, cols, func(r, c int) int { return r - 2*c }) {
+				m[r] = make([]float64, cols)
+				for c, v := range row {
+					m[r][c] = float64(v) / 8
+				}
+			}
+			x := make([]float64, cols)
+			for c := range x {
+				x[c] = math.Sin(float64(c))
+			}
+			values, err := eval.Replicate(x, MatrixVectorDimension(rows, cols))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt := hefloat.NewPlaintext(params, params.MaxLevel())
+			if err := encoder.Encode(values, pt); err != nil {
+				t.Fatal(err)
+			}
+			ct, err := rlwe.NewEncryptor(params, sk).EncryptNew(pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := eval.Mul(m, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Level() != ct.Level()-1 {
+				t.Errorf("%dx%d: product at level %d, want %d", rows, cols, res.Level(), ct.Level()-1)
+			}
+			got := make([]float64, params.MaxSlots())
+			if err := encoder.Decode(rlwe.NewDecryptor(params, sk).DecryptNew(res), got); err != nil {
+				t.Fatal(err)
+			}
+			for r := range m {
+				var want float64
+				for c := range x {
+					want += m[r][c] * x[c]
+				}
+				if math.Abs(got[r]-want) > 1e-3 {
+					t.Errorf("%dx%d: entry %d decrypted %f, want %f", rows, cols, r, got[r], want)
+				}
+			}
+		}
+	})
+}
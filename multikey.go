@@ -0,0 +1,93 @@
+package examples
+
+import (
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/ring"
+)
+
+// NewMultiKeyCiphertext returns a zero extended multi-key ciphertext at the given level, without mask components.
+func NewMultiKeyCiphertext(params heint.Parameters, level int) *MultiKeyCiphertext {
+	ct := heint.NewCiphertext(params, 0, level)
+	return &MultiKeyCiphertext{MetaData: ct.MetaData, C0: ct.Value[0], C: map[int]ring.Poly{}}
+}
+
+// ExtendCiphertext returns the ciphertext (c0, c1) of party i as an extended multi-key ciphertext, at the level of ct,
+// with the single mask component c_i = c1. The polynomials are shared with ct.
+func ExtendCiphertext(ct *rlwe.Ciphertext, i int) *MultiKeyCiphertext {
+	md := *ct.MetaData
+	return &MultiKeyCiphertext{MetaData: &md, C0: ct.Value[0], C: map[int]ring.Poly{i: ct.Value[1]}}
+}
+
+// Level returns the level of ct, that of c0.
+func (ct *MultiKeyCiphertext) Level() int {
+	return ct.C0.Level()
+}
+
+// Parties returns the indices of the parties with a mask component in ct, in increasing order.
+func (ct *MultiKeyCiphertext) Parties() []int {
+	return MultiKeyParties(ct)
+}
+
+// Resize truncates c0 and all the mask components to the given level, without modifying the underlying data they
+// may share with other ciphertexts.
+func (ct *MultiKeyCiphertext) Resize(level int) {
+	ct.C0.Resize(level)
+	for i, ci := range ct.C {
+		ci.Resize(level)
+		ct.C[i] = ci
+	}
+}
+
+// Dense packs c0 and the mask components of ct into a ciphertext of degree the number of components, and returns it
+// with the indices of the parties, whose components are Value[1:] in this order.
+func (ct *MultiKeyCiphertext) Dense(params heint.Parameters) (*rlwe.Ciphertext, []int) {
+	idx := ct.Parties()
+	ctd := heint.NewCiphertext(params, len(idx), ct.Level())
+	*ctd.MetaData = *ct.MetaData
+	ctd.Value[0] = ct.C0
+	for k, i := range idx {
+		ctd.Value[k+1] = ct.C[i]
+	}
+	return ctd, idx
+}
+
+// Project returns the projection (c0, c_i) of ct on party i, at the level of ct, with c_i = 0 if party i has no mask
+// component. The partial decryption of the projection under the key of party i is its share of the decryption of ct.
+func (ct *MultiKeyCiphertext) Project(params heint.Parameters, i int) *rlwe.Ciphertext {
+	ctp := heint.NewCiphertext(params, 1, ct.Level())
+	*ctp.MetaData = *ct.MetaData
+	ctp.Value[0] = ct.C0
+	if ci, ok := ct.C[i]; ok {
+		ctp.Value[1] = ci
+	}
+	return ctp
+}
+
+// Body returns the ciphertext (c0, 0) at the level of ct, which completes the decryption of ct once the partial
+// decryptions of its projections are summed.
+func (ct *MultiKeyCiphertext) Body(params heint.Parameters) *rlwe.Ciphertext {
+	ctb := heint.NewCiphertext(params, 1, ct.Level())
+	*ctb.MetaData = *ct.MetaData
+	ctb.Value[0] = ct.C0
+	return ctb
+}
+
+// MultiKeyParties returns the union of the indices of the parties with a mask component in the ciphertexts, in
+// increasing order.
+func MultiKeyParties(cts ...*MultiKeyCiphertext) []int {
+	seen := map[int]bool{}
+	idx := make([]int, 0)
+	for _, ct := range cts {
+		for i := range ct.C {
+			if !seen[i] {
+				seen[i] = true
+				idx = append(idx, i)
+			}
+		}
+	}
+	sort.Ints(idx)
+	return idx
+}
@@ -0,0 +1,63 @@
+package examples
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+func TestMultiKeyCiphertext(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder := heint.NewEncoder(params)
+	values := make([]uint64, params.MaxSlots())
+	for j := range values {
+		values[j] = uint64(j) % params.PlaintextModulus()
+	}
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(values, pt); err != nil {
+		t.Fatal(err)
+	}
+	sk := rlwe.NewKeyGenerator(params).GenSecretKeyNew()
+	ct, err := rlwe.NewEncryptor(params, sk).EncryptNew(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mkct := ExtendCiphertext(ct, 3)
+	if parties := mkct.Parties(); !reflect.DeepEqual(parties, []int{3}) {
+		t.Fatalf("parties %v, want [3]", parties)
+	}
+	if parties := MultiKeyParties(mkct, ExtendCiphertext(ct, 1), NewMultiKeyCiphertext(params, 0)); !reflect.DeepEqual(parties, []int{1, 3}) {
+		t.Errorf("union of the parties %v, want [1 3]", parties)
+	}
+	dense, idx := mkct.Dense(params)
+	if dense.Degree() != 1 || !reflect.DeepEqual(idx, []int{3}) || !dense.Value[1].Equal(&ct.Value[1]) {
+		t.Errorf("dense form of degree %d with parties %v", dense.Degree(), idx)
+	}
+	if !mkct.Project(params, 1).Value[1].Equal(&heint.NewCiphertext(params, 1, ct.Level()).Value[1]) {
+		t.Error("projection on a party without component: c_i is not zero")
+	}
+
+	// the partial decryption of the projection completes the decryption of the body
+	mkct.Resize(0)
+	if mkct.Level() != 0 || mkct.C[3].Level() != 0 || ct.Level() != params.MaxLevel() {
+		t.Fatalf("resized to level %d, component at level %d, ciphertext at level %d", mkct.Level(), mkct.C[3].Level(), ct.Level())
+	}
+	decryptor := rlwe.NewDecryptor(params, sk)
+	part := heint.NewPlaintext(params, mkct.Level())
+	decryptor.Decryptpart(mkct.Project(params, 3), part)
+	decryptor.Decryptall(mkct.Body(params), part)
+	part.Scale = mkct.Scale
+	res := make([]uint64, params.MaxSlots())
+	if err := encoder.Decode(part, res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, values) {
+		t.Errorf("decrypted %v..., want %v...", res[:8], values[:8])
+	}
+}
@@ -0,0 +1,203 @@
+package examples
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/ring"
+)
+
+// NoiseFailureProbability is the per-coefficient probability, used by NoiseModel, that the noise exceeds its predicted bound.
+const NoiseFailureProbability = 0x1p-40
+
+// SumSecretKeys returns the collective secret key sum_i sks[i], i.e. the key a ciphertext encrypted under the
+// aggregated public key decrypts with. It is only available in simulation, where all the secret keys are known.
+func SumSecretKeys(params rlwe.ParameterProvider, sks ...*rlwe.SecretKey) *rlwe.SecretKey {
+	p := params.GetRLWEParameters()
+	sk := rlwe.NewSecretKey(p)
+	ringQP := p.RingQP()
+	for _, ski := range sks {
+		ringQP.Add(sk.Value, ski.Value, sk.Value)
+	}
+	return sk
+}
+
+// CiphertextNoise returns the log2 of the infinity norm of c0 + sum_i c_{i+1}*sks[i] - want, i.e. the actual noise of ct
+// with respect to the expected plaintext want. A ciphertext under a collective key is measured with the collective
+// secret key (see SumSecretKeys); a multi-key ciphertext of degree k is measured with the k keys of its components.
+func CiphertextNoise(params rlwe.ParameterProvider, ct *rlwe.Ciphertext, want *rlwe.Plaintext, sks ...*rlwe.SecretKey) (float64, error) {
+	if ct.Degree() != len(sks) {
+		return 0, fmt.Errorf("cannot CiphertextNoise: ciphertext of degree %d requires %d secret keys but %d were given", ct.Degree(), ct.Degree(), len(sks))
+	}
+
+	ringQ := params.GetRLWEParameters().RingQ().AtLevel(ct.Level())
+
+	phase := ringQ.NewPoly()
+	buff := ringQ.NewPoly()
+	phase.Copy(ct.Value[0])
+	if !ct.IsNTT {
+		ringQ.NTT(phase, phase)
+	}
+	for i, sk := range sks {
+		buff.Copy(ct.Value[i+1])
+		if !ct.IsNTT {
+			ringQ.NTT(buff, buff)
+		}
+		ringQ.MulCoeffsMontgomeryThenAdd(buff, sk.Value.Q, phase)
+	}
+	ringQ.INTT(phase, phase)
+
+	return noiseLog2(ringQ, phase, want), nil
+}
+
+// PlaintextNoise returns the log2 of the infinity norm of pt - want, where pt is a decrypted plaintext before decoding,
+// e.g. the sum of the partial decryptions, and want is the encoding of the expected values.
+func PlaintextNoise(params rlwe.ParameterProvider, pt, want *rlwe.Plaintext) float64 {
+	ringQ := params.GetRLWEParameters().RingQ().AtLevel(min(pt.Level(), want.Level()))
+	phase := ringQ.NewPoly()
+	phase.Copy(pt.Value)
+	if pt.IsNTT {
+		ringQ.INTT(phase, phase)
+	}
+	return noiseLog2(ringQ, phase, want)
+}
+
+// NoiseBudget returns the number of bits of noise that can still be added at the given level before decryption fails,
+// given the log2 of the infinity norm of the current noise. Decoding multiplies the phase by the plaintext modulus t,
+// so the noise must remain below Q/(2t). A negative budget means that decryption fails.
+func NoiseBudget(params heint.Parameters, level int, noise float64) float64 {
+	var logQ float64
+	for _, qi := range params.Q()[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+	return logQ - 1 - math.Log2(float64(params.PlaintextModulus())) - noise
+}
+
+// noiseLog2 returns log2||phase - want||, with phase in the coefficient domain.
+func noiseLog2(ringQ *ring.Ring, phase ring.Poly, want *rlwe.Plaintext) float64 {
+	diff := ringQ.NewPoly()
+	diff.Copy(want.Value)
+	if want.IsNTT {
+		ringQ.INTT(diff, diff)
+	}
+	ringQ.Sub(phase, diff, diff)
+	return infNormLog2(ringQ, diff)
+}
+
+// infNormLog2 returns the log2 of the infinity norm of poly, in the coefficient domain, or 0 if poly is zero.
+func infNormLog2(ringQ *ring.Ring, poly ring.Poly) float64 {
+	coeffs := make([]*big.Int, ringQ.N())
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+	ringQ.PolyToBigintCentered(poly, 1, coeffs)
+
+	norm := new(big.Int)
+	for _, c := range coeffs {
+		if c.CmpAbs(norm) > 0 {
+			norm.Abs(c)
+		}
+	}
+	if norm.Sign() == 0 {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(norm).Float64()
+	return math.Log2(f)
+}
+
+// NoiseModel predicts the noise of the multiparty `heint` flows analytically, as a function of the parameter set,
+// the number of parties whose keys are aggregated and the number of parties taking part in the decryption.
+//
+// Ciphertexts are assumed to be fresh public-key encryptions, with the noise of the public key being the sum of the
+// noise of each party's share. The prediction is a heuristic bound on the infinity norm that holds except with
+// probability NoiseFailureProbability per coefficient; it does not account for multiplications.
+type NoiseModel struct {
+	Params heint.Parameters
+
+	// Parties is the number of secret keys aggregated into the key the ciphertexts are encrypted under:
+	// N for a collective key (e.g. MHE_CRS), t for a key generated by t online parties (e.g. TMHE)
+	// and 1 for the per-party keys of the programs without CRS.
+	Parties int
+
+	// Decryptors is the number of parties contributing a partial decryption.
+	Decryptors int
+
+	// SmudgingSigma is the standard deviation of the noise each party adds to its partial decryption, 0 if none.
+	SmudgingSigma float64
+}
+
+// NoisePrediction is the predicted log2 of the infinity norm of the noise at each step of a flow.
+type NoisePrediction struct {
+	Fresh     float64 // after encryption
+	Computed  float64 // after summing the input ciphertexts
+	Decrypted float64 // after summing the partial decryptions
+	Budget    float64 // remaining budget of the decrypted plaintext, in bits
+}
+
+// Predict returns the predicted noise of the sum of inputs fresh ciphertexts at the given level, decrypted by
+// m.Decryptors parties.
+func (m NoiseModel) Predict(inputs, level int) NoisePrediction {
+	p := predictNoise(m.Params.LogN(), sigma(m.Params), secretVariance(m.Params), m.Parties, m.Decryptors, m.SmudgingSigma, inputs)
+	p.Budget = NoiseBudget(m.Params, level, p.Decrypted)
+	return p
+}
+
+// Err returns an error if the predicted noise leaves less than one bit of budget, i.e. if decryption is expected to fail.
+func (p NoisePrediction) Err() error {
+	if p.Budget < 1 {
+		return fmt.Errorf("predicted noise 2^%.2f leaves %.2f bits of budget: decryption is expected to fail", p.Decrypted, p.Budget)
+	}
+	return nil
+}
+
+// predictNoise predicts the noise of the sum of inputs fresh public-key encryptions under the sum of parties keys,
+// decrypted by decryptors parties, for a ring of degree 2^logN. The budget is left unset.
+func predictNoise(logN int, sigma, secretVariance float64, parties, decryptors int, smudgingSigma float64, inputs int) NoisePrediction {
+	n := math.Exp2(float64(logN))
+
+	// v*e_pk + e0 + e1*s, where s and e_pk are the sums of the parties' secrets and errors: the products
+	// are sums of n terms, each of a secret-distributed and a parties-fold error coefficient
+	fresh := sigma * sigma * (1 + 2*n*float64(max(parties, 1))*secretVariance)
+	computed := float64(max(inputs, 1)) * fresh
+	decrypted := computed + float64(decryptors)*smudgingSigma*smudgingSigma
+
+	return NoisePrediction{
+		Fresh:     noiseBound(logN, fresh),
+		Computed:  noiseBound(logN, computed),
+		Decrypted: noiseBound(logN, decrypted),
+	}
+}
+
+// noiseBound converts a coefficient variance into the log2 of a bound on the infinity norm of the noise.
+func noiseBound(logN int, variance float64) float64 {
+	return math.Log2(noiseTail(logN)*math.Sqrt(variance) + 1)
+}
+
+// noiseTail is the number of standard deviations a coefficient exceeds with probability NoiseFailureProbability.
+func noiseTail(logN int) float64 {
+	return math.Sqrt(2 * math.Log(math.Exp2(float64(logN)+1)/NoiseFailureProbability))
+}
+
+func sigma(params rlwe.ParameterProvider) float64 {
+	if g, ok := params.GetRLWEParameters().Xe().(ring.DiscreteGaussian); ok {
+		return g.Sigma
+	}
+	return rlwe.DefaultNoise
+}
+
+func secretVariance(params rlwe.ParameterProvider) float64 {
+	p := params.GetRLWEParameters()
+	if t, ok := p.Xs().(ring.Ternary); ok {
+		if t.H > 0 {
+			return float64(t.H) / float64(p.N())
+		}
+		return t.P
+	}
+	return defaultSecretVariance
+}
+
+// defaultSecretVariance is the variance of a coefficient of a uniform ternary secret.
+const defaultSecretVariance = 2.0 / 3.0
@@ -84,6 +84,30 @@ var (
 		PlaintextModulus: 0x10001,
 	}
 
+	// HEIntMultiparty10ParamsN14QP151 is an example parameter set for the multiparty `heint` flows with up to 10 parties.
+	HEIntMultiparty10ParamsN14QP151 = heint.ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{55, 40},
+		LogP:             []int{56},
+		PlaintextModulus: 0x10001,
+	}
+
+	// HEIntMultiparty100ParamsN14QP153 is an example parameter set for the multiparty `heint` flows with up to 100 parties.
+	HEIntMultiparty100ParamsN14QP153 = heint.ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{56, 40},
+		LogP:             []int{57},
+		PlaintextModulus: 0x10001,
+	}
+
+	// HEIntMultiparty1000ParamsN14QP159 is an example parameter set for the multiparty `heint` flows with up to 1000 parties.
+	HEIntMultiparty1000ParamsN14QP159 = heint.ParametersLiteral{
+		LogN:             14,
+		LogQ:             []int{59, 40},
+		LogP:             []int{60},
+		PlaintextModulus: 0x10001,
+	}
+
 	// HEFloatComplexParamsN12QP109 is an example parameter set for the `hefloat` package with logN=12 and logQP=109.
 	// These parameters instantiate `hefloat` over the complex field with N/2 SIMD slots.
 	HEFloatComplexParamsN12QP109 = hefloat.ParametersLiteral{
@@ -180,6 +204,14 @@ var (
 
 var HEIntParams = []heint.ParametersLiteral{HEIntParamsN12QP109, HEIntParamsN13QP218, HEIntParamsN14QP438, HEIntParamsN15QP880}
 
+// MultipartySmudgingSigma is the standard deviation of the smudging noise of each share of SmudgedDecrypt.
+const MultipartySmudgingSigma = 1 << 30
+
+var HEIntMultipartyParams = []heint.ParametersLiteral{HEIntMultiparty10ParamsN14QP151, HEIntMultiparty100ParamsN14QP153, HEIntMultiparty1000ParamsN14QP159}
+
+// HEIntMultipartyParties is the maximum number of parties of each of the HEIntMultipartyParams.
+var HEIntMultipartyParties = []int{10, 100, 1000}
+
 var HEIntScaleInvariantParams = []heint.ParametersLiteral{HEIntScaleInvariantParamsN12QP109, HEIntScaleInvariantParamsN13QP218, HEIntScaleInvariantParamsN14QP438, HEIntScaleInvariantParamsN15QP880}
 
 var HEFloatComplexParams = []hefloat.ParametersLiteral{HEFloatComplexParamsN12QP109, HEFloatComplexParamsN13QP218, HEFloatComplexParamsN14QP438, HEFloatComplexParamsN15QP881, HEFloatComplexParamsPN16QP1761}
@@ -0,0 +1,256 @@
+package examples
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+// InterpolatePolynomial returns the coefficients, by increasing degree, of the polynomial over Z_T of degree below
+// len(points) that maps points[k] to values[k]. T must be prime and the points distinct modulo T.
+func InterpolatePolynomial(T uint64, points, values []uint64) ([]uint64, error) {
+	if len(points) != len(values) {
+		return nil, fmt.Errorf("cannot InterpolatePolynomial: %d points but %d values", len(points), len(values))
+	}
+	n := len(points)
+	// Newton divided differences, then expansion of the Newton form from the highest degree down
+	dd := make([]uint64, n)
+	for k := range dd {
+		dd[k] = values[k] % T
+	}
+	for j := 1; j < n; j++ {
+		for k := n - 1; k >= j; k-- {
+			den := subMod(points[k]%T, points[k-j]%T, T)
+			if den == 0 {
+				return nil, fmt.Errorf("cannot InterpolatePolynomial: points %d and %d are equal modulo %d", k-j, k, T)
+			}
+			dd[k] = mulMod(subMod(dd[k], dd[k-1], T), invMod(den, T), T)
+		}
+	}
+	coeffs := make([]uint64, n)
+	for j := n - 1; j >= 0; j-- {
+		// coeffs = coeffs * (X - points[j]) + dd[j]
+		for k := n - 1; k > 0; k-- {
+			coeffs[k] = subMod(coeffs[k-1], mulMod(coeffs[k], points[j]%T, T), T)
+		}
+		coeffs[0] = subMod(dd[j], mulMod(coeffs[0], points[j]%T, T), T)
+	}
+	return coeffs, nil
+}
+
+// EvaluatePolynomialMod returns the evaluation at x of the polynomial over Z_T with the given coefficients.
+func EvaluatePolynomialMod(coeffs []uint64, x, T uint64) (y uint64) {
+	for k := len(coeffs) - 1; k >= 0; k-- {
+		y = addMod(mulMod(y, x%T, T), coeffs[k]%T, T)
+	}
+	return
+}
+
+// EqualPolynomial returns the coefficients of the polynomial over Z_T that maps a difference d = x - y to one if
+// d = 0 and to zero otherwise, for x and y in [0, bound). If bound is 0, x and y are any elements of Z_T and the
+// polynomial is 1 - d^(T-1), of degree T-1.
+func EqualPolynomial(T, bound uint64) ([]uint64, error) {
+	if bound == 0 {
+		coeffs := make([]uint64, T)
+		coeffs[0], coeffs[T-1] = 1, T-1
+		return coeffs, nil
+	}
+	return differencePolynomial(T, bound, func(d int64) bool { return d == 0 })
+}
+
+// LessThanPolynomial returns the coefficients of the polynomial over Z_T that maps a difference d = x - y to one if
+// x < y and to zero otherwise, for x and y in [0, bound).
+func LessThanPolynomial(T, bound uint64) ([]uint64, error) {
+	if bound == 0 {
+		return nil, fmt.Errorf("cannot LessThanPolynomial: the bound must be positive")
+	}
+	return differencePolynomial(T, bound, func(d int64) bool { return d < 0 })
+}
+
+// differencePolynomial interpolates the indicator of f over the differences (-bound, bound), which must be distinct
+// modulo T. The polynomial has degree 2*bound-2.
+func differencePolynomial(T, bound uint64, f func(d int64) bool) ([]uint64, error) {
+	if 2*bound-1 > T {
+		return nil, fmt.Errorf("cannot interpolate over [0, %d): the differences are not distinct modulo %d, the bound must be at most %d", bound, T, (T+1)/2)
+	}
+	points := make([]uint64, 0, 2*bound-1)
+	values := make([]uint64, 0, 2*bound-1)
+	for d := -int64(bound) + 1; d < int64(bound); d++ {
+		points = append(points, uint64((d+int64(T))%int64(T)))
+		if f(d) {
+			values = append(values, 1)
+		} else {
+			values = append(values, 0)
+		}
+	}
+	return InterpolatePolynomial(T, points, values)
+}
+
+// PolynomialDepth returns the number of levels consumed by the evaluation of a polynomial of the given degree:
+// ceil(log2(degree)).
+func PolynomialDepth(degree int) int {
+	if degree < 2 {
+		return 0
+	}
+	return bits.Len(uint(degree - 1))
+}
+
+// PolynomialEvaluator evaluates polynomials over Z_T, the plaintext modulus, on heint ciphertexts under a collective
+// key. The powers are computed in a tree of depth PolynomialDepth, each product being relinearized with the
+// collective relinearization key and rescaled; only the powers with a non-zero coefficient and the powers they are
+// computed from are computed, so sparse polynomials of high degree, such as 1 - X^(T-1), are cheap.
+type PolynomialEvaluator struct {
+	params  heint.Parameters
+	eval    *heint.Evaluator
+	encoder *heint.Encoder
+}
+
+// NewPolynomialEvaluator returns a PolynomialEvaluator using the collective relinearization key rlk.
+func NewPolynomialEvaluator(params heint.Parameters, rlk *rlwe.RelinearizationKey) *PolynomialEvaluator {
+	return &PolynomialEvaluator{params: params, eval: heint.NewEvaluator(params, rlwe.NewMemEvaluationKeySet(rlk)), encoder: heint.NewEncoder(params)}
+}
+
+// Evaluate returns the encrypted evaluation of the polynomial with the given coefficients, by increasing degree, on
+// every slot of ct. It returns an error if ct has fewer levels than the polynomial needs.
+func (p *PolynomialEvaluator) Evaluate(ct *rlwe.Ciphertext, coeffs []uint64) (*rlwe.Ciphertext, error) {
+	T := p.params.PlaintextModulus()
+	degree := len(coeffs) - 1
+	for degree > 0 && coeffs[degree]%T == 0 {
+		degree--
+	}
+	if degree < 0 {
+		return nil, fmt.Errorf("cannot Evaluate: no coefficients")
+	}
+	if depth := PolynomialDepth(degree); depth > ct.Level() {
+		return nil, fmt.Errorf("cannot Evaluate: a polynomial of degree %d needs %d levels, the ciphertext has %d; "+
+			"use parameters with at least %d moduli in LogQ", degree, depth, ct.Level(), depth+1)
+	}
+
+	powers := map[int]*rlwe.Ciphertext{1: ct}
+	var res *rlwe.Ciphertext
+	for k := 1; k <= degree; k++ {
+		if coeffs[k]%T == 0 {
+			continue
+		}
+		xk, err := p.power(powers, k)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+		term, err := p.mulConstant(xk, coeffs[k]%T)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+		if res == nil {
+			res = term
+		} else if err = p.eval.Add(res, term, res); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+	}
+	if res == nil {
+		// a constant polynomial: 0*ct + c
+		var err error
+		if res, err = p.mulConstant(ct, 0); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+	}
+	if c := coeffs[0] % T; c != 0 {
+		pt, err := p.constant(c, res)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+		if err = p.eval.Add(res, pt, res); err != nil {
+			return nil, fmt.Errorf("cannot Evaluate: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// power returns X^k, computed as X^hi * X^(k-hi) with hi the largest power of two below k, so that X^k is at depth
+// ceil(log2(k)).
+func (p *PolynomialEvaluator) power(powers map[int]*rlwe.Ciphertext, k int) (*rlwe.Ciphertext, error) {
+	if xk, ok := powers[k]; ok {
+		return xk, nil
+	}
+	hi := 1 << (bits.Len(uint(k-1)) - 1)
+	a, err := p.power(powers, hi)
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.power(powers, k-hi)
+	if err != nil {
+		return nil, err
+	}
+	if a.Level() != b.Level() {
+		level := min(a.Level(), b.Level())
+		a, b = a.CopyNew(), b.CopyNew()
+		a.Resize(a.Degree(), level)
+		b.Resize(b.Degree(), level)
+	}
+	xk, err := p.eval.MulRelinNew(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.eval.Rescale(xk, xk); err != nil {
+		return nil, err
+	}
+	powers[k] = xk
+	return xk, nil
+}
+
+// mulConstant returns c*ct.
+func (p *PolynomialEvaluator) mulConstant(ct *rlwe.Ciphertext, c uint64) (*rlwe.Ciphertext, error) {
+	pt := heint.NewPlaintext(p.params, ct.Level())
+	values := make([]uint64, p.params.MaxSlots())
+	for k := range values {
+		values[k] = c
+	}
+	if err := p.encoder.Encode(values, pt); err != nil {
+		return nil, err
+	}
+	return p.eval.MulNew(ct, pt)
+}
+
+// constant returns the plaintext of c in every slot, at the level and the scale of ct.
+func (p *PolynomialEvaluator) constant(c uint64, ct *rlwe.Ciphertext) (*rlwe.Plaintext, error) {
+	pt := heint.NewPlaintext(p.params, ct.Level())
+	pt.Scale = ct.Scale
+	values := make([]uint64, p.params.MaxSlots())
+	for k := range values {
+		values[k] = c
+	}
+	return pt, p.encoder.Encode(values, pt)
+}
+
+func addMod(a, b, T uint64) uint64 {
+	s := a + b
+	if s >= T || s < a {
+		s -= T
+	}
+	return s
+}
+
+func subMod(a, b, T uint64) uint64 {
+	if a >= b {
+		return a - b
+	}
+	return a + (T - b)
+}
+
+func mulMod(a, b, T uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, T)
+}
+
+// invMod returns a^(T-2) mod T, the inverse of a modulo the prime T.
+func invMod(a, T uint64) uint64 {
+	r, e := uint64(1), T-2
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = mulMod(r, a, T)
+		}
+		a = mulMod(a, a, T)
+	}
+	return r
+}
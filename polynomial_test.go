@@ -0,0 +1,124 @@
+package examples
+
+import (
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+func TestPolynomial(t *testing.T) {
+	const T = 0x10001
+	points, values := []uint64{0, 1, 2, T - 1}, []uint64{5, 0, 7, 3}
+	coeffs, err := InterpolatePolynomial(T, points, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range points {
+		if y := EvaluatePolynomialMod(coeffs, points[k], T); y != values[k] {
+			t.Errorf("interpolated polynomial at %d: %d, want %d", points[k], y, values[k])
+		}
+	}
+	if _, err := InterpolatePolynomial(T, []uint64{1, T + 1}, []uint64{0, 1}); err == nil {
+		t.Error("InterpolatePolynomial: equal points: no error")
+	}
+
+	// every pair of inputs in [0, bound), and the full domain of the equality for bound 0
+	const bound = 4
+	equal, err := EqualPolynomial(T, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	less, err := LessThanPolynomial(T, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fermat, err := EqualPolynomial(T, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := uint64(0); x < bound; x++ {
+		for y := uint64(0); y < bound; y++ {
+			d := (x + T - y) % T
+			if got, want := EvaluatePolynomialMod(equal, d, T), b2u(x == y); got != want {
+				t.Errorf("equal(%d, %d) = %d, want %d", x, y, got, want)
+			}
+			if got, want := EvaluatePolynomialMod(less, d, T), b2u(x < y); got != want {
+				t.Errorf("less(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+	for _, d := range []uint64{0, 1, 1234, T - 1} {
+		if got, want := EvaluatePolynomialMod(fermat, d, T), b2u(d == 0); got != want {
+			t.Errorf("1 - d^(T-1) at %d: %d, want %d", d, got, want)
+		}
+	}
+	if _, err := LessThanPolynomial(T, (T+3)/2); err == nil {
+		t.Error("LessThanPolynomial: bound above (T+1)/2: no error")
+	}
+
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN13QP218)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kgen := rlwe.NewKeyGenerator(params)
+	sk := kgen.GenSecretKeyNew()
+	eval := NewPolynomialEvaluator(params, kgen.GenRelinearizationKeyNew(sk))
+	encoder := heint.NewEncoder(params)
+	prng, err := sampling.NewKeyedPRNG([]byte("polynomial"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2*params.MaxSlots())
+	if _, err := prng.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	// the differences x - y of random inputs in [0, bound)
+	x, y := make([]uint64, params.MaxSlots()), make([]uint64, params.MaxSlots())
+	diff := make([]uint64, params.MaxSlots())
+	for k := range diff {
+		x[k], y[k] = uint64(buf[2*k])%bound, uint64(buf[2*k+1])%bound
+		diff[k] = (x[k] + T - y[k]) % T
+	}
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	if err := encoder.Encode(diff, pt); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := rlwe.NewEncryptor(params, sk).EncryptNew(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		coeffs []uint64
+		f      func(x, y uint64) bool
+	}{
+		"equal": {equal, func(x, y uint64) bool { return x == y }},
+		"less":  {less, func(x, y uint64) bool { return x < y }},
+	} {
+		res, err := eval.Evaluate(ct, tc.coeffs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]uint64, params.MaxSlots())
+		if err := encoder.Decode(rlwe.NewDecryptor(params, sk).DecryptNew(res), got); err != nil {
+			t.Fatal(err)
+		}
+		for k := range got {
+			if want := b2u(tc.f(x[k], y[k])); got[k] != want {
+				t.Fatalf("%s(%d, %d) in slot %d: decrypted %d, want %d", name, x[k], y[k], k, got[k], want)
+			}
+		}
+	}
+	// the degree T-1 of the full-domain equality needs 16 levels
+	if _, err := eval.Evaluate(ct, fermat); err == nil {
+		t.Errorf("Evaluate: degree %d with %d levels: no error", T-1, ct.Level())
+	}
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,172 @@
+package examples
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// The ways of combining the parties' encrypted set indicators in a private set intersection.
+const (
+	// PSISum subtracts the number of parties from the sum of the indicators and multiplies the result by a random
+	// non-zero mask of every party: a slot decrypts to zero if and only if every party holds its element, and to a
+	// uniform value otherwise. It needs no relinearization key and no level.
+	PSISum = "sum"
+	// PSIProduct multiplies the indicators: a slot decrypts to one if and only if every party holds its element, and
+	// to zero otherwise. It needs the collective relinearization key and ceil(log2(parties)) levels.
+	PSIProduct = "product"
+)
+
+// EncodeSet returns the indicator vector of set over the slots: one in the slot of each element, zero elsewhere.
+// The elements are slot indices, in [0, slots).
+func EncodeSet(slots int, set []uint64) ([]uint64, error) {
+	indicator := make([]uint64, slots)
+	for _, e := range set {
+		if e >= uint64(slots) {
+			return nil, fmt.Errorf("cannot EncodeSet: element %d is not in [0, %d)", e, slots)
+		}
+		indicator[e] = 1
+	}
+	return indicator, nil
+}
+
+// PSIProductDepth returns the number of levels PSIProduct consumes for the given number of parties.
+func PSIProductDepth(parties int) int {
+	if parties < 2 {
+		return 0
+	}
+	return bits.Len(uint(parties - 1))
+}
+
+// PSIEvaluator combines the parties' encrypted set indicators.
+type PSIEvaluator struct {
+	params  heint.Parameters
+	eval    *heint.Evaluator
+	encoder *heint.Encoder
+	rlk     bool
+}
+
+// NewPSIEvaluator returns a PSIEvaluator. The collective relinearization key rlk is only needed by Product and may
+// be nil otherwise.
+func NewPSIEvaluator(params heint.Parameters, rlk *rlwe.RelinearizationKey) *PSIEvaluator {
+	var evk rlwe.EvaluationKeySet
+	if rlk != nil {
+		evk = rlwe.NewMemEvaluationKeySet(rlk)
+	}
+	return &PSIEvaluator{params: params, eval: heint.NewEvaluator(params, evk), encoder: heint.NewEncoder(params), rlk: rlk != nil}
+}
+
+// SumEqualsN returns the encrypted sum of the indicators minus their number. It must be masked by every party, see
+// Mask, before it is decrypted: unmasked, it reveals how many parties hold each element.
+func (p *PSIEvaluator) SumEqualsN(cts []*rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	if len(cts) == 0 {
+		return nil, fmt.Errorf("cannot SumEqualsN: no inputs")
+	}
+	sum := cts[0].CopyNew()
+	for i, ct := range cts[1:] {
+		if err := p.eval.Add(sum, ct, sum); err != nil {
+			return nil, fmt.Errorf("cannot SumEqualsN: input %d: %w", i+1, err)
+		}
+	}
+	n := make([]uint64, p.params.MaxSlots())
+	for k := range n {
+		n[k] = uint64(len(cts)) % p.params.PlaintextModulus()
+	}
+	pt := heint.NewPlaintext(p.params, sum.Level())
+	pt.Scale = sum.Scale
+	if err := p.encoder.Encode(n, pt); err != nil {
+		return nil, fmt.Errorf("cannot SumEqualsN: %w", err)
+	}
+	if err := p.eval.Sub(sum, pt, sum); err != nil {
+		return nil, fmt.Errorf("cannot SumEqualsN: %w", err)
+	}
+	return sum, nil
+}
+
+// Mask multiplies ct, slot by slot, by a uniform non-zero mask drawn from prng. Each party masks with its own PRNG,
+// so that the mask is unknown unless every party colludes.
+func (p *PSIEvaluator) Mask(ct *rlwe.Ciphertext, prng sampling.PRNG) error {
+	T := p.params.PlaintextModulus()
+	mask := make([]uint64, p.params.MaxSlots())
+	buf := make([]byte, 8)
+	for k := range mask {
+		if _, err := prng.Read(buf); err != nil {
+			return fmt.Errorf("cannot Mask: %w", err)
+		}
+		mask[k] = binary.LittleEndian.Uint64(buf)%(T-1) + 1
+	}
+	pt := heint.NewPlaintext(p.params, ct.Level())
+	if err := p.encoder.Encode(mask, pt); err != nil {
+		return fmt.Errorf("cannot Mask: %w", err)
+	}
+	if err := p.eval.Mul(ct, pt, ct); err != nil {
+		return fmt.Errorf("cannot Mask: %w", err)
+	}
+	return nil
+}
+
+// Product returns the encrypted product of the indicators, multiplied pairwise in a binary tree of depth
+// PSIProductDepth, each product being relinearized and rescaled.
+func (p *PSIEvaluator) Product(cts []*rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	if len(cts) == 0 {
+		return nil, fmt.Errorf("cannot Product: no inputs")
+	}
+	if !p.rlk {
+		return nil, fmt.Errorf("cannot Product: no relinearization key")
+	}
+	if depth := PSIProductDepth(len(cts)); depth > cts[0].Level() {
+		return nil, fmt.Errorf("cannot Product: %d parties need %d levels, the ciphertexts have %d", len(cts), depth, cts[0].Level())
+	}
+	layer := make([]*rlwe.Ciphertext, len(cts))
+	for i, ct := range cts {
+		layer[i] = ct.CopyNew()
+	}
+	for len(layer) > 1 {
+		next := make([]*rlwe.Ciphertext, 0, (len(layer)+1)/2)
+		for i := 0; i+1 < len(layer); i += 2 {
+			a, b := layer[i], layer[i+1]
+			// an odd ciphertext carried from a previous layer is at a higher level
+			level := min(a.Level(), b.Level())
+			a.Resize(a.Degree(), level)
+			b.Resize(b.Degree(), level)
+			prod, err := p.eval.MulRelinNew(a, b)
+			if err != nil {
+				return nil, fmt.Errorf("cannot Product: %w", err)
+			}
+			if err = p.eval.Rescale(prod, prod); err != nil {
+				return nil, fmt.Errorf("cannot Product: %w", err)
+			}
+			next = append(next, prod)
+		}
+		if len(layer)%2 == 1 {
+			next = append(next, layer[len(layer)-1])
+		}
+		layer = next
+	}
+	return layer[0], nil
+}
+
+// Intersection returns the slots of the decrypted combination that hold an element of the intersection: the zero
+// slots for PSISum, the slots equal to one for PSIProduct.
+func Intersection(mode string, values []uint64) ([]uint64, error) {
+	var want uint64
+	switch mode {
+	case PSISum:
+		want = 0
+	case PSIProduct:
+		want = 1
+	default:
+		return nil, fmt.Errorf("cannot Intersection: invalid mode %q, expected %q or %q", mode, PSISum, PSIProduct)
+	}
+	var elements []uint64
+	for k, v := range values {
+		if v == want {
+			elements = append(elements, uint64(k))
+		}
+	}
+	return elements, nil
+}
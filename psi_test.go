@@ -0,0 +1,35 @@
+package examples
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPSI(t *testing.T) {
+	for parties, depth := range map[int]int{1: 0, 2: 1, 3: 2, 4: 2, 5: 3, 16: 4, 17: 5} {
+		if got := PSIProductDepth(parties); got != depth {
+			t.Errorf("PSIProductDepth(%d) = %d, want %d", parties, got, depth)
+		}
+	}
+
+	indicator, err := EncodeSet(6, []uint64{4, 1, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{0, 1, 0, 0, 1, 0}; !reflect.DeepEqual(indicator, want) {
+		t.Errorf("EncodeSet: %v, want %v", indicator, want)
+	}
+	if _, err := EncodeSet(6, []uint64{6}); err == nil {
+		t.Error("EncodeSet: element outside the slots: no error")
+	}
+
+	values := []uint64{0, 1, 7, 0, 1}
+	for mode, want := range map[string][]uint64{PSISum: {0, 3}, PSIProduct: {1, 4}} {
+		if got, err := Intersection(mode, values); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Intersection(%q) = %v, %v, want %v", mode, got, err, want)
+		}
+	}
+	if _, err := Intersection("xor", values); err == nil {
+		t.Error("Intersection: invalid mode: no error")
+	}
+}
@@ -0,0 +1,311 @@
+package examples
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/hefloat"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+// MultipartyRequirements describes a multiparty computation for which parameters are recommended.
+type MultipartyRequirements struct {
+	// Parties is the total number of parties N.
+	Parties int
+
+	// Threshold is the number of parties t needed to decrypt, 0 for N-out-of-N.
+	Threshold int
+
+	// MultiKey is true when the inputs are encrypted under per-party keys (programs without CRS)
+	// rather than under a collective key.
+	MultiKey bool
+
+	// Inputs is the number of ciphertexts summed before decryption, 0 for one per party.
+	Inputs int
+
+	// Depth is the multiplicative depth of the computation.
+	Depth int
+
+	// PlaintextModulus is the plaintext modulus t of `heint`; it is ignored by RecommendHEFloatParameters.
+	PlaintextModulus uint64
+
+	// LogPrecision is the number of bits of precision required after decryption by `hefloat`;
+	// it is ignored by RecommendHEIntParameters.
+	LogPrecision int
+
+	// Security is the target security in bits: 128, 192 or 256.
+	Security int
+
+	// SmudgingSigma is the standard deviation of the noise each party adds to its partial decryption, 0 if none.
+	SmudgingSigma float64
+}
+
+// Recommendation is a parameter literal together with an explanation of how it was chosen.
+type Recommendation[T heint.ParametersLiteral | hefloat.ParametersLiteral] struct {
+	Literal     T
+	Explanation []string
+}
+
+func (r Recommendation[T]) String() (s string) {
+	for _, line := range r.Explanation {
+		s += line + "\n"
+	}
+	return
+}
+
+const (
+	minRecommendedLogN = 12
+	maxRecommendedLogN = 16
+	maxLogModulus      = 60
+	noiseMarginBits    = 2
+)
+
+// RecommendHEIntParameters returns the `heint` parameters with the smallest ring degree that decrypt the
+// computation described by req correctly, according to NoiseModel, at the target security.
+//
+// The first prime holds the decrypted noise: log q0 >= 1 + log t + noise + margin, split over several primes if
+// needed. Each multiplicative level adds a prime of log t plus the rescaling noise, which grows with the square
+// root of the number of aggregated keys.
+func RecommendHEIntParameters(req MultipartyRequirements) (rec Recommendation[heint.ParametersLiteral], err error) {
+	if err = req.check(); err != nil {
+		return rec, fmt.Errorf("cannot RecommendHEIntParameters: %w", err)
+	}
+	if req.PlaintextModulus < 2 {
+		return rec, fmt.Errorf("cannot RecommendHEIntParameters: invalid plaintext modulus %d", req.PlaintextModulus)
+	}
+
+	logT := math.Log2(float64(req.PlaintextModulus))
+
+	for logN := minRecommendedLogN; logN <= maxRecommendedLogN; logN++ {
+		noise := req.predict(logN)
+
+		logQ := splitModulus(1 + logT + noise.Decrypted + noiseMarginBits)
+		for i := 0; i < req.Depth; i++ {
+			logQ = append(logQ, int(math.Ceil(logT+req.rescalingNoise(logN)+1)))
+		}
+		logP := specialPrimes(logQ)
+
+		literal := heint.ParametersLiteral{
+			LogN:             logN,
+			LogQ:             logQ,
+			LogP:             logP,
+			PlaintextModulus: req.PlaintextModulus,
+		}
+
+		logQP := sum(logQ) + sum(logP)
+		maxLogQP, ok := HEStandardMaxLogQP(req.Security, logN)
+		if !ok || logQP > maxLogQP {
+			continue
+		}
+
+		params, err := heint.NewParametersFromLiteral(literal)
+		if err != nil {
+			continue
+		}
+
+		rec.Literal = literal
+		rec.Explanation = append(req.explain(logN, noise),
+			fmt.Sprintf("plaintext modulus t=%d (%.2f bits): log q0 >= 1 + log t + noise + %d bits of margin", req.PlaintextModulus, logT, noiseMarginBits))
+		if req.Depth > 0 {
+			rec.Explanation = append(rec.Explanation,
+				fmt.Sprintf("%d multiplicative level(s) of %d bits: log t + rescaling noise 2^%.2f + 1", req.Depth, logQ[len(logQ)-1], req.rescalingNoise(logN)))
+		}
+		if slots := params.LogMaxSlots(); slots < logN {
+			rec.Explanation = append(rec.Explanation,
+				fmt.Sprintf("t-1 is not divisible by 2N: only 2^%d slots are available", slots))
+		}
+		rec.Explanation = append(rec.Explanation, req.explainSecurity(logN, logQP, maxLogQP))
+		return rec, nil
+	}
+
+	return rec, fmt.Errorf("cannot RecommendHEIntParameters: no ring degree up to 2^%d fits %d-bit security", maxRecommendedLogN, req.Security)
+}
+
+// RecommendHEFloatParameters returns the `hefloat` parameters with the smallest ring degree that decrypt the
+// computation described by req with req.LogPrecision bits of precision, according to NoiseModel, at the
+// target security.
+//
+// The default scale is the decrypted noise plus the required precision; the first prime leaves headroom for
+// the magnitude of the messages and each multiplicative level consumes one prime of the size of the scale.
+func RecommendHEFloatParameters(req MultipartyRequirements) (rec Recommendation[hefloat.ParametersLiteral], err error) {
+	if err = req.check(); err != nil {
+		return rec, fmt.Errorf("cannot RecommendHEFloatParameters: %w", err)
+	}
+	if req.LogPrecision < 1 {
+		return rec, fmt.Errorf("cannot RecommendHEFloatParameters: invalid precision %d", req.LogPrecision)
+	}
+
+	for logN := minRecommendedLogN; logN <= maxRecommendedLogN; logN++ {
+		noise := req.predict(logN)
+
+		logScale := int(math.Ceil(noise.Decrypted)) + req.LogPrecision
+		if logScale > maxLogModulus-hefloatHeadroomBits {
+			return rec, fmt.Errorf("cannot RecommendHEFloatParameters: a scale of 2^%d does not fit a single prime", logScale)
+		}
+
+		logQ := []int{logScale + hefloatHeadroomBits}
+		for i := 0; i < req.Depth; i++ {
+			logQ = append(logQ, logScale)
+		}
+		logP := specialPrimes(logQ)
+
+		literal := hefloat.ParametersLiteral{
+			LogN:            logN,
+			LogQ:            logQ,
+			LogP:            logP,
+			LogDefaultScale: logScale,
+		}
+
+		logQP := sum(logQ) + sum(logP)
+		maxLogQP, ok := HEStandardMaxLogQP(req.Security, logN)
+		if !ok || logQP > maxLogQP {
+			continue
+		}
+
+		if _, err := hefloat.NewParametersFromLiteral(literal); err != nil {
+			continue
+		}
+
+		rec.Literal = literal
+		rec.Explanation = append(req.explain(logN, noise),
+			fmt.Sprintf("default scale 2^%d: noise 2^%.2f plus %d bits of precision", logScale, noise.Decrypted, req.LogPrecision),
+			fmt.Sprintf("first prime of %d bits: scale plus %d bits of headroom for the messages", logQ[0], hefloatHeadroomBits))
+		if req.Depth > 0 {
+			rec.Explanation = append(rec.Explanation,
+				fmt.Sprintf("%d multiplicative level(s) of %d bits, one rescale each", req.Depth, logScale))
+		}
+		rec.Explanation = append(rec.Explanation, req.explainSecurity(logN, logQP, maxLogQP))
+		return rec, nil
+	}
+
+	return rec, fmt.Errorf("cannot RecommendHEFloatParameters: no ring degree up to 2^%d fits %d-bit security", maxRecommendedLogN, req.Security)
+}
+
+// hefloatHeadroomBits is the number of bits the first prime of `hefloat` leaves above the scale.
+const hefloatHeadroomBits = 10
+
+func (req MultipartyRequirements) check() error {
+	if req.Parties < 1 {
+		return fmt.Errorf("invalid number of parties %d", req.Parties)
+	}
+	if req.Threshold < 0 || req.Threshold > req.Parties {
+		return fmt.Errorf("invalid threshold %d for %d parties", req.Threshold, req.Parties)
+	}
+	if req.Depth < 0 {
+		return fmt.Errorf("invalid depth %d", req.Depth)
+	}
+	if _, ok := HEStandardMaxLogQP(req.Security, minRecommendedLogN); !ok {
+		return fmt.Errorf("unsupported security level %d", req.Security)
+	}
+	return nil
+}
+
+// decryptors is the number of parties taking part in a decryption.
+func (req MultipartyRequirements) decryptors() int {
+	if req.Threshold > 0 {
+		return req.Threshold
+	}
+	return req.Parties
+}
+
+// keys is the number of secret keys aggregated into the key an input is encrypted under.
+func (req MultipartyRequirements) keys() int {
+	if req.MultiKey {
+		return 1
+	}
+	return req.decryptors()
+}
+
+func (req MultipartyRequirements) inputs() int {
+	if req.Inputs > 0 {
+		return req.Inputs
+	}
+	return req.Parties
+}
+
+func (req MultipartyRequirements) predict(logN int) NoisePrediction {
+	return predictNoise(logN, rlwe.DefaultNoise, defaultSecretVariance, req.keys(), req.decryptors(), req.SmudgingSigma, req.inputs())
+}
+
+// rescalingNoise is the log2 of the bound on the rounding noise t*<u, s> introduced by a rescaling,
+// where s is the sum of the aggregated keys.
+func (req MultipartyRequirements) rescalingNoise(logN int) float64 {
+	n := math.Exp2(float64(logN))
+	return math.Log2(noiseTail(logN) * math.Sqrt(n*float64(req.decryptors())*defaultSecretVariance/12))
+}
+
+func (req MultipartyRequirements) explain(logN int, noise NoisePrediction) []string {
+	lines := []string{
+		fmt.Sprintf("LogN=%d is the smallest ring degree that fits the modulus at %d-bit security", logN, req.Security),
+		fmt.Sprintf("%d parties, %d decrypting, %d input(s) summed: predicted noise fresh 2^%.2f, summed 2^%.2f, decrypted 2^%.2f",
+			req.Parties, req.decryptors(), req.inputs(), noise.Fresh, noise.Computed, noise.Decrypted),
+	}
+	switch {
+	case req.MultiKey:
+		lines = append(lines, "multi-key: each input is under a single party's key, partial decryptions add up")
+	case req.Threshold > 0:
+		lines = append(lines, "threshold: the key aggregates the t additive shares obtained with Lagrange coefficients from exact Shamir shares, so the coefficients do not scale the noise")
+	default:
+		lines = append(lines, "collective key: the public key aggregates the noise of all N shares")
+	}
+	if req.SmudgingSigma > 0 {
+		lines = append(lines, fmt.Sprintf("smudging: each partial decryption adds noise of standard deviation 2^%.2f", math.Log2(req.SmudgingSigma)))
+	}
+	return lines
+}
+
+func (req MultipartyRequirements) explainSecurity(logN, logQP, maxLogQP int) string {
+	return fmt.Sprintf("logQP=%d <= %d, the HE standard bound for ternary secrets at LogN=%d and %d-bit security", logQP, maxLogQP, logN, req.Security)
+}
+
+// splitModulus splits a modulus of the given number of bits into primes of at most maxLogModulus bits.
+func splitModulus(bits float64) []int {
+	n := int(math.Ceil(bits / maxLogModulus))
+	size := int(math.Ceil(bits / float64(n)))
+	logQ := make([]int, n)
+	for i := range logQ {
+		logQ[i] = size
+	}
+	return logQ
+}
+
+// specialPrimes returns the key-switching primes: one prime, one bit larger than the largest prime of Q,
+// for every six primes of Q.
+func specialPrimes(logQ []int) []int {
+	var largest int
+	for _, qi := range logQ {
+		largest = max(largest, qi)
+	}
+	logP := make([]int, (len(logQ)+5)/6)
+	for i := range logP {
+		logP[i] = min(largest+1, maxLogModulus+1)
+	}
+	return logP
+}
+
+func sum(v []int) (s int) {
+	for _, vi := range v {
+		s += vi
+	}
+	return
+}
+
+// heStandardMaxLogQP is the maximum logQP of the Homomorphic Encryption Standard (ternary secrets, classical
+// attacks) indexed by security and logN. The standard stops at LogN=15: the LogN=16 row is not part of it, but
+// extrapolated by doubling the LogN=15 row.
+var heStandardMaxLogQP = map[int]map[int]int{
+	128: {10: 27, 11: 54, 12: 109, 13: 218, 14: 438, 15: 881, 16: 1761},
+	192: {10: 19, 11: 37, 12: 75, 13: 152, 14: 305, 15: 611, 16: 1222},
+	256: {10: 14, 11: 29, 12: 58, 13: 118, 14: 237, 15: 476, 16: 952},
+}
+
+// HEStandardMaxLogQP returns the maximum logQP of the Homomorphic Encryption Standard for the given security and logN.
+func HEStandardMaxLogQP(security, logN int) (maxLogQP int, ok bool) {
+	bounds, ok := heStandardMaxLogQP[security]
+	if !ok {
+		return 0, false
+	}
+	maxLogQP, ok = bounds[logN]
+	return
+}
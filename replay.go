@@ -0,0 +1,264 @@
+package examples
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// Deviation is a transcript entry that does not match the value the replay expects.
+type Deviation struct {
+	Entry  TranscriptEntry
+	Reason string
+}
+
+func (d Deviation) String() string {
+	return fmt.Sprintf("%s: %s", d.Entry, d.Reason)
+}
+
+// ReplayReport is the outcome of ReplayTranscript.
+type ReplayReport struct {
+	Rounds     int // number of rounds replayed
+	Checks     int // number of entries checked
+	Deviations []Deviation
+}
+
+// First returns the deviation that comes first in the transcript, if any.
+func (r ReplayReport) First() (d Deviation, ok bool) {
+	for _, di := range r.Deviations {
+		if !ok || di.Entry.Seq < d.Entry.Seq {
+			d, ok = di, true
+		}
+	}
+	return
+}
+
+// ReplayTranscript re-executes the aggregations and decryptions of a `heint` transcript and reports every entry that
+// deviates from the value recomputed from the entries before it.
+//
+// A public-key round is replayed by re-sampling the CRP from the recorded seed, aggregating the recorded shares and
+// comparing the public key. A decryption round is replayed by aggregating the recorded partial decryptions and
+// finishing the decryption of the recorded ciphertext. The aggregated values only tell that a round went wrong; when
+// the secret keys are in the transcript, each share is also checked on its own, which pinpoints the party at fault:
+// a public-key share must be -s*a + e with e below the bound of NoiseModel, and a partial decryption must be equal
+// to the one recomputed with the party's key.
+func ReplayTranscript(entries []TranscriptEntry) (report ReplayReport, err error) {
+	var params heint.Parameters
+	var paramsFound bool
+	sks := map[int]*rlwe.SecretKey{}
+
+	var rounds []string
+	byRound := map[string][]TranscriptEntry{}
+
+	for _, e := range entries {
+		switch e.Kind {
+		case TranscriptPhase:
+		case TranscriptParams:
+			var config ParametersConfig
+			if err = json.Unmarshal(e.Data, &config); err != nil {
+				return report, fmt.Errorf("cannot ReplayTranscript: %s: %w", e, err)
+			}
+			pl, err := config.HEIntLiteral()
+			if err != nil {
+				return report, fmt.Errorf("cannot ReplayTranscript: %s: %w", e, err)
+			}
+			if params, err = heint.NewParametersFromLiteral(pl); err != nil {
+				return report, fmt.Errorf("cannot ReplayTranscript: %s: %w", e, err)
+			}
+			paramsFound = true
+		case TranscriptSecretKey:
+			if !paramsFound {
+				return report, fmt.Errorf("cannot ReplayTranscript: %s before the parameters", e)
+			}
+			sk := rlwe.NewSecretKey(params)
+			if err = sk.UnmarshalBinary(e.Data); err != nil {
+				return report, fmt.Errorf("cannot ReplayTranscript: %s: %w", e, err)
+			}
+			sks[e.Party] = sk
+		default:
+			if _, ok := byRound[e.Round]; !ok {
+				rounds = append(rounds, e.Round)
+			}
+			byRound[e.Round] = append(byRound[e.Round], e)
+		}
+	}
+
+	if !paramsFound {
+		return report, fmt.Errorf("cannot ReplayTranscript: the transcript does not record the parameters")
+	}
+
+	r := replayer{params: params, sks: sks, report: &report}
+	for _, round := range rounds {
+		if err = r.round(byRound[round]); err != nil {
+			return report, fmt.Errorf("cannot ReplayTranscript: round %q: %w", round, err)
+		}
+		report.Rounds++
+	}
+	return
+}
+
+type replayer struct {
+	params heint.Parameters
+	sks    map[int]*rlwe.SecretKey
+	report *ReplayReport
+}
+
+func (r replayer) deviate(e TranscriptEntry, format string, args ...interface{}) {
+	r.report.Deviations = append(r.report.Deviations, Deviation{Entry: e, Reason: fmt.Sprintf(format, args...)})
+}
+
+// check compares the recorded data of e with the encoding of the recomputed value.
+func (r replayer) check(e TranscriptEntry, recomputed interface{ MarshalBinary() ([]byte, error) }, what string) error {
+	data, err := recomputed.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r.report.Checks++
+	if !bytes.Equal(data, e.Data) {
+		r.deviate(e, "differs from the %s", what)
+	}
+	return nil
+}
+
+func (r replayer) round(entries []TranscriptEntry) error {
+	for _, e := range entries {
+		switch e.Kind {
+		case TranscriptCRSSeed, TranscriptPublicKeyShare, TranscriptPublicKey:
+			return r.publicKeyRound(entries)
+		case TranscriptCiphertext, TranscriptPartialDecryption, TranscriptDecryptionSum, TranscriptDecryption:
+			return r.decryptionRound(entries)
+		}
+	}
+	return nil
+}
+
+func (r replayer) publicKeyRound(entries []TranscriptEntry) error {
+	ckg := mhe.NewPublicKeyGenProtocol(r.params)
+
+	var crp mhe.PublicKeyGenCRP
+	var crpFound bool
+	agg := ckg.AllocateShare()
+
+	for _, e := range entries {
+		switch e.Kind {
+		case TranscriptCRSSeed:
+			prng, err := sampling.NewKeyedPRNG(e.Data)
+			if err != nil {
+				return err
+			}
+			crp, crpFound = ckg.SampleCRP(prng), true
+		case TranscriptPublicKeyShare:
+			if !crpFound {
+				return fmt.Errorf("%s before the CRS seed", e)
+			}
+			share, err := DecodePublicKeyGenShare(r.params, e.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e, err)
+			}
+			if sk, ok := r.sks[e.Party]; ok {
+				r.report.Checks++
+				if err := CheckPublicKeyGenShare(r.params, crp, sk, share); err != nil {
+					r.deviate(e, "%v", err)
+				}
+			}
+			ckg.AggregateShares(share, agg, &agg)
+		case TranscriptPublicKey:
+			if !crpFound {
+				return fmt.Errorf("%s before the CRS seed", e)
+			}
+			pk := rlwe.NewPublicKey(r.params)
+			ckg.GenPublicKey(agg, crp, pk)
+			if err := r.check(e, pk, "public key of the aggregated shares"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r replayer) decryptionRound(entries []TranscriptEntry) error {
+	cts := map[int]*rlwe.Ciphertext{}
+	var sum *rlwe.Plaintext
+
+	// the aggregation of the partial decryptions and the final decryption do not depend on the secret key
+	dec := rlwe.NewDecryptor(r.params, rlwe.NewSecretKey(r.params))
+
+	for _, e := range entries {
+		switch e.Kind {
+		case TranscriptCiphertext:
+			ct, err := DecodeCiphertext(r.params, e.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e, err)
+			}
+			cts[e.Party] = ct
+		case TranscriptPartialDecryption:
+			ct, ok := cts[e.Party]
+			if !ok {
+				if ct, ok = cts[-1]; !ok {
+					return fmt.Errorf("%s before the ciphertext", e)
+				}
+			}
+			ptpart, err := DecodePartialDecryption(r.params, ct.Level(), e.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e, err)
+			}
+			if sum != nil && sum.Level() != ptpart.Level() {
+				return fmt.Errorf("%s: at level %d, the other partial decryptions are at level %d", e, ptpart.Level(), sum.Level())
+			}
+			if sk, ok := r.sks[e.Party]; ok {
+				want := heint.NewPlaintext(r.params, ct.Level())
+				rlwe.NewDecryptor(r.params, sk).Decryptpart(ct, want)
+				if err := r.check(e, want, "partial decryption recomputed with the party's key"); err != nil {
+					return err
+				}
+			}
+			if sum == nil {
+				sum = ptpart
+			} else {
+				dec.Decryptadd(ptpart, sum)
+			}
+		case TranscriptDecryptionSum:
+			if sum == nil {
+				return fmt.Errorf("%s before the partial decryptions", e)
+			}
+			if err := r.check(e, sum, "sum of the partial decryptions"); err != nil {
+				return err
+			}
+			// the replay continues from the recorded sum, so that a deviation is only reported once
+			recorded, err := DecodePartialDecryption(r.params, sum.Level(), e.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e, err)
+			}
+			sum = recorded
+		case TranscriptDecryption:
+			ct, ok := cts[-1]
+			if !ok {
+				if ct, ok = cts[e.Party]; !ok {
+					return fmt.Errorf("%s before the ciphertext", e)
+				}
+			}
+			if sum == nil {
+				return fmt.Errorf("%s before the partial decryptions", e)
+			}
+			if sum.Level() != ct.Level() {
+				return fmt.Errorf("%s: the partial decryptions are at level %d, the ciphertext at level %d", e, sum.Level(), ct.Level())
+			}
+			dec.Decryptall(ct, sum)
+			pt, err := DecodePartialDecryption(r.params, ct.Level(), e.Data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e, err)
+			}
+			// the scale may be set by the caller after the decryption, only the coefficients are compared
+			r.report.Checks++
+			if !sum.Value.Equal(&pt.Value) {
+				r.deviate(e, "differs from the decryption of the sum of the partial decryptions")
+			}
+		}
+	}
+	return nil
+}
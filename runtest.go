@@ -0,0 +1,50 @@
+package examples
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// RunTable runs every case of the table test of an example program as a subtest named after the fields of the case,
+// e.g. "N=3/t=2": it runs the program on the configuration of the case, fails the subtest if the run fails and
+// checks the result otherwise.
+func RunTable[C, R any](t *testing.T, cases []C, run func(C) (R, error), check func(t *testing.T, c C, out R)) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(caseName(c), func(t *testing.T) {
+			out, err := run(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, c, out)
+		})
+	}
+}
+
+// caseName returns the fields of c as "name=value" separated by slashes.
+func caseName(c any) string {
+	return strings.NewReplacer(" ", "/", ":", "=").Replace(strings.Trim(fmt.Sprintf("%+v", c), "{}"))
+}
+
+// CheckDecryption checks the decryptions of the programs that encrypt one input per party and add two of them:
+// decrypted[i] must be inputs[i], modulo T, if partiesOK and differ from it otherwise, and sum must be the sum of the
+// inputs of the two summands if sumOK and differ from it otherwise, a decryption by too few parties being expected to
+// fail.
+func CheckDecryption(t testing.TB, T uint64, inputs, decrypted [][]uint64, summands [2]int, sum []uint64, partiesOK, sumOK bool) {
+	t.Helper()
+	for i, want := range inputs {
+		if got := decrypted[i]; reflect.DeepEqual(got, want) != partiesOK {
+			t.Errorf("party %d: decrypted %v..., want %v... (correct: %v)", i, got[:4], want[:4], partiesOK)
+		}
+	}
+	a, b := summands[0], summands[1]
+	want := make([]uint64, len(sum))
+	for k := range want {
+		want[k] = (inputs[a][k] + inputs[b][k]) % T
+	}
+	if reflect.DeepEqual(sum, want) != sumOK {
+		t.Errorf("ctadd: decrypted %v..., want %v... (correct: %v)", sum[:4], want[:4], sumOK)
+	}
+}
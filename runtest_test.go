@@ -0,0 +1,14 @@
+package examples
+
+import "testing"
+
+func TestCaseName(t *testing.T) {
+	tc := struct {
+		N, t int
+		mode string
+		ok   bool
+	}{N: 5, t: 3, mode: PSIProduct, ok: true}
+	if got, want := caseName(tc), "N=5/t=3/mode=product/ok=true"; got != want {
+		t.Errorf("caseName: %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package examples
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+)
+
+// SecurityEstimate is the estimated security of a parameter set against the primal uSVP attack on its RLWE instance.
+type SecurityEstimate struct {
+	LogN  int
+	LogQP float64
+
+	// Standard is the highest security level of the Homomorphic Encryption Standard (128, 192 or 256) whose
+	// bound on logQP the parameters meet, 0 if none.
+	Standard int
+
+	// Beta is the smallest BKZ block size for which the primal uSVP attack succeeds.
+	Beta int
+
+	// CoreSVP is the core-SVP hardness 0.292*Beta, i.e. the log2 of the cost of a single call to a sieving
+	// SVP oracle in dimension Beta.
+	CoreSVP float64
+
+	// Bits is the log2 of the cost of the attack in the model used to derive the bounds of the HE standard:
+	// CoreSVP + log2(8d) + 16.4, where d is the dimension of the embedding lattice.
+	Bits float64
+}
+
+func (s SecurityEstimate) String() string {
+	standard := "below the HE standard"
+	if s.Standard > 0 {
+		standard = fmt.Sprintf("HE standard %d bits", s.Standard)
+	}
+	return fmt.Sprintf("LogN=%d logQP=%.2f: %s, primal uSVP beta=%d, core-SVP %.2f bits, estimated %.2f bits", s.LogN, s.LogQP, standard, s.Beta, s.CoreSVP, s.Bits)
+}
+
+// Meets returns true if both estimates reach the given number of bits. Bits is allowed to fall short by
+// securityEstimateSlack, since at the bounds of the HE standard the two models only agree up to rounding.
+func (s SecurityEstimate) Meets(bits int) bool {
+	return s.Standard >= bits && s.Bits >= float64(bits)-securityEstimateSlack
+}
+
+const securityEstimateSlack = 1
+
+// EstimateSecurity estimates the security of params. The key-switching modulus P is included, since the
+// evaluation keys are RLWE samples modulo QP.
+//
+// The attack is the primal uSVP attack in the 2016 estimate: BKZ with block size beta succeeds on the embedding
+// lattice of dimension d = m + n + 1 if sigma*sqrt(beta) <= delta(beta)^(2beta-d-1) * Vol^(1/d), where the
+// secret coordinates are rescaled to the standard deviation sigma of the error and m is chosen by the attacker.
+func EstimateSecurity(params rlwe.ParameterProvider) SecurityEstimate {
+	p := params.GetRLWEParameters()
+
+	s := SecurityEstimate{
+		LogN:  p.LogN(),
+		LogQP: p.LogQP(),
+	}
+
+	for _, security := range []int{256, 192, 128} {
+		if maxLogQP, ok := HEStandardMaxLogQP(security, s.LogN); ok && s.LogQP <= float64(maxLogQP) {
+			s.Standard = security
+			break
+		}
+	}
+
+	var d int
+	s.Beta, d = primalUSVP(p.N(), s.LogQP, sigma(p), math.Sqrt(secretVariance(p)))
+	s.CoreSVP = 0.292 * float64(s.Beta)
+	s.Bits = s.CoreSVP + math.Log2(8*float64(d)) + 16.4
+	return s
+}
+
+// primalUSVP returns the smallest block size for which the primal uSVP attack succeeds on RLWE of dimension n,
+// modulus 2^logQ and error of standard deviation sigma with secret of standard deviation secretSigma,
+// together with the dimension of the embedding lattice. It returns n if no block size up to n succeeds.
+func primalUSVP(n int, logQ, sigma, secretSigma float64) (beta, d int) {
+	lnQ := logQ * math.Ln2
+	lnNu := math.Log(sigma / secretSigma)
+
+	// succeeds returns the best embedding dimension for beta, or 0 if the attack fails
+	succeeds := func(beta int) int {
+		lnDelta := math.Log(rootHermiteFactor(beta))
+		lhs := math.Log(sigma) + 0.5*math.Log(float64(beta))
+		best, bestD := math.Inf(-1), 0
+		for m := max(n/4, 1); m <= 2*n; m += max(n/64, 1) {
+			d := m + n + 1
+			rhs := float64(2*beta-d-1)*lnDelta + (float64(m)*lnQ+float64(n)*lnNu)/float64(d)
+			if rhs > best {
+				best, bestD = rhs, d
+			}
+		}
+		if lhs <= best {
+			return bestD
+		}
+		return 0
+	}
+
+	// the attack succeeds for all block sizes above the smallest successful one
+	lo, hi := minBlockSize, n
+	if d = succeeds(hi); d == 0 {
+		return n, 2*n + 1
+	}
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if succeeds(mid) > 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, succeeds(lo)
+}
+
+// minBlockSize is the smallest block size considered, below which the root Hermite factor estimate is not meaningful.
+const minBlockSize = 40
+
+// rootHermiteFactor is the root Hermite factor delta(beta) = ((pi*beta)^(1/beta) * beta/(2*pi*e))^(1/(2(beta-1)))
+// achieved by BKZ with block size beta.
+func rootHermiteFactor(beta int) float64 {
+	b := float64(beta)
+	return math.Pow(math.Pow(math.Pi*b, 1/b)*b/(2*math.Pi*math.E), 1/(2*(b-1)))
+}
@@ -0,0 +1,95 @@
+package examples
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+// SeededRandomness derives all the randomness of a simulated multiparty run from a master seed, so that two runs with
+// the same seed produce byte-identical keys, shares and ciphertexts, e.g. for golden-file tests.
+//
+// INSECURE: anyone who knows the master seed knows every secret of the run. It must never be used outside of tests
+// and reproducible demonstrations.
+//
+// The methods of a nil *SeededRandomness fall back to fresh system randomness, so that the same code serves both modes.
+type SeededRandomness struct {
+	seed []byte
+}
+
+// NewSeededRandomness returns a SeededRandomness for the given master seed.
+func NewSeededRandomness(seed []byte) *SeededRandomness {
+	return &SeededRandomness{seed: append([]byte{}, seed...)}
+}
+
+// key derives the key of the stream of the given party and label: SHA-256(seed || party || label).
+// Party -1 denotes the public randomness, e.g. the CRS or the randomness of an aggregator.
+func (s *SeededRandomness) key(party int, label string) []byte {
+	h := sha256.New()
+	h.Write(s.seed)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(int64(party)))
+	h.Write(buf[:])
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
+// Seed returns a 32-byte seed for the given party and label, e.g. the seed of a CRS, or a fresh random seed if s is nil.
+func (s *SeededRandomness) Seed(party int, label string) []byte {
+	if s == nil {
+		seed := make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			panic(err)
+		}
+		return seed
+	}
+	return s.key(party, label)
+}
+
+// PRNG returns a PRNG keyed for the given party and label, or a freshly keyed PRNG if s is nil.
+func (s *SeededRandomness) PRNG(party int, label string) (sampling.PRNG, error) {
+	return sampling.NewKeyedPRNG(s.Seed(party, label))
+}
+
+// With calls f with the system randomness replaced by the stream of the given party and label, and restores it
+// before returning. The Lattigo objects that f creates, such as a KeyGenerator, an Encryptor, a Thresholdizer or a
+// protocol instance, draw the keys of their internal PRNGs from it when they are created, so every randomness they
+// sample afterwards derives from the master seed. They accept no PRNG of their own, hence the swap.
+//
+// It calls f directly if s is nil. The swaps are serialized, but anything else reading crypto/rand concurrently, e.g.
+// a TLS handshake, would read the stream of the seed: it panics after RefuseSeededRandomness.
+func (s *SeededRandomness) With(party int, label string, f func()) {
+	if s == nil {
+		f()
+		return
+	}
+	if refused.Load() {
+		panic("examples: seeded randomness in a binary that serves the network")
+	}
+	prng, err := sampling.NewKeyedPRNG(s.key(party, label))
+	if err != nil {
+		panic(err)
+	}
+	withMu.Lock()
+	defer withMu.Unlock()
+	reader := rand.Reader
+	rand.Reader = prng
+	defer func() { rand.Reader = reader }()
+	f()
+}
+
+// withMu serializes the swaps of crypto/rand.Reader by With.
+var withMu sync.Mutex
+
+// refused is set by RefuseSeededRandomness.
+var refused atomic.Bool
+
+// RefuseSeededRandomness makes With panic for the rest of the process. Binaries that serve the network call it at
+// startup, so that no seeded stream can ever replace the randomness of their connections.
+func RefuseSeededRandomness() {
+	refused.Store(true)
+}
@@ -0,0 +1,89 @@
+package examples
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+)
+
+func TestSeededRandomness(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// run derives a secret key, a Shamir polynomial, a public-key share and a ciphertext for the given party
+	run := func(seeded *SeededRandomness, party int) []byte {
+		var sk *rlwe.SecretKey
+		seeded.With(party, "keygen", func() { sk = rlwe.NewKeyGenerator(params).GenSecretKeyNew() })
+
+		var thresholdizer mhe.Thresholdizer
+		seeded.With(party, "thresholdizer", func() { thresholdizer = mhe.NewThresholdizer(params) })
+		poly, err := thresholdizer.GenShamirPolynomial(2, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shamirShare := thresholdizer.AllocateThresholdSecretShare()
+		thresholdizer.GenShamirSecretShare(mhe.ShamirPublicPoint(1), poly, &shamirShare)
+
+		var ckg mhe.PublicKeyGenProtocol
+		seeded.With(-1, "ckg", func() { ckg = mhe.NewPublicKeyGenProtocol(params) })
+		crs, err := seeded.PRNG(-1, "crs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		crp := ckg.SampleCRP(crs)
+		share := ckg.AllocateShare()
+		ckg.GenShare(sk, crp, &share)
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(share, crp, pk)
+
+		var encryptor *rlwe.Encryptor
+		seeded.With(party, "encrypt", func() { encryptor = rlwe.NewEncryptor(params, pk) })
+		ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+		if err := encryptor.EncryptZero(ct); err != nil {
+			t.Fatal(err)
+		}
+
+		var out []byte
+		for _, v := range []interface{ MarshalBinary() ([]byte, error) }{sk, &shamirShare, &share, ct} {
+			data, err := v.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, data...)
+		}
+		return out
+	}
+
+	seed := []byte("golden")
+	if !bytes.Equal(run(NewSeededRandomness(seed), 0), run(NewSeededRandomness(seed), 0)) {
+		t.Fatal("two runs with the same seed differ")
+	}
+	if bytes.Equal(run(NewSeededRandomness(seed), 0), run(NewSeededRandomness(seed), 1)) {
+		t.Fatal("two parties derive the same randomness")
+	}
+	if bytes.Equal(run(nil, 0), run(nil, 0)) {
+		t.Fatal("two unseeded runs are equal")
+	}
+
+	// a binary that serves the network refuses the seeded streams, not the system randomness
+	RefuseSeededRandomness()
+	defer refused.Store(false)
+	var called bool
+	(*SeededRandomness)(nil).With(0, "keygen", func() { called = true })
+	if !called {
+		t.Error("With of a nil SeededRandomness did not call f")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("With after RefuseSeededRandomness: no panic")
+			}
+		}()
+		NewSeededRandomness(seed).With(0, "keygen", func() {})
+	}()
+}
@@ -0,0 +1,194 @@
+package examples
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+// StatisticsInput is the encrypted contribution of a party to the statistics. Both vectors have one entry per slot:
+// Values holds the party's value, zero in the slots it does not contribute to, and Count holds one in the slots it
+// contributes to, zero elsewhere.
+type StatisticsInput struct {
+	Values *rlwe.Ciphertext
+	Count  *rlwe.Ciphertext
+}
+
+// EncryptStatisticsInput encodes and encrypts the values of a party. If present is not nil, the party only contributes
+// to the slots where it is true.
+func EncryptStatisticsInput(params heint.Parameters, encryptor *rlwe.Encryptor, values []uint64, present []bool) (in StatisticsInput, err error) {
+	if present != nil && len(present) != len(values) {
+		return in, fmt.Errorf("cannot EncryptStatisticsInput: %d values but %d presence flags", len(values), len(present))
+	}
+	masked := make([]uint64, len(values))
+	count := make([]uint64, len(values))
+	for k, v := range values {
+		if present == nil || present[k] {
+			masked[k], count[k] = v, 1
+		}
+	}
+	if in.Values, err = encryptVector(params, encryptor, masked); err != nil {
+		return in, fmt.Errorf("cannot EncryptStatisticsInput: %w", err)
+	}
+	if in.Count, err = encryptVector(params, encryptor, count); err != nil {
+		return in, fmt.Errorf("cannot EncryptStatisticsInput: %w", err)
+	}
+	return
+}
+
+func encryptVector(params heint.Parameters, encryptor *rlwe.Encryptor, values []uint64) (*rlwe.Ciphertext, error) {
+	pt := heint.NewPlaintext(params, params.MaxLevel())
+	if err := heint.NewEncoder(params).Encode(values, pt); err != nil {
+		return nil, err
+	}
+	return encryptor.EncryptNew(pt)
+}
+
+// StatisticsMaxValue returns the largest value the given number of parties can contribute without the encrypted sum of
+// squares wrapping around the plaintext modulus. Larger values make every aggregate of the slot meaningless.
+func StatisticsMaxValue(params heint.Parameters, parties int) uint64 {
+	v := uint64(math.Sqrt(float64(params.PlaintextModulus()-1) / float64(parties)))
+	for v > 0 && v*v*uint64(parties) >= params.PlaintextModulus() {
+		v--
+	}
+	return v
+}
+
+// EncryptedStatistics are the encrypted aggregates of the parties' inputs, the only ciphertexts to decrypt.
+type EncryptedStatistics struct {
+	Sum          *rlwe.Ciphertext
+	Count        *rlwe.Ciphertext
+	SumOfSquares *rlwe.Ciphertext
+}
+
+// StatisticsEvaluator aggregates encrypted inputs under the collective public key.
+// The sum of squares multiplies ciphertexts, which needs the collective relinearization key.
+type StatisticsEvaluator struct {
+	eval *heint.Evaluator
+}
+
+// NewStatisticsEvaluator returns a StatisticsEvaluator using the collective relinearization key rlk.
+func NewStatisticsEvaluator(params heint.Parameters, rlk *rlwe.RelinearizationKey) *StatisticsEvaluator {
+	return &StatisticsEvaluator{eval: heint.NewEvaluator(params, rlwe.NewMemEvaluationKeySet(rlk))}
+}
+
+// Aggregate returns the encrypted sum, count and sum of squares of the inputs, slot by slot.
+func (s *StatisticsEvaluator) Aggregate(inputs []StatisticsInput) (agg EncryptedStatistics, err error) {
+	if len(inputs) == 0 {
+		return agg, fmt.Errorf("cannot Aggregate: no inputs")
+	}
+	agg.Sum = inputs[0].Values.CopyNew()
+	agg.Count = inputs[0].Count.CopyNew()
+	if agg.SumOfSquares, err = s.eval.MulRelinNew(inputs[0].Values, inputs[0].Values); err != nil {
+		return agg, fmt.Errorf("cannot Aggregate: %w", err)
+	}
+	for i, in := range inputs[1:] {
+		if err = s.eval.Add(agg.Sum, in.Values, agg.Sum); err != nil {
+			return agg, fmt.Errorf("cannot Aggregate: input %d: %w", i+1, err)
+		}
+		if err = s.eval.Add(agg.Count, in.Count, agg.Count); err != nil {
+			return agg, fmt.Errorf("cannot Aggregate: input %d: %w", i+1, err)
+		}
+		square, err := s.eval.MulRelinNew(in.Values, in.Values)
+		if err != nil {
+			return agg, fmt.Errorf("cannot Aggregate: input %d: %w", i+1, err)
+		}
+		if err = s.eval.Add(agg.SumOfSquares, square, agg.SumOfSquares); err != nil {
+			return agg, fmt.Errorf("cannot Aggregate: input %d: %w", i+1, err)
+		}
+	}
+	return
+}
+
+// Histogram returns the encrypted histogram of the parties' values: the sum, bucket by bucket, of the encrypted
+// indicator vectors of EncryptHistogramInput. The k-th ciphertext counts, in each slot, the values in the k-th bucket.
+func (s *StatisticsEvaluator) Histogram(inputs [][]*rlwe.Ciphertext) ([]*rlwe.Ciphertext, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("cannot Histogram: no inputs")
+	}
+	hist := make([]*rlwe.Ciphertext, len(inputs[0]))
+	for k, ct := range inputs[0] {
+		hist[k] = ct.CopyNew()
+	}
+	for i, in := range inputs[1:] {
+		if len(in) != len(hist) {
+			return nil, fmt.Errorf("cannot Histogram: input %d has %d buckets, expected %d", i+1, len(in), len(hist))
+		}
+		for k, ct := range in {
+			if err := s.eval.Add(hist[k], ct, hist[k]); err != nil {
+				return nil, fmt.Errorf("cannot Histogram: input %d: %w", i+1, err)
+			}
+		}
+	}
+	return hist, nil
+}
+
+// HistogramBuckets returns one indicator vector per bucket [edges[k], edges[k+1]): the k-th vector is one in the slots
+// whose value falls in the k-th bucket. Slots that are not present, or whose value is outside
+// [edges[0], edges[len(edges)-1]), are in no bucket.
+func HistogramBuckets(values []uint64, present []bool, edges []uint64) [][]uint64 {
+	if len(edges) < 2 {
+		return nil
+	}
+	buckets := make([][]uint64, len(edges)-1)
+	for k := range buckets {
+		buckets[k] = make([]uint64, len(values))
+	}
+	for j, v := range values {
+		if present != nil && !present[j] {
+			continue
+		}
+		for k := range buckets {
+			if edges[k] <= v && v < edges[k+1] {
+				buckets[k][j] = 1
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+// EncryptHistogramInput encrypts the indicator vectors of HistogramBuckets. The sum of the parties' indicator vectors
+// is the histogram of each slot, so no party reveals more than the aggregate counts.
+func EncryptHistogramInput(params heint.Parameters, encryptor *rlwe.Encryptor, values []uint64, present []bool, edges []uint64) ([]*rlwe.Ciphertext, error) {
+	if present != nil && len(present) != len(values) {
+		return nil, fmt.Errorf("cannot EncryptHistogramInput: %d values but %d presence flags", len(values), len(present))
+	}
+	buckets := HistogramBuckets(values, present, edges)
+	if buckets == nil {
+		return nil, fmt.Errorf("cannot EncryptHistogramInput: %d edges, expected at least 2", len(edges))
+	}
+	cts := make([]*rlwe.Ciphertext, len(buckets))
+	for k, indicator := range buckets {
+		var err error
+		if cts[k], err = encryptVector(params, encryptor, indicator); err != nil {
+			return nil, fmt.Errorf("cannot EncryptHistogramInput: %w", err)
+		}
+	}
+	return cts, nil
+}
+
+// Statistics are the decrypted aggregates of a slot vector and the statistics derived from them.
+// Mean and Variance are NaN in the slots no party contributed to.
+type Statistics struct {
+	Sum, Count, SumOfSquares []uint64
+	Mean, Variance           []float64 // the variance is the population variance
+}
+
+// NewStatistics derives the mean and the variance from the decrypted aggregates.
+func NewStatistics(sum, count, sumOfSquares []uint64) Statistics {
+	s := Statistics{Sum: sum, Count: count, SumOfSquares: sumOfSquares, Mean: make([]float64, len(sum)), Variance: make([]float64, len(sum))}
+	for k := range sum {
+		if count[k] == 0 {
+			s.Mean[k], s.Variance[k] = math.NaN(), math.NaN()
+			continue
+		}
+		// (n*Q - S^2) / n^2 is exact in integers, unlike Q/n - (S/n)^2
+		n := count[k]
+		s.Mean[k] = float64(sum[k]) / float64(n)
+		s.Variance[k] = float64(int64(n*sumOfSquares[k])-int64(sum[k]*sum[k])) / float64(n*n)
+	}
+	return s
+}
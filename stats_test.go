@@ -0,0 +1,36 @@
+package examples
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+)
+
+func TestStatistics(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, parties := range []int{1, 3, 40, 1000} {
+		v := StatisticsMaxValue(params, parties)
+		if T := params.PlaintextModulus(); v*v*uint64(parties) >= T || (v+1)*(v+1)*uint64(parties) < T {
+			t.Errorf("StatisticsMaxValue(%d parties) = %d, not the largest value whose sum of squares is below %d", parties, v, T)
+		}
+	}
+
+	// slot 0: three parties with the values 2, 4 and 6; slot 1: a single party; slot 2: no party
+	s := NewStatistics([]uint64{12, 5, 0}, []uint64{3, 1, 0}, []uint64{56, 25, 0})
+	if s.Mean[0] != 4 || s.Variance[0] != 8.0/3 || s.Mean[1] != 5 || s.Variance[1] != 0 {
+		t.Errorf("mean %v, variance %v, want [4 5 NaN] and [8/3 0 NaN]", s.Mean, s.Variance)
+	}
+	if !math.IsNaN(s.Mean[2]) || !math.IsNaN(s.Variance[2]) {
+		t.Errorf("slot without contributions: mean %v, variance %v, want NaN", s.Mean[2], s.Variance[2])
+	}
+
+	buckets := HistogramBuckets([]uint64{0, 4, 5, 9, 10, 3}, []bool{true, true, true, true, true, false}, []uint64{0, 5, 10})
+	if want := [][]uint64{{1, 1, 0, 0, 0, 0}, {0, 0, 1, 1, 0, 0}}; !reflect.DeepEqual(buckets, want) {
+		t.Errorf("HistogramBuckets: %v, want %v", buckets, want)
+	}
+}
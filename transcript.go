@@ -0,0 +1,153 @@
+package examples
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Kinds of the entries of a transcript.
+const (
+	TranscriptPhase             = "phase"              // a phase boundary, Round holds the name of the phase
+	TranscriptParams            = "params"             // the ParametersConfig, JSON-encoded
+	TranscriptSecretKey         = "secret-key"         // a party's secret key, only known in simulation
+	TranscriptCRSSeed           = "crs-seed"           // the seed of the CRS of a public-key round
+	TranscriptPublicKeyShare    = "pk-share"           // a party's share of a public-key round
+	TranscriptPublicKey         = "pk"                 // the public key obtained from the aggregated shares
+	TranscriptCiphertext        = "ciphertext"         // the ciphertext of a decryption round; with a party, the ciphertext that party decrypts
+	TranscriptPartialDecryption = "partial-decryption" // a party's partial decryption
+	TranscriptDecryptionSum     = "decryption-sum"     // the aggregated partial decryptions
+	TranscriptDecryption        = "decryption"         // the decrypted plaintext
+)
+
+// TranscriptEntry is a message of a multiparty run.
+type TranscriptEntry struct {
+	Seq   int    // position in the transcript
+	Phase string // phase the entry was recorded in
+	Round string // protocol round the entry belongs to, e.g. "pk" or "decrypt/3"
+	Kind  string
+	Party int // sending party, -1 for an aggregator or the public
+	Data  []byte
+}
+
+func (e TranscriptEntry) String() string {
+	party := "-"
+	if e.Party >= 0 {
+		party = fmt.Sprint(e.Party)
+	}
+	return fmt.Sprintf("#%d [%s] %s %s party %s (%d B)", e.Seq, e.Phase, e.Round, e.Kind, party, len(e.Data))
+}
+
+// Transcript records the messages of a multiparty run to a file, as a stream of gob-encoded TranscriptEntry.
+//
+// All methods are no-ops on a nil *Transcript, so that the recording can be left in place when it is disabled.
+// Errors are sticky: the first one stops the recording and is returned by Close.
+//
+// A transcript of a simulation contains the secret keys of all the parties and must be handled accordingly.
+type Transcript struct {
+	f     *os.File
+	w     *bufio.Writer
+	enc   *gob.Encoder
+	seq   int
+	phase string
+	err   error
+}
+
+// CreateTranscript creates the file at path and returns a Transcript recording to it.
+func CreateTranscript(path string) (*Transcript, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &Transcript{f: f, w: w, enc: gob.NewEncoder(w)}, nil
+}
+
+// Phase records a phase boundary; the following entries belong to the phase.
+func (t *Transcript) Phase(name string) {
+	if t == nil {
+		return
+	}
+	t.phase = name
+	t.RecordBytes(name, TranscriptPhase, -1, nil)
+}
+
+// Params records the parameters of the run, from which a replay instantiates them.
+func (t *Transcript) Params(config ParametersConfig) {
+	if t == nil {
+		return
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	t.RecordBytes("", TranscriptParams, -1, data)
+}
+
+// Record records the binary encoding of v.
+func (t *Transcript) Record(round, kind string, party int, v encoding.BinaryMarshaler) {
+	if t == nil || t.err != nil {
+		return
+	}
+	data, err := v.MarshalBinary()
+	if err != nil {
+		t.fail(fmt.Errorf("cannot record %s %s of party %d: %w", round, kind, party, err))
+		return
+	}
+	t.RecordBytes(round, kind, party, data)
+}
+
+// RecordBytes records raw data.
+func (t *Transcript) RecordBytes(round, kind string, party int, data []byte) {
+	if t == nil || t.err != nil {
+		return
+	}
+	if err := t.enc.Encode(TranscriptEntry{Seq: t.seq, Phase: t.phase, Round: round, Kind: kind, Party: party, Data: data}); err != nil {
+		t.fail(err)
+		return
+	}
+	t.seq++
+}
+
+func (t *Transcript) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+// Close flushes and closes the transcript file and returns the first error of the recording.
+func (t *Transcript) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.fail(t.w.Flush())
+	t.fail(t.f.Close())
+	return t.err
+}
+
+// ReadTranscript reads all the entries of a transcript file.
+func ReadTranscript(path string) (entries []TranscriptEntry, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		var e TranscriptEntry
+		if err = dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return entries, fmt.Errorf("cannot ReadTranscript: entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,105 @@
+package examples
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v5/core/rlwe"
+	"github.com/tuneinsight/lattigo/v5/he/heint"
+	"github.com/tuneinsight/lattigo/v5/mhe"
+	"github.com/tuneinsight/lattigo/v5/utils/sampling"
+)
+
+func TestReplayTranscript(t *testing.T) {
+	params, err := heint.NewParametersFromLiteral(HEIntParamsN12QP109)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// record a 4-party run: collective key generation and decryption of one ciphertext
+	record := func(path string, tamper int) {
+		tr, err := CreateTranscript(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Params(HEIntConfig("", HEIntParamsN12QP109))
+
+		kgen := rlwe.NewKeyGenerator(params)
+		sks := make([]*rlwe.SecretKey, 4)
+		for i := range sks {
+			sks[i] = kgen.GenSecretKeyNew()
+			tr.Record("keys", TranscriptSecretKey, i, sks[i])
+		}
+
+		tr.Phase("pk")
+		seed := make([]byte, 32)
+		prng, err := sampling.NewKeyedPRNG(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.RecordBytes("pk", TranscriptCRSSeed, -1, seed)
+		ckg := mhe.NewPublicKeyGenProtocol(params)
+		crp := ckg.SampleCRP(prng)
+		share, agg := ckg.AllocateShare(), ckg.AllocateShare()
+		for i, sk := range sks {
+			ckg.GenShare(sk, crp, &share)
+			tr.Record("pk", TranscriptPublicKeyShare, i, &share)
+			ckg.AggregateShares(share, agg, &agg)
+		}
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(agg, crp, pk)
+		tr.Record("pk", TranscriptPublicKey, -1, pk)
+
+		tr.Phase("decrypt")
+		ct := heint.NewCiphertext(params, 1, params.MaxLevel())
+		if err := rlwe.NewEncryptor(params, pk).EncryptZero(ct); err != nil {
+			t.Fatal(err)
+		}
+		tr.Record("decrypt", TranscriptCiphertext, -1, ct)
+		dec := rlwe.NewDecryptor(params, sks[0])
+		sum := heint.NewPlaintext(params, ct.Level())
+		for i, sk := range sks {
+			ptpart := heint.NewPlaintext(params, ct.Level())
+			rlwe.NewDecryptor(params, sk).Decryptpart(ct, ptpart)
+			if i == tamper {
+				ptpart.Value.Coeffs[0][0] ^= 1
+			}
+			tr.Record("decrypt", TranscriptPartialDecryption, i, ptpart)
+			dec.Decryptadd(ptpart, sum)
+		}
+		tr.Record("decrypt", TranscriptDecryptionSum, -1, sum)
+		dec.Decryptall(ct, sum)
+		tr.Record("decrypt", TranscriptDecryption, -1, sum)
+
+		if err := tr.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay := func(tamper int) ReplayReport {
+		path := filepath.Join(t.TempDir(), "transcript")
+		record(path, tamper)
+		entries, err := ReadTranscript(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := ReplayTranscript(entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	if report := replay(-1); len(report.Deviations) != 0 {
+		t.Fatalf("honest run: unexpected deviations %v", report.Deviations)
+	}
+
+	report := replay(2)
+	first, ok := report.First()
+	if !ok {
+		t.Fatal("tampered run: no deviation")
+	}
+	if first.Entry.Kind != TranscriptPartialDecryption || first.Entry.Party != 2 {
+		t.Fatalf("tampered run: first deviation is %s, expected the partial decryption of party 2", first)
+	}
+}
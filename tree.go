@@ -0,0 +1,81 @@
+package examples
+
+// AggregateTree aggregates the shares of the parties along a k-ary tree: the parent of the party at position i is the
+// party at position (i-1)/k, and every node adds the aggregates forwarded by its children to its own share before
+// forwarding the result to its parent, so that the root, at position 0, receives only k shares. With k < 2 the tree
+// degenerates into the linear aggregation of all the shares at a single aggregator.
+//
+// If alloc is nil, the shares are aggregated in place without allocation and the shares of the caller are
+// overwritten; otherwise every node aggregates into a share returned by alloc.
+func AggregateTree[T any](shares []T, k int, alloc func() T, add func(share T, acc *T)) T {
+	n := len(shares)
+	k = TreeArity(n, k)
+	acc := shares
+	if alloc != nil {
+		acc = make([]T, n)
+	}
+	for i := n - 1; i >= 0; i-- {
+		if alloc != nil {
+			acc[i] = alloc()
+			add(shares[i], &acc[i])
+		}
+		for _, c := range TreeChildren(n, k, i) {
+			add(acc[c], &acc[i])
+		}
+	}
+	return acc[0]
+}
+
+// AuditTree records in log the share every node forwards to its parent when the shares of the given kind are
+// aggregated by AggregateTree with arity k, parties[0] being the root. It is a no-op on a nil log.
+func AuditTree(log *AuditLog, round, kind string, parties []int, k int) {
+	if log == nil {
+		return
+	}
+	k = TreeArity(len(parties), k)
+	for c := 1; c < len(parties); c++ {
+		parent := parties[(c-1)/k]
+		log.ShareSent(round, kind, parties[c], parent)
+		log.ShareReceived(round, kind, parent, parties[c])
+	}
+}
+
+// TreeArity returns the arity of the aggregation tree of n parties requested with arity k: k, or n when k < 2 or
+// k > n, where the tree is the linear aggregation at the root.
+func TreeArity(n, k int) int {
+	if k < 2 || k > n {
+		return n
+	}
+	return k
+}
+
+// TreeChildren returns the positions of the children of the node at position i in the aggregation tree of n parties
+// with arity k.
+func TreeChildren(n, k, i int) []int {
+	k = TreeArity(n, k)
+	children := make([]int, 0, k)
+	for c := k*i + 1; c <= k*i+k && c < n; c++ {
+		children = append(children, c)
+	}
+	return children
+}
+
+// TreeDepth returns the depth of the aggregation tree of n parties with arity k, that is the number of forwarding
+// rounds for the shares to reach the root.
+func TreeDepth(n, k int) int {
+	k = TreeArity(n, k)
+	depth := 0
+	for last := n - 1; last > 0; last = (last - 1) / k {
+		depth++
+	}
+	return depth
+}
+
+// TreeRootInbound returns the number of shares the root of the aggregation tree of n parties with arity k receives.
+func TreeRootInbound(n, k int) int {
+	k = TreeArity(n, k)
+	if n-1 < k {
+		return n - 1
+	}
+	return k
+}
@@ -0,0 +1,76 @@
+package examples
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateTree(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		for k := 0; k <= n+1; k++ {
+			// every node records the positions whose shares it received, directly or aggregated
+			shares := make([][]int, n)
+			for i := range shares {
+				shares[i] = []int{i}
+			}
+			var rootInbound int
+			root := AggregateTree(shares, k, nil, func(share []int, acc *[]int) {
+				if acc == &shares[0] {
+					rootInbound++
+				}
+				*acc = append(*acc, share...)
+			})
+			if len(root) != n {
+				t.Errorf("n=%d, k=%d: the root aggregated %v", n, k, root)
+			}
+			if want := TreeRootInbound(n, k); rootInbound != want || len(TreeChildren(n, k, 0)) != want {
+				t.Errorf("n=%d, k=%d: the root received %d shares, want %d", n, k, rootInbound, want)
+			}
+
+			sum := AggregateTree(make([]int, n), k, func() int { return 0 }, func(share int, acc *int) { *acc += share + 1 })
+			if sum != n {
+				t.Errorf("n=%d, k=%d: aggregating with alloc counted %d shares", n, k, sum)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		n, k         int
+		arity, depth int
+	}{
+		{n: 1, k: 2, arity: 1, depth: 0},
+		{n: 10, k: 0, arity: 10, depth: 1},
+		{n: 10, k: 3, arity: 3, depth: 2},
+		{n: 40, k: 2, arity: 2, depth: 5},
+		{n: 3, k: 4, arity: 3, depth: 1},
+	} {
+		if arity, depth := TreeArity(tc.n, tc.k), TreeDepth(tc.n, tc.k); arity != tc.arity || depth != tc.depth {
+			t.Errorf("n=%d, k=%d: arity %d, depth %d, want %d, %d", tc.n, tc.k, arity, depth, tc.arity, tc.depth)
+		}
+	}
+
+	var buf bytes.Buffer
+	AuditTree(NewAuditLog(&buf, "tree"), "pk", TranscriptPublicKeyShare, []int{4, 2, 7, 1}, 2)
+	AuditTree(nil, "pk", TranscriptPublicKeyShare, []int{4, 2, 7, 1}, 2)
+	var received []float64
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e struct {
+			Msg   string  `json:"msg"`
+			Party float64 `json:"party"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Msg == AuditShareReceived {
+			received = append(received, e.Party)
+		}
+	}
+	// parties 2 and 7 forward to the root 4, party 1 to its parent 2
+	if want := []float64{4, 4, 2}; !reflect.DeepEqual(received, want) {
+		t.Errorf("shares received by %v, want %v", received, want)
+	}
+}